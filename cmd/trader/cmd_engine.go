@@ -0,0 +1,408 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// engineStatus mirrors the engine's status summary.
+type engineStatus struct {
+	Mode     string   `json:"mode"`
+	Leader   bool     `json:"leader"`
+	Accounts []string `json:"accounts"`
+	NGS      struct {
+		State string `json:"state"`
+		URL   string `json:"url"`
+		Error string `json:"error"`
+	} `json:"ngs"`
+	LastRiskLoop  time.Time `json:"last_risk_loop"`
+	KillSwitch    bool      `json:"kill_switch"`
+	TradingConfig struct {
+		Configs   int       `json:"configs"`
+		Slots     int       `json:"slots"`
+		FetchedAt time.Time `json:"fetched_at"`
+	} `json:"trading_config"`
+	Positions   int `json:"positions"`
+	Pending     int `json:"pending_entries"`
+	Pauses      int `json:"pauses"`
+	Breakers    int `json:"tripped_breakers"`
+	OutboxDepth int `json:"outbox_depth"`
+	DeadLetters int `json:"outbox_dead_letters"`
+	Queues      struct {
+		Workers  int   `json:"workers"`
+		Queued   int   `json:"queued"`
+		Dropped  int64 `json:"dropped"`
+		MaxLagMs int64 `json:"max_lag_ms"`
+	} `json:"signal_queues"`
+}
+
+// enginePosition mirrors the engine's in-memory position state.
+type enginePosition struct {
+	AccountID     string    `json:"account_id"`
+	Symbol        string    `json:"symbol"`
+	MarketType    string    `json:"market_type"`
+	Side          string    `json:"side"`
+	Strategy      string    `json:"strategy"`
+	Leverage      int       `json:"leverage"`
+	EntryPrice    float64   `json:"entry_price"`
+	Quantity      float64   `json:"quantity"`
+	StopLoss      float64   `json:"stop_loss"`
+	TakeProfit    float64   `json:"take_profit"`
+	HardStop      float64   `json:"hard_stop"`
+	TrailingStop  float64   `json:"trailing_stop"`
+	MarkPrice     float64   `json:"mark_price"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"`
+	OpenedAt      time.Time `json:"opened_at"`
+	Closing       bool      `json:"closing"`
+}
+
+type engineCooldowns struct {
+	Cooldowns []struct {
+		AccountID string    `json:"account_id"`
+		Symbol    string    `json:"symbol"`
+		Action    string    `json:"action"`
+		Until     time.Time `json:"until"`
+	} `json:"cooldowns"`
+	Conflicts []struct {
+		AccountID string `json:"account_id"`
+		Symbol    string `json:"symbol"`
+		Side      string `json:"side"`
+	} `json:"conflicts"`
+}
+
+type engineAllowlistEntry struct {
+	Exchange    string `json:"exchange"`
+	Product     string `json:"product"`
+	Granularity string `json:"granularity"`
+	Strategy    string `json:"strategy"`
+}
+
+type engineSignal struct {
+	Subject    string    `json:"subject"`
+	Action     string    `json:"action"`
+	Price      float64   `json:"price"`
+	Confidence float64   `json:"confidence"`
+	Timestamp  time.Time `json:"timestamp"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// enginePause mirrors the engine's operator pause.
+type enginePause struct {
+	AccountID string    `json:"account_id,omitempty"`
+	Strategy  string    `json:"strategy,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	PausedAt  time.Time `json:"paused_at"`
+}
+
+var engineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "Control the trading engine running in traderd",
+}
+
+var (
+	engineAccount  string
+	engineStrategy string
+	engineReason   string
+	engineConfirm  bool
+)
+
+// engineScope is the request body selecting the tenant (no flags), an
+// account, a strategy, or a strategy on an account.
+func engineScope() map[string]string {
+	return map[string]string{"account_id": engineAccount, "strategy": engineStrategy, "reason": engineReason}
+}
+
+// describeScope renders a pause scope for humans.
+func describeScope(accountID, strategy string) string {
+	switch {
+	case accountID == "" && strategy == "":
+		return "all accounts"
+	case strategy == "":
+		return "account " + accountID
+	case accountID == "":
+		return "strategy " + strategy + " on all accounts"
+	default:
+		return "strategy " + strategy + " on account " + accountID
+	}
+}
+
+// engineError replaces a traderd API error with the message in its body.
+func engineError(err error) error {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal([]byte(apiErr.Body), &body) == nil && body.Error != "" {
+			return errors.New(body.Error)
+		}
+	}
+	return err
+}
+
+// ---- status ----
+
+var engineStatusAllowlist bool
+
+var engineStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what the trading engine is doing",
+	Long: `Show the engine's connection and loop state, its open positions with their
+stops and mark prices, active cooldowns, the direction conflict guard and the
+last signal received per subject. --allowlist adds the signal allowlist.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := newClient()
+		var (
+			status    engineStatus
+			positions []enginePosition
+			cooldowns engineCooldowns
+			signals   []engineSignal
+			allowlist []engineAllowlistEntry
+		)
+		for path, out := range map[string]any{
+			"/api/v1/engine/status":    &status,
+			"/api/v1/engine/positions": &positions,
+			"/api/v1/engine/cooldowns": &cooldowns,
+			"/api/v1/engine/signals":   &signals,
+		} {
+			if err := c.Get(c.traderURL(path), out); err != nil {
+				return engineError(err)
+			}
+		}
+		if engineStatusAllowlist {
+			if err := c.Get(c.traderURL("/api/v1/engine/allowlist"), &allowlist); err != nil {
+				return engineError(err)
+			}
+		}
+
+		if useJSON, _ := cmd.Flags().GetBool("json"); useJSON {
+			return PrintJSON(map[string]any{
+				"status":    status,
+				"positions": positions,
+				"cooldowns": cooldowns.Cooldowns,
+				"conflicts": cooldowns.Conflicts,
+				"signals":   signals,
+				"allowlist": allowlist,
+			})
+		}
+		printEngineStatus(status)
+
+		fmt.Println("\nPositions")
+		rows := make([][]string, len(positions))
+		for i, p := range positions {
+			closing := ""
+			if p.Closing {
+				closing = "closing"
+			}
+			rows[i] = []string{
+				p.AccountID, p.Symbol, p.Side, p.Strategy, fmtLeverage(p.Leverage),
+				fmtQty(p.Quantity), fmtPrice(p.EntryPrice), fmtLevel(p.MarkPrice), fmtFloat2(p.UnrealizedPnL),
+				fmtLevel(p.StopLoss), fmtLevel(p.TakeProfit), fmtLevel(p.HardStop), fmtLevel(p.TrailingStop),
+				fmtTime(p.OpenedAt), closing,
+			}
+		}
+		PrintTable([]string{"ACCOUNT", "SYMBOL", "SIDE", "STRATEGY", "LEV", "QTY", "ENTRY", "MARK", "UPNL", "SL", "TP", "HARD-STOP", "TRAIL", "OPENED", ""}, rows)
+
+		fmt.Println("\nCooldowns")
+		rows = make([][]string, len(cooldowns.Cooldowns))
+		for i, cd := range cooldowns.Cooldowns {
+			rows[i] = []string{cd.AccountID, cd.Symbol, cd.Action, fmtTime(cd.Until), time.Until(cd.Until).Round(time.Second).String()}
+		}
+		PrintTable([]string{"ACCOUNT", "SYMBOL", "ACTION", "UNTIL", "REMAINING"}, rows)
+
+		fmt.Println("\nConflict guard")
+		rows = make([][]string, len(cooldowns.Conflicts))
+		for i, cf := range cooldowns.Conflicts {
+			rows[i] = []string{cf.AccountID, cf.Symbol, cf.Side}
+		}
+		PrintTable([]string{"ACCOUNT", "SYMBOL", "SIDE"}, rows)
+
+		fmt.Println("\nLast signals")
+		rows = make([][]string, len(signals))
+		for i, sg := range signals {
+			rows[i] = []string{sg.Subject, sg.Action, fmtFloat(sg.Price), fmtFloat2(sg.Confidence), fmtTime(sg.Timestamp), fmtTime(sg.ReceivedAt)}
+		}
+		PrintTable([]string{"SUBJECT", "ACTION", "PRICE", "CONF", "CANDLE", "RECEIVED"}, rows)
+
+		if engineStatusAllowlist {
+			fmt.Println("\nAllowlist")
+			rows = make([][]string, len(allowlist))
+			for i, a := range allowlist {
+				rows[i] = []string{a.Exchange, a.Product, a.Granularity, a.Strategy}
+			}
+			PrintTable([]string{"EXCHANGE", "PRODUCT", "GRANULARITY", "STRATEGY"}, rows)
+		}
+		return nil
+	},
+}
+
+// printEngineStatus renders the status summary as a field/value table.
+func printEngineStatus(st engineStatus) {
+	ngs := st.NGS.State
+	if st.NGS.URL != "" {
+		ngs += " (" + st.NGS.URL + ")"
+	}
+	if st.NGS.Error != "" {
+		ngs += " — " + st.NGS.Error
+	}
+	riskLoop := "never"
+	if !st.LastRiskLoop.IsZero() {
+		riskLoop = fmtTime(st.LastRiskLoop) + " (" + time.Since(st.LastRiskLoop).Round(time.Second).String() + " ago)"
+	}
+	PrintTable(
+		[]string{"FIELD", "VALUE"},
+		[][]string{
+			{"Mode", st.Mode},
+			{"Leader", fmtBool(st.Leader)},
+			{"Accounts", strings.Join(st.Accounts, ", ")},
+			{"NGS", ngs},
+			{"Last risk loop", riskLoop},
+			{"Kill switch", fmtBool(st.KillSwitch)},
+			{"Trading configs", fmt.Sprintf("%d configs, %d slots, fetched %s", st.TradingConfig.Configs, st.TradingConfig.Slots, fmtTime(st.TradingConfig.FetchedAt))},
+			{"Positions", strconv.Itoa(st.Positions)},
+			{"Pending entries", strconv.Itoa(st.Pending)},
+			{"Pauses", strconv.Itoa(st.Pauses)},
+			{"Tripped breakers", strconv.Itoa(st.Breakers)},
+			{"Outbox depth", strconv.Itoa(st.OutboxDepth)},
+			{"Outbox dead letters", strconv.Itoa(st.DeadLetters)},
+			{"Signal queues", fmt.Sprintf("%d workers, %d queued, %d dropped, max lag %dms", st.Queues.Workers, st.Queues.Queued, st.Queues.Dropped, st.Queues.MaxLagMs)},
+		},
+	)
+}
+
+// fmtLevel formats a price level, returning "-" for 0 (unset).
+func fmtLevel(f float64) string {
+	if f == 0 {
+		return "-"
+	}
+	return fmtPrice(f)
+}
+
+// ---- pause ----
+
+var enginePauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause new positions for the tenant, an account or a strategy",
+	Long: `Pause new positions until resumed. Without flags every account is paused;
+--account and --strategy narrow the scope. Closes and risk exits continue.
+The pause is persisted, so it survives restarts and applies on every replica.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := newClient()
+		var pause enginePause
+		if err := c.Post(c.traderURL("/api/v1/engine/pause"), engineScope(), &pause); err != nil {
+			return engineError(err)
+		}
+		if useJSON, _ := cmd.Flags().GetBool("json"); useJSON {
+			return PrintJSON(pause)
+		}
+		fmt.Printf("paused new positions for %s\n", describeScope(pause.AccountID, pause.Strategy))
+		return nil
+	},
+}
+
+// ---- resume ----
+
+var engineResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume new positions paused with `trader engine pause`",
+	Long: `Lift the pause on exactly the scope given by --account and --strategy.
+Pauses on wider or narrower scopes stay in place.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := newClient()
+		if err := c.Post(c.traderURL("/api/v1/engine/resume"), engineScope(), nil); err != nil {
+			if isNotFound(err) {
+				return fmt.Errorf("%s is not paused", describeScope(engineAccount, engineStrategy))
+			}
+			return engineError(err)
+		}
+		fmt.Printf("resumed new positions for %s\n", describeScope(engineAccount, engineStrategy))
+		return nil
+	},
+}
+
+// ---- pauses ----
+
+var enginePausesCmd = &cobra.Command{
+	Use:   "pauses",
+	Short: "List operator pauses",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := newClient()
+		var pauses []enginePause
+		if err := c.Get(c.traderURL("/api/v1/engine/pauses"), &pauses); err != nil {
+			return engineError(err)
+		}
+		if useJSON, _ := cmd.Flags().GetBool("json"); useJSON {
+			return PrintJSON(pauses)
+		}
+		if len(pauses) == 0 {
+			fmt.Println("no pauses — new positions are allowed")
+			return nil
+		}
+		rows := make([][]string, len(pauses))
+		for i, p := range pauses {
+			rows[i] = []string{describeScope(p.AccountID, p.Strategy), p.Reason, fmtTime(p.PausedAt)}
+		}
+		PrintTable([]string{"SCOPE", "REASON", "PAUSED"}, rows)
+		return nil
+	},
+}
+
+// ---- flatten ----
+
+var engineFlattenCmd = &cobra.Command{
+	Use:   "flatten",
+	Short: "Close every engine-managed position at market",
+	Long: `Close every position the engine manages, on all accounts or only on
+--account. Flattening does not stop new positions; run ` + "`trader engine pause`" + `
+first to keep the engine from re-entering.
+The --confirm flag is required to prevent accidental closes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !engineConfirm {
+			return fmt.Errorf("use --confirm to close all positions")
+		}
+		c := newClient()
+		var result struct {
+			Closed int `json:"closed"`
+		}
+		body := map[string]string{"account_id": engineAccount}
+		if err := c.Post(c.traderURL("/api/v1/engine/flatten"), body, &result); err != nil {
+			return engineError(err)
+		}
+		if useJSON, _ := cmd.Flags().GetBool("json"); useJSON {
+			return PrintJSON(result)
+		}
+		where := "all accounts"
+		if engineAccount != "" {
+			where = "account " + engineAccount
+		}
+		fmt.Printf("closed %d position(s) on %s\n", result.Closed, where)
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{enginePauseCmd, engineResumeCmd} {
+		cmd.Flags().StringVar(&engineAccount, "account", "", "Account to scope to (default: all accounts)")
+		cmd.Flags().StringVar(&engineStrategy, "strategy", "", "Strategy to scope to (default: all strategies)")
+	}
+	enginePauseCmd.Flags().StringVar(&engineReason, "reason", "", "Why new positions are paused")
+
+	engineFlattenCmd.Flags().StringVar(&engineAccount, "account", "", "Only flatten this account")
+	engineFlattenCmd.Flags().BoolVar(&engineConfirm, "confirm", false, "Required: confirm closing the positions")
+
+	engineStatusCmd.Flags().BoolVar(&engineStatusAllowlist, "allowlist", false, "Also list the signal allowlist")
+
+	engineCmd.AddCommand(engineStatusCmd, enginePauseCmd, engineResumeCmd, enginePausesCmd, engineFlattenCmd)
+	rootCmd.AddCommand(engineCmd)
+}
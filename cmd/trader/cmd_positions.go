@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
@@ -60,7 +61,84 @@ var positionsCmd = &cobra.Command{
 	},
 }
 
+// ---- engine overrides ----
+
+// positionLevelCmd builds a command that moves one exit level of an
+// engine-managed position through traderd.
+func positionLevelCmd(use, short, level, endpoint string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <account-id> <symbol> <price>",
+		Short: short,
+		Long: short + `.
+
+The engine applies the change to its in-memory state, persists it and moves
+the exchange-side order in live mode. Levels the market has already crossed
+are rejected.`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accountID, symbol := args[0], args[1]
+			price, err := strconv.ParseFloat(args[2], 64)
+			if err != nil || price <= 0 {
+				return fmt.Errorf("invalid price %q: must be a positive number", args[2])
+			}
+			c := newClient()
+			var ps enginePosition
+			path := "/api/v1/engine/positions/" + url.PathEscape(accountID) + "/" + url.PathEscape(symbol) + "/" + endpoint
+			if err := c.Post(c.traderURL(path), map[string]float64{"price": price}, &ps); err != nil {
+				return engineError(err)
+			}
+			if useJSON, _ := cmd.Flags().GetBool("json"); useJSON {
+				return PrintJSON(ps)
+			}
+			fmt.Printf("%s %s %s set to %s (stop %s, take-profit %s, trailing %s, mark %s)\n",
+				accountID, symbol, level, fmtPrice(price),
+				fmtLevel(ps.StopLoss), fmtLevel(ps.TakeProfit), fmtLevel(ps.TrailingStop), fmtLevel(ps.MarkPrice))
+			return nil
+		},
+	}
+}
+
+var (
+	positionsCloseReason  string
+	positionsCloseConfirm bool
+)
+
+var positionsCloseCmd = &cobra.Command{
+	Use:   "close <account-id> <symbol>",
+	Short: "Close an engine-managed position at market",
+	Long: `Close a position the engine manages at market, recording --reason as the
+exit reason. The --confirm flag is required to prevent accidental closes.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !positionsCloseConfirm {
+			return fmt.Errorf("use --confirm to close the position")
+		}
+		accountID, symbol := args[0], args[1]
+		c := newClient()
+		var result map[string]string
+		path := "/api/v1/engine/positions/" + url.PathEscape(accountID) + "/" + url.PathEscape(symbol) + "/close"
+		if err := c.Post(c.traderURL(path), map[string]string{"reason": positionsCloseReason}, &result); err != nil {
+			return engineError(err)
+		}
+		if useJSON, _ := cmd.Flags().GetBool("json"); useJSON {
+			return PrintJSON(result)
+		}
+		fmt.Printf("closed %s on %s\n", symbol, accountID)
+		return nil
+	},
+}
+
 func init() {
 	positionsCmd.Flags().StringVar(&positionsStatus, "status", "open", "Filter by status: open, closed, all")
+
+	positionsCloseCmd.Flags().StringVar(&positionsCloseReason, "reason", "", `Exit reason recorded on the close trade (default "Manual close: operator request")`)
+	positionsCloseCmd.Flags().BoolVar(&positionsCloseConfirm, "confirm", false, "Required: confirm closing the position")
+
+	positionsCmd.AddCommand(
+		positionLevelCmd("set-stop", "Move the stop loss of an engine-managed position", "stop loss", "stop-loss"),
+		positionLevelCmd("set-tp", "Move the take-profit of an engine-managed position", "take-profit", "take-profit"),
+		positionLevelCmd("set-trailing", "Move the trailing stop of an engine-managed ML-strategy position", "trailing stop", "trailing-stop"),
+		positionsCloseCmd,
+	)
 	rootCmd.AddCommand(positionsCmd)
 }
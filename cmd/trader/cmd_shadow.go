@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Signal-ngn/trader/internal/platform"
+)
+
+// shadowTrade is a line of the shadow ledger, or an entry of GET
+// /api/v1/engine/shadow/trades.
+type shadowTrade struct {
+	AccountID string `json:"account_id"`
+	platform.LedgerTrade
+}
+
+// tradeSummary aggregates the trades of one side of a comparison. A close is
+// a trade that carries an exit reason or realised P&L.
+type tradeSummary struct {
+	Trades      int     `json:"trades"`
+	Closes      int     `json:"closes"`
+	Wins        int     `json:"wins"`
+	RealizedPnL float64 `json:"realized_pnl"`
+}
+
+func (s *tradeSummary) add(t platform.LedgerTrade) {
+	s.Trades++
+	if t.ExitReason == nil && t.RealizedPnL == 0 {
+		return
+	}
+	s.Closes++
+	s.RealizedPnL += t.RealizedPnL
+	if t.RealizedPnL > 0 {
+		s.Wins++
+	}
+}
+
+// winRate formats the share of closes that made money.
+func (s tradeSummary) winRate() string {
+	if s.Closes == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(s.Wins)/float64(s.Closes)*100)
+}
+
+type symbolComparison struct {
+	Symbol string       `json:"symbol"`
+	Shadow tradeSummary `json:"shadow"`
+	Real   tradeSummary `json:"real"`
+}
+
+type shadowComparison struct {
+	AccountID string             `json:"account_id"`
+	Since     time.Time          `json:"since"`
+	Until     time.Time          `json:"until"`
+	Shadow    tradeSummary       `json:"shadow"`
+	Real      tradeSummary       `json:"real"`
+	Symbols   []symbolComparison `json:"symbols"`
+}
+
+// compareTrades summarises shadow and real trades in total and per symbol.
+func compareTrades(shadow, real []platform.LedgerTrade) (total symbolComparison, symbols []symbolComparison) {
+	bySymbol := make(map[string]*symbolComparison)
+	get := func(symbol string) *symbolComparison {
+		sc, ok := bySymbol[symbol]
+		if !ok {
+			sc = &symbolComparison{Symbol: symbol}
+			bySymbol[symbol] = sc
+		}
+		return sc
+	}
+	for _, t := range shadow {
+		total.Shadow.add(t)
+		get(t.Symbol).Shadow.add(t)
+	}
+	for _, t := range real {
+		total.Real.add(t)
+		get(t.Symbol).Real.add(t)
+	}
+	for _, sc := range bySymbol {
+		symbols = append(symbols, *sc)
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+	return total, symbols
+}
+
+// readShadowLedger reads the account's trades in [since, until) from a shadow
+// ledger file. A zero since is open.
+func readShadowLedger(path, accountID string, since, until time.Time) ([]platform.LedgerTrade, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []platform.LedgerTrade
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var t shadowTrade
+		if err := json.Unmarshal(sc.Bytes(), &t); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		if t.AccountID != accountID || t.Timestamp.Before(since) || !t.Timestamp.Before(until) {
+			continue
+		}
+		out = append(out, t.LedgerTrade)
+	}
+	return out, sc.Err()
+}
+
+// ---- compare ----
+
+var (
+	shadowFile  string
+	shadowSince string
+	shadowUntil string
+)
+
+var shadowCmd = &cobra.Command{
+	Use:   "shadow",
+	Short: "Inspect a shadow-mode trading engine",
+}
+
+var shadowCompareCmd = &cobra.Command{
+	Use:   "compare <account-id>",
+	Short: "Compare shadow trades with the real account's trades",
+	Long: `Compare the would-be trades of a shadow engine (TRADING_MODE=shadow) with the
+trades the real account made over the same period: trade and close counts, win
+rate and realised P&L, in total and per symbol.
+
+Shadow trades are fetched from traderd, or read from a copy of its
+SHADOW_LEDGER_FILE with --file. The period starts at --since, by default the
+first shadow trade for the account, and ends at --until, by default now.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		accountID := args[0]
+		var since, until time.Time
+		for _, b := range []struct {
+			name, val string
+			t         *time.Time
+		}{{"since", shadowSince, &since}, {"until", shadowUntil, &until}} {
+			if b.val == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, b.val)
+			if err != nil {
+				return fmt.Errorf("invalid --%s %q: must be RFC3339", b.name, b.val)
+			}
+			*b.t = t
+		}
+		if until.IsZero() {
+			until = time.Now().UTC()
+		}
+
+		var shadow []platform.LedgerTrade
+		if shadowFile != "" {
+			var err error
+			if shadow, err = readShadowLedger(shadowFile, accountID, since, until); err != nil {
+				return fmt.Errorf("read shadow ledger: %w", err)
+			}
+		} else {
+			c := newClient()
+			q := url.Values{}
+			q.Set("account_id", accountID)
+			q.Set("until", until.Format(time.RFC3339))
+			if !since.IsZero() {
+				q.Set("since", since.Format(time.RFC3339))
+			}
+			var trades []shadowTrade
+			if err := c.Get(c.traderURL("/api/v1/engine/shadow/trades", q), &trades); err != nil {
+				return engineError(err)
+			}
+			for _, t := range trades {
+				shadow = append(shadow, t.LedgerTrade)
+			}
+		}
+		if since.IsZero() {
+			if len(shadow) == 0 {
+				return fmt.Errorf("no shadow trades for account %s", accountID)
+			}
+			since = shadow[0].Timestamp
+			for _, t := range shadow {
+				if t.Timestamp.Before(since) {
+					since = t.Timestamp
+				}
+			}
+		}
+
+		trades, err := newPlatformClient().ListTrades(context.Background(), accountID, since)
+		if err != nil {
+			return fmt.Errorf("list account trades: %w", err)
+		}
+		var real []platform.LedgerTrade
+		for _, t := range trades {
+			if t.Timestamp.Before(until) {
+				real = append(real, t)
+			}
+		}
+
+		total, symbols := compareTrades(shadow, real)
+		if useJSON, _ := cmd.Flags().GetBool("json"); useJSON {
+			return PrintJSON(shadowComparison{
+				AccountID: accountID,
+				Since:     since,
+				Until:     until,
+				Shadow:    total.Shadow,
+				Real:      total.Real,
+				Symbols:   symbols,
+			})
+		}
+
+		fmt.Printf("account %s, %s → %s\n", accountID, fmtTime(since), fmtTime(until))
+		total.Symbol = "TOTAL"
+		rows := make([][]string, 0, len(symbols)+1)
+		for _, sc := range append(symbols, total) {
+			rows = append(rows, []string{
+				sc.Symbol,
+				fmt.Sprintf("%d/%d", sc.Shadow.Trades, sc.Shadow.Closes),
+				sc.Shadow.winRate(),
+				fmtFloat2(sc.Shadow.RealizedPnL),
+				fmt.Sprintf("%d/%d", sc.Real.Trades, sc.Real.Closes),
+				sc.Real.winRate(),
+				fmtFloat2(sc.Real.RealizedPnL),
+				fmtFloat2(sc.Shadow.RealizedPnL - sc.Real.RealizedPnL),
+			})
+		}
+		PrintTable(
+			[]string{"SYMBOL", "SHADOW TRADES/CLOSES", "SHADOW WIN", "SHADOW PNL", "REAL TRADES/CLOSES", "REAL WIN", "REAL PNL", "PNL DIFF"},
+			rows,
+		)
+		return nil
+	},
+}
+
+func init() {
+	shadowCompareCmd.Flags().StringVar(&shadowFile, "file", "", "Read shadow trades from this SHADOW_LEDGER_FILE copy instead of traderd")
+	shadowCompareCmd.Flags().StringVar(&shadowSince, "since", "", "Start of the period (RFC3339; default: first shadow trade)")
+	shadowCompareCmd.Flags().StringVar(&shadowUntil, "until", "", "End of the period (RFC3339; default: now)")
+
+	shadowCmd.AddCommand(shadowCompareCmd)
+	rootCmd.AddCommand(shadowCmd)
+}
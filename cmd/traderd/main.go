@@ -88,7 +88,27 @@ func main() {
 		// Construct the API-backed engine store.
 		apiStore := engine.NewAPIEngineStore(platformClient, firestoreClient, cfg)
 
-		eng := engine.New(cfg, apiStore, srv.StreamRegistry())
+		// Shadow mode keeps every write in a local ledger.
+		var store engine.EngineStore = apiStore
+		if cfg.TradingMode == "shadow" {
+			shadowStore, err := engine.NewShadowEngineStore(apiStore, cfg.ShadowLedgerFile)
+			if err != nil {
+				log.Fatal().Err(err).Str("file", cfg.ShadowLedgerFile).Msg("failed to open shadow ledger")
+			}
+			defer shadowStore.Close()
+			store = shadowStore
+			log.Info().Str("ledger", cfg.ShadowLedgerFile).Str("configs", cfg.ShadowConfigFile).Msg("shadow mode — trades go to the shadow ledger only")
+		}
+
+		eng := engine.New(cfg, store, srv.StreamRegistry())
+		if cfg.LeaderElection {
+			if cfg.LeaderLeaseTTL <= 0 {
+				log.Fatal().Msg("LEADER_LEASE_TTL must be positive when LEADER_ELECTION=true")
+			}
+			eng.SetLeaseStore(engine.NewFirestoreLeaseStore(firestoreClient))
+		}
+		srv.SetOperatorAuth(middleware.NewPlatformUserRepository(cfg.TraderAPIURL))
+		srv.SetEngine(eng)
 		go func() {
 			if err := eng.Start(ctx); err != nil {
 				log.Error().Err(err).Msg("trading engine error")
@@ -1,13 +1,28 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 
 	"github.com/Signal-ngn/trader/internal/api/middleware"
+	"github.com/Signal-ngn/trader/internal/domain"
+	"github.com/Signal-ngn/trader/internal/engine"
 )
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	resp := map[string]interface{}{"status": "ok"}
+	if eng := s.tradingEngine(); eng != nil {
+		resp["outbox_depth"] = eng.OutboxDepth()
+		resp["leader"] = eng.IsLeader()
+		resp["signal_queues"] = eng.QueueStats()
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // handleAuthResolve returns the resolved tenant ID for the authenticated caller.
@@ -17,3 +32,313 @@ func (s *Server) handleAuthResolve(w http.ResponseWriter, r *http.Request) {
 		"tenant_id": tenantID.String(),
 	})
 }
+
+// handleEngineStatus returns a summary of the engine's state.
+func (s *Server) handleEngineStatus(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	writeJSON(w, http.StatusOK, eng.Status())
+}
+
+// handleEnginePositions returns the in-memory risk state of every open
+// position, with its mark price.
+func (s *Server) handleEnginePositions(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	writeJSON(w, http.StatusOK, eng.PositionStatuses())
+}
+
+// handleEngineCooldowns returns the active open cooldowns and the direction
+// conflict guard.
+func (s *Server) handleEngineCooldowns(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"cooldowns": eng.Cooldowns(),
+		"conflicts": eng.Conflicts(),
+	})
+}
+
+// handleEngineAllowlist returns the signal slots the engine accepts.
+func (s *Server) handleEngineAllowlist(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	writeJSON(w, http.StatusOK, eng.Allowlist())
+}
+
+// handleEngineSignals returns the last allowlisted signal per NGS subject.
+func (s *Server) handleEngineSignals(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	writeJSON(w, http.StatusOK, eng.LastSignals())
+}
+
+// handleEngineReconcile returns the most recent exchange ↔ ledger
+// reconciliation report.
+func (s *Server) handleEngineReconcile(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	report := eng.LastReconcile()
+	if report == nil {
+		writeError(w, http.StatusNotFound, "no reconciliation has run yet (live mode only, every RECONCILE_INTERVAL)")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleEngineConfigRefresh makes the engine re-fetch its trading configs now
+// instead of at the next CONFIG_REFRESH_INTERVAL.
+func (s *Server) handleEngineConfigRefresh(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	status, err := eng.RefreshTradingConfigs(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "refresh trading configs: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleEngineBreakers lists the tripped circuit breakers.
+func (s *Server) handleEngineBreakers(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	writeJSON(w, http.StatusOK, eng.Breakers())
+}
+
+// handleEngineBreakerRearm re-arms a tripped circuit breaker for an account,
+// or for one strategy on it with ?strategy=, so new positions may open again.
+func (s *Server) handleEngineBreakerRearm(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	accountID := chi.URLParam(r, "accountId")
+	strategy := r.URL.Query().Get("strategy")
+	ok, err := eng.RearmBreaker(r.Context(), accountID, strategy)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "no tripped circuit breaker for this account/strategy")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"account_id": accountID, "strategy": strategy, "status": "re-armed"})
+}
+
+// engineScopeRequest selects the tenant, an account, a strategy, or a
+// strategy on an account; empty fields widen the scope.
+type engineScopeRequest struct {
+	AccountID string `json:"account_id"`
+	Strategy  string `json:"strategy"`
+	Reason    string `json:"reason"`
+}
+
+// decodeScope reads an optional engineScopeRequest body; an empty body is
+// the whole tenant.
+func decodeScope(w http.ResponseWriter, r *http.Request) (engineScopeRequest, bool) {
+	var req engineScopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+// writeEngineError maps an engine operation error to a status code.
+func writeEngineError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, engine.ErrUnknownAccount), errors.Is(err, engine.ErrPositionNotFound), errors.Is(err, engine.ErrNotShadow):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, engine.ErrNotLeader):
+		writeError(w, http.StatusConflict, err.Error()+" — retry against the leader")
+	case errors.Is(err, engine.ErrPositionClosing):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, engine.ErrInvalidOverride):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusBadGateway, err.Error())
+	}
+}
+
+// handleEnginePauses lists the operator pauses on new positions.
+func (s *Server) handleEnginePauses(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	pauses, err := eng.Pauses(r.Context())
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pauses)
+}
+
+// handleEnginePause pauses new positions for the scope in the request body.
+func (s *Server) handleEnginePause(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	req, ok := decodeScope(w, r)
+	if !ok {
+		return
+	}
+	pause, err := eng.Pause(r.Context(), req.AccountID, req.Strategy, req.Reason)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pause)
+}
+
+// handleEngineResume lifts the pause on the scope in the request body.
+func (s *Server) handleEngineResume(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	req, ok := decodeScope(w, r)
+	if !ok {
+		return
+	}
+	resumed, err := eng.Resume(r.Context(), req.AccountID, req.Strategy)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	if !resumed {
+		writeError(w, http.StatusNotFound, "no pause on this scope")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"account_id": req.AccountID, "strategy": req.Strategy, "status": "resumed"})
+}
+
+// handleEngineFlatten closes every engine-managed position on the account in
+// the request body, or on every account when none is given.
+func (s *Server) handleEngineFlatten(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	req, ok := decodeScope(w, r)
+	if !ok {
+		return
+	}
+	closed, err := eng.Flatten(r.Context(), req.AccountID)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"account_id": req.AccountID, "closed": closed})
+}
+
+// positionLevelRequest is the body of a position level override.
+type positionLevelRequest struct {
+	Price float64 `json:"price"`
+}
+
+// handleEnginePositionLevel returns a handler that moves one exit level of an
+// engine-managed position with set and responds with the updated position.
+func (s *Server) handleEnginePositionLevel(set func(EngineController, context.Context, string, string, float64) (engine.PositionStatus, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eng := s.tradingEngine()
+		if eng == nil {
+			writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+			return
+		}
+		var req positionLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		ps, err := set(eng, r.Context(), chi.URLParam(r, "accountId"), chi.URLParam(r, "symbol"), req.Price)
+		if err != nil {
+			writeEngineError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ps)
+	}
+}
+
+// handleEnginePositionClose closes an engine-managed position at market with
+// the operator's exit reason.
+func (s *Server) handleEnginePositionClose(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	req, ok := decodeScope(w, r)
+	if !ok {
+		return
+	}
+	accountID, symbol := chi.URLParam(r, "accountId"), chi.URLParam(r, "symbol")
+	if err := eng.ClosePosition(r.Context(), accountID, symbol, req.Reason); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"account_id": accountID, "symbol": symbol, "status": "closed"})
+}
+
+// handleEngineShadowTrades lists a shadow engine's would-be trades, filtered
+// by the optional account_id, since and until (RFC3339) query parameters.
+func (s *Server) handleEngineShadowTrades(w http.ResponseWriter, r *http.Request) {
+	eng := s.tradingEngine()
+	if eng == nil {
+		writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+		return
+	}
+	q := r.URL.Query()
+	var bounds [2]time.Time
+	for i, name := range []string{"since", "until"} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid "+name+": must be RFC3339")
+				return
+			}
+			bounds[i] = t
+		}
+	}
+	trades, err := eng.ShadowTrades(q.Get("account_id"), bounds[0], bounds[1])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	if trades == nil {
+		trades = []domain.Trade{}
+	}
+	writeJSON(w, http.StatusOK, trades)
+}
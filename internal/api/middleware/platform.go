@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/platform"
+)
+
+// platformAuthTTL is how long a resolved API key is trusted before the
+// platform is asked again.
+const platformAuthTTL = time.Minute
+
+type platformAuthEntry struct {
+	user    *AuthUser
+	expires time.Time
+}
+
+// PlatformUserRepository resolves API keys to tenants with the platform's
+// GET /auth/resolve. It satisfies UserRepository.
+type PlatformUserRepository struct {
+	apiURL string
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]platformAuthEntry
+}
+
+// NewPlatformUserRepository creates a PlatformUserRepository against the
+// platform API at apiURL.
+func NewPlatformUserRepository(apiURL string) *PlatformUserRepository {
+	return &PlatformUserRepository{
+		apiURL: apiURL,
+		cache:  make(map[uuid.UUID]platformAuthEntry),
+	}
+}
+
+// GetByAPIKey returns the tenant of apiKey, or nil when the platform rejects
+// the key. Keys the platform knows are cached for a minute.
+func (p *PlatformUserRepository) GetByAPIKey(ctx context.Context, apiKey uuid.UUID) (*AuthUser, error) {
+	p.mu.Lock()
+	entry, ok := p.cache[apiKey]
+	p.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.user, nil
+	}
+
+	tenant, err := platform.New(p.apiURL, apiKey.String()).ResolveAuth(ctx)
+	if err != nil {
+		var apiErr *platform.APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	tenantID, err := uuid.Parse(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("platform returned invalid tenant_id %q: %w", tenant, err)
+	}
+
+	user := &AuthUser{TenantID: tenantID}
+	p.mu.Lock()
+	p.cache[apiKey] = platformAuthEntry{user: user, expires: time.Now().Add(platformAuthTTL)}
+	p.mu.Unlock()
+	return user, nil
+}
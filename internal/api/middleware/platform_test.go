@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPlatformUserRepository_GetByAPIKey(t *testing.T) {
+	tenant, known, rejected := uuid.New(), uuid.New(), uuid.New()
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		case known.String():
+			w.Write([]byte(`{"tenant_id":"` + tenant.String() + `"}`))
+		case rejected.String():
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	repo := NewPlatformUserRepository(srv.URL)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		user, err := repo.GetByAPIKey(ctx, known)
+		if err != nil || user == nil || user.TenantID != tenant {
+			t.Fatalf("known key: got %+v %v", user, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("a resolved key should be cached, got %d platform calls", calls)
+	}
+	if user, err := repo.GetByAPIKey(ctx, rejected); user != nil || err != nil {
+		t.Errorf("rejected key: want nil user and no error, got %+v %v", user, err)
+	}
+	if _, err := repo.GetByAPIKey(ctx, uuid.New()); err == nil {
+		t.Error("a platform failure should be an error")
+	}
+}
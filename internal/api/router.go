@@ -1,8 +1,11 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -12,6 +15,8 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/Signal-ngn/trader/internal/api/middleware"
+	"github.com/Signal-ngn/trader/internal/domain"
+	"github.com/Signal-ngn/trader/internal/engine"
 )
 
 // Server holds the HTTP server dependencies.
@@ -19,6 +24,43 @@ type Server struct {
 	enforceAuth    bool
 	defaultTID     uuid.UUID
 	streamRegistry *StreamRegistry
+
+	// engine is set once the trading engine is constructed; nil when
+	// TRADING_ENABLED is false.
+	engineMu sync.RWMutex
+	engine   EngineController
+
+	// operators resolves the API keys allowed to change the engine's state;
+	// only keys of the engine's own tenant pass.
+	operators middleware.UserRepository
+}
+
+// EngineController is the view of the trading engine served over HTTP.
+// *engine.Engine satisfies this interface.
+type EngineController interface {
+	LastReconcile() *engine.ReconcileReport
+	OutboxDepth() int
+	IsLeader() bool
+	TenantID() uuid.UUID
+	QueueStats() engine.QueueStats
+	RefreshTradingConfigs(ctx context.Context) (engine.TradingConfigStatus, error)
+	Breakers() []engine.BreakerState
+	RearmBreaker(ctx context.Context, accountID, strategy string) (bool, error)
+	Pauses(ctx context.Context) ([]engine.PauseState, error)
+	Pause(ctx context.Context, accountID, strategy, reason string) (engine.PauseState, error)
+	Resume(ctx context.Context, accountID, strategy string) (bool, error)
+	Flatten(ctx context.Context, accountID string) (int, error)
+	SetStopLoss(ctx context.Context, accountID, symbol string, price float64) (engine.PositionStatus, error)
+	SetTakeProfit(ctx context.Context, accountID, symbol string, price float64) (engine.PositionStatus, error)
+	SetTrailingStop(ctx context.Context, accountID, symbol string, price float64) (engine.PositionStatus, error)
+	ClosePosition(ctx context.Context, accountID, symbol, reason string) error
+	ShadowTrades(accountID string, since, until time.Time) ([]domain.Trade, error)
+	Status() engine.Status
+	PositionStatuses() []engine.PositionStatus
+	Cooldowns() []engine.CooldownStatus
+	Conflicts() []engine.ConflictStatus
+	Allowlist() []engine.AllowlistEntry
+	LastSignals() []engine.SignalRecord
 }
 
 // NewServer creates a new API server.
@@ -35,6 +77,28 @@ func (s *Server) StreamRegistry() *StreamRegistry {
 	return s.streamRegistry
 }
 
+// SetEngine attaches the trading engine to the server's engine endpoints.
+func (s *Server) SetEngine(e EngineController) {
+	s.engineMu.Lock()
+	s.engine = e
+	s.engineMu.Unlock()
+}
+
+// SetOperatorAuth sets how the API keys of engine-mutating requests are
+// resolved to a tenant. Without it those requests are refused.
+func (s *Server) SetOperatorAuth(operators middleware.UserRepository) {
+	s.engineMu.Lock()
+	s.operators = operators
+	s.engineMu.Unlock()
+}
+
+// tradingEngine returns the attached engine, or nil when none is running.
+func (s *Server) tradingEngine() EngineController {
+	s.engineMu.RLock()
+	defer s.engineMu.RUnlock()
+	return s.engine
+}
+
 // Router returns the configured chi router.
 func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
@@ -62,13 +126,81 @@ func (s *Server) Router() http.Handler {
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Use(authMW)
 
+		// Engine routes also need a key of the engine's own tenant: they
+		// change or expose the state of the tenant it trades for.
+		opMW := s.requireEngineTenant
+
 		// SSE trade stream
 		r.Get("/accounts/{accountId}/trades/stream", s.handleTradeStream)
+
+		// Trading engine
+		r.With(opMW).Get("/engine/status", s.handleEngineStatus)
+		r.With(opMW).Get("/engine/positions", s.handleEnginePositions)
+		r.With(opMW).Post("/engine/positions/{accountId}/{symbol}/stop-loss", s.handleEnginePositionLevel(EngineController.SetStopLoss))
+		r.With(opMW).Post("/engine/positions/{accountId}/{symbol}/take-profit", s.handleEnginePositionLevel(EngineController.SetTakeProfit))
+		r.With(opMW).Post("/engine/positions/{accountId}/{symbol}/trailing-stop", s.handleEnginePositionLevel(EngineController.SetTrailingStop))
+		r.With(opMW).Post("/engine/positions/{accountId}/{symbol}/close", s.handleEnginePositionClose)
+		r.With(opMW).Get("/engine/cooldowns", s.handleEngineCooldowns)
+		r.With(opMW).Get("/engine/allowlist", s.handleEngineAllowlist)
+		r.With(opMW).Get("/engine/signals", s.handleEngineSignals)
+		r.With(opMW).Get("/engine/reconcile", s.handleEngineReconcile)
+		r.With(opMW).Post("/engine/config/refresh", s.handleEngineConfigRefresh)
+		r.With(opMW).Get("/engine/breakers", s.handleEngineBreakers)
+		r.With(opMW).Post("/engine/breakers/{accountId}/rearm", s.handleEngineBreakerRearm)
+		r.With(opMW).Get("/engine/pauses", s.handleEnginePauses)
+		r.With(opMW).Post("/engine/pause", s.handleEnginePause)
+		r.With(opMW).Post("/engine/resume", s.handleEngineResume)
+		r.With(opMW).Post("/engine/flatten", s.handleEngineFlatten)
+		r.With(opMW).Get("/engine/shadow/trades", s.handleEngineShadowTrades)
 	})
 
 	return r
 }
 
+// requireEngineTenant admits a request only when its Bearer API key resolves,
+// through the operator repository, to the tenant the engine trades for. It
+// applies whatever ENFORCE_AUTH says: 401 for a missing or unknown key, 403
+// for a key of another tenant.
+func (s *Server) requireEngineTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.engineMu.RLock()
+		eng, operators := s.engine, s.operators
+		s.engineMu.RUnlock()
+		if eng == nil {
+			writeError(w, http.StatusServiceUnavailable, "trading engine is not running")
+			return
+		}
+		engineTenant := eng.TenantID()
+		if engineTenant == uuid.Nil || operators == nil {
+			writeError(w, http.StatusServiceUnavailable, "trading engine tenant is not resolved yet")
+			return
+		}
+
+		rawKey, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		apiKey, err := uuid.Parse(rawKey)
+		if !ok || err != nil || apiKey == uuid.Nil {
+			writeError(w, http.StatusUnauthorized, "a Bearer API key is required")
+			return
+		}
+		user, err := operators.GetByAPIKey(r.Context(), apiKey)
+		if err != nil {
+			log.Error().Err(err).Msg("auth: error resolving engine operator key")
+			writeError(w, http.StatusUnauthorized, "authentication service unavailable")
+			return
+		}
+		if user == nil {
+			writeError(w, http.StatusUnauthorized, "unknown API key")
+			return
+		}
+		if user.TenantID != engineTenant {
+			log.Warn().Str("tenant_id", user.TenantID.String()).Str("path", r.URL.Path).Msg("auth: engine operation refused for another tenant")
+			writeError(w, http.StatusForbidden, "API key does not belong to the engine's tenant")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func requestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
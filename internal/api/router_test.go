@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/api/middleware"
+	"github.com/Signal-ngn/trader/internal/engine"
+)
+
+// fakeEngine implements only the EngineController methods the tests call.
+type fakeEngine struct {
+	EngineController
+	tenant    uuid.UUID
+	refreshes int
+}
+
+func (f *fakeEngine) TenantID() uuid.UUID { return f.tenant }
+
+func (f *fakeEngine) RefreshTradingConfigs(ctx context.Context) (engine.TradingConfigStatus, error) {
+	f.refreshes++
+	return engine.TradingConfigStatus{}, nil
+}
+
+// fakeOperators resolves keys from a fixed map.
+type fakeOperators map[uuid.UUID]uuid.UUID
+
+func (f fakeOperators) GetByAPIKey(ctx context.Context, apiKey uuid.UUID) (*middleware.AuthUser, error) {
+	tenant, ok := f[apiKey]
+	if !ok {
+		return nil, nil
+	}
+	return &middleware.AuthUser{TenantID: tenant}, nil
+}
+
+func TestEngineMutatingRoutes_RequireEngineTenant(t *testing.T) {
+	engineTenant, otherTenant := uuid.New(), uuid.New()
+	ownKey, otherKey, unknownKey := uuid.New(), uuid.New(), uuid.New()
+
+	eng := &fakeEngine{tenant: engineTenant}
+	srv := NewServer(false, middleware.DefaultTenantID)
+	srv.SetOperatorAuth(fakeOperators{ownKey: engineTenant, otherKey: otherTenant})
+	srv.SetEngine(eng)
+	h := srv.Router()
+
+	do := func(method, path, auth string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, tc := range []struct {
+		name, auth string
+		want       int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"malformed key", "Bearer not-a-key", http.StatusUnauthorized},
+		{"unknown key", "Bearer " + unknownKey.String(), http.StatusUnauthorized},
+		{"other tenant", "Bearer " + otherKey.String(), http.StatusForbidden},
+		{"engine tenant", "Bearer " + ownKey.String(), http.StatusOK},
+	} {
+		if got := do(http.MethodPost, "/api/v1/engine/config/refresh", tc.auth); got != tc.want {
+			t.Errorf("%s: want %d, got %d", tc.name, tc.want, got)
+		}
+	}
+	if eng.refreshes != 1 {
+		t.Errorf("only the engine tenant's request should refresh, got %d calls", eng.refreshes)
+	}
+
+	// Until Start resolves the tenant, nothing is admitted.
+	eng.tenant = uuid.Nil
+	if got := do(http.MethodPost, "/api/v1/engine/config/refresh", "Bearer "+ownKey.String()); got != http.StatusServiceUnavailable {
+		t.Errorf("unresolved tenant: want 503, got %d", got)
+	}
+}
+
+func TestEngineReadRoutes_RequireEngineTenant(t *testing.T) {
+	engineTenant := uuid.New()
+	otherKey := uuid.New()
+	srv := NewServer(false, middleware.DefaultTenantID)
+	srv.SetOperatorAuth(fakeOperators{otherKey: uuid.New()})
+	srv.SetEngine(&fakeEngine{tenant: engineTenant})
+	h := srv.Router()
+
+	for _, path := range []string{
+		"/api/v1/engine/status",
+		"/api/v1/engine/positions",
+		"/api/v1/engine/cooldowns",
+		"/api/v1/engine/allowlist",
+		"/api/v1/engine/signals",
+		"/api/v1/engine/reconcile",
+		"/api/v1/engine/breakers",
+		"/api/v1/engine/pauses",
+		"/api/v1/engine/shadow/trades",
+	} {
+		for auth, want := range map[string]int{
+			"":                            http.StatusUnauthorized,
+			"Bearer " + otherKey.String(): http.StatusForbidden,
+		} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != want {
+				t.Errorf("GET %s with %q: want %d, got %d", path, auth, want, rec.Code)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -37,7 +38,7 @@ type Config struct {
 
 	// Trading engine settings
 	TradingEnabled   bool
-	TradingMode      string   // "paper" or "live"
+	TradingMode      string   // "paper", "live" or "shadow"
 	TraderAccounts   []string // account IDs to trade under (empty = all tenant accounts)
 	StrategyFilter   string   // optional prefix filter for signal strategies
 	PortfolioSize       float64 // total portfolio size in USD (reference for scaling)
@@ -56,6 +57,86 @@ type Config struct {
 	SNNATSCredsFile  string  // path to NGS NATS credentials file (optional)
 	BinanceAPIKey    string  // Binance API key (live mode only)
 	BinanceAPISecret string  // Binance API secret (live mode only)
+	CoinbaseAPIKey    string // Coinbase CDP API key name, "organizations/{org}/apiKeys/{key}" (live mode only)
+	CoinbaseAPISecret string // Coinbase CDP EC private key in PEM form (live mode only)
+	CoinbaseAPIURL    string // Coinbase Advanced Trade base URL (override for testing)
+
+	// Per-account exchange credential overrides, keyed by AccountEnvSuffix(accountID)
+	// then venue ("binance", "coinbase"). Read from BINANCE_API_KEY_<ACCOUNT>,
+	// BINANCE_API_SECRET_<ACCOUNT>, COINBASE_API_KEY_<ACCOUNT> and COINBASE_API_SECRET_<ACCOUNT>.
+	AccountExchangeCredentials map[string]map[string]VenueCredentials
+
+	// Entry order settings
+	EntryOrderType    string        // "market" (default), "limit", "stop_market" or "post_only"
+	EntryTimeInForce  string        // time-in-force for limit entries: "GTC" (default), "IOC", "FOK"
+	LimitOrderTimeout time.Duration // unfilled limit/stop entries are cancelled after this long
+	EntryOffsetBps    float64       // limit entries rest this far inside, stop entries trigger this far beyond, the signal price
+	MaxScaleIns       int           // max scale-in layers added to an open position (0 = scale-in disabled)
+
+	// Live-mode reconciliation between exchange positions and the ledger
+	ReconcileInterval time.Duration // how often to diff exchange, ledger and engine state (0 = disabled)
+	ReconcileAutoFix  bool          // write corrective ledger trades for drift seen on two consecutive runs
+
+	// Durable outbox for ledger trades awaiting platform acknowledgement
+	OutboxDir string // directory holding one JSON file per queued trade ("" = disabled)
+
+	// Shadow mode: trade on paper fills into a local ledger instead of the platform
+	ShadowLedgerFile string // JSONL file receiving shadow trades; position state is kept next to it
+	ShadowConfigFile string // trading configs to trial, same JSON as GET /config/trading ("" = the platform's)
+
+	// Cross-delivery and cross-replica signal deduplication
+	SignalDedupTTL time.Duration // how long a processed signal ID is remembered (0 = disabled)
+
+	// Trading config cache
+	ConfigRefreshInterval time.Duration // how often cached trading configs are revalidated against the platform (0 = on request only)
+	ConfigUpdatesSubject  string        // NATS subject carrying trading config change events ("" = polling only)
+
+	// Signal gates; each trading config may override them
+	SignalMaxAge    time.Duration // signals older than this are dropped (0 = no limit)
+	ConfidenceFloor float64       // BUY/SHORT signals below this confidence are dropped
+	OpenCooldown    time.Duration // no new open for the same account, symbol and action for this long after an open
+
+	// Portfolio-wide exposure limits in USD across all managed accounts (0 = no limit)
+	MaxTotalExposure  float64 // gross open notional
+	MaxSymbolExposure float64 // gross open notional per symbol
+	MaxNetExposure    float64 // |long notional − short notional|
+	MaxMarginUsage    float64 // futures margin in use
+
+	// Correlated-position limit per account
+	MaxCorrelatedPositions int           // max same-direction positions in one correlated cluster (0 = no limit)
+	CorrelationThreshold   float64       // return correlation at which two symbols count as one cluster
+	CorrelationInterval    time.Duration // price sampling interval for return correlations
+	CorrelationWindow      int           // returns per symbol the correlation is computed over
+
+	// Daily loss with open positions marked to market
+	DailyLossIncludeUnrealized bool    // count unrealised P&L towards DailyLossLimit
+	FlattenLossLimit           float64 // USD loss today, realised plus unrealised, that closes every position on the account (0 = disabled)
+
+	// Circuit breakers beyond the daily loss limit (0 = disabled); a trip pauses
+	// opens for the account or strategy until BreakerCooldown passes or re-arm
+	MaxDrawdownPct       float64       // % below the equity peak within DrawdownWindow
+	DrawdownWindow       time.Duration // rolling window the equity peak is taken over
+	WeeklyLossLimit      float64       // USD realised loss over the last 7 days
+	MaxConsecutiveLosses int           // losing closes in a row per strategy
+	BreakerCooldown      time.Duration // how long a trip lasts (0 = until re-armed)
+
+	// Kelly sizing statistics from the ledger's closed trades
+	KellyLookback        time.Duration // closed trades considered
+	KellyMinTrades       int           // fewer closed trades per strategy+product fall back to PositionSizePct
+	KellyRefreshInterval time.Duration // how often the statistics are rebuilt
+
+	// Per-(account, symbol) ordered signal processing
+	SignalQueueDepth int // max signals waiting per account+symbol; further signals are dropped
+
+	// Leader election between traderd instances of the same tenant
+	LeaderElection bool          // only the lease holder trades
+	LeaderLeaseTTL time.Duration // lease lifetime; renewed every third of it
+}
+
+// VenueCredentials holds the API credentials for one exchange venue.
+type VenueCredentials struct {
+	APIKey    string
+	APISecret string
 }
 
 // Load reads configuration from environment variables with .env support.
@@ -98,6 +179,62 @@ func Load() (*Config, error) {
 		SNNATSCredsFile:  os.Getenv("SN_NATS_CREDS_FILE"),
 		BinanceAPIKey:    os.Getenv("BINANCE_API_KEY"),
 		BinanceAPISecret: os.Getenv("BINANCE_API_SECRET"),
+		CoinbaseAPIKey:    os.Getenv("COINBASE_API_KEY"),
+		CoinbaseAPISecret: os.Getenv("COINBASE_API_SECRET"),
+		CoinbaseAPIURL:    getEnv("COINBASE_API_URL", "https://api.coinbase.com"),
+
+		EntryOrderType:    getEnv("ENTRY_ORDER_TYPE", "market"),
+		EntryTimeInForce:  getEnv("ENTRY_TIME_IN_FORCE", "GTC"),
+		LimitOrderTimeout: parseDuration(os.Getenv("LIMIT_ORDER_TIMEOUT"), 2*time.Minute),
+		EntryOffsetBps:    parseFloat(os.Getenv("ENTRY_OFFSET_BPS"), 0),
+		MaxScaleIns:       parseInt(os.Getenv("MAX_SCALE_INS"), 2),
+
+		ReconcileInterval: parseDuration(os.Getenv("RECONCILE_INTERVAL"), 5*time.Minute),
+		ReconcileAutoFix:  os.Getenv("RECONCILE_AUTO_FIX") == "true",
+
+		OutboxDir: os.Getenv("OUTBOX_DIR"),
+
+		ShadowLedgerFile: getEnv("SHADOW_LEDGER_FILE", "/tmp/trader-shadow.jsonl"),
+		ShadowConfigFile: os.Getenv("SHADOW_TRADING_CONFIG_FILE"),
+
+		SignalDedupTTL: parseDuration(os.Getenv("SIGNAL_DEDUP_TTL"), 30*time.Minute),
+
+		ConfigRefreshInterval: parseDuration(os.Getenv("CONFIG_REFRESH_INTERVAL"), 5*time.Minute),
+		ConfigUpdatesSubject:  os.Getenv("CONFIG_UPDATES_SUBJECT"),
+
+		SignalMaxAge:    parseDuration(os.Getenv("SIGNAL_MAX_AGE"), 10*time.Minute),
+		ConfidenceFloor: parseFloat(os.Getenv("CONFIDENCE_FLOOR"), 0.5),
+		OpenCooldown:    parseDuration(os.Getenv("OPEN_COOLDOWN"), 5*time.Minute),
+
+		MaxTotalExposure:  parseFloat(os.Getenv("MAX_TOTAL_EXPOSURE"), 0),
+		MaxSymbolExposure: parseFloat(os.Getenv("MAX_SYMBOL_EXPOSURE"), 0),
+		MaxNetExposure:    parseFloat(os.Getenv("MAX_NET_EXPOSURE"), 0),
+		MaxMarginUsage:    parseFloat(os.Getenv("MAX_MARGIN_USAGE"), 0),
+
+		MaxCorrelatedPositions: parseInt(os.Getenv("MAX_CORRELATED_POSITIONS"), 0),
+		CorrelationThreshold:   parseFloat(os.Getenv("CORRELATION_THRESHOLD"), 0.7),
+		CorrelationInterval:    parseDuration(os.Getenv("CORRELATION_INTERVAL"), time.Hour),
+		CorrelationWindow:      parseInt(os.Getenv("CORRELATION_WINDOW"), 48),
+
+		DailyLossIncludeUnrealized: getEnv("DAILY_LOSS_INCLUDE_UNREALIZED", "false") == "true",
+		FlattenLossLimit:           parseFloat(os.Getenv("FLATTEN_LOSS_LIMIT"), 0),
+
+		MaxDrawdownPct:       parseFloat(os.Getenv("MAX_DRAWDOWN_PCT"), 0),
+		DrawdownWindow:       parseDuration(os.Getenv("DRAWDOWN_WINDOW"), 30*24*time.Hour),
+		WeeklyLossLimit:      parseFloat(os.Getenv("WEEKLY_LOSS_LIMIT"), 0),
+		MaxConsecutiveLosses: parseInt(os.Getenv("MAX_CONSECUTIVE_LOSSES"), 0),
+		BreakerCooldown:      parseDuration(os.Getenv("BREAKER_COOLDOWN"), 24*time.Hour),
+
+		KellyLookback:        parseDuration(os.Getenv("KELLY_LOOKBACK"), 30*24*time.Hour),
+		KellyMinTrades:       parseInt(os.Getenv("KELLY_MIN_TRADES"), 30),
+		KellyRefreshInterval: parseDuration(os.Getenv("KELLY_REFRESH_INTERVAL"), time.Hour),
+
+		SignalQueueDepth: parseInt(os.Getenv("SIGNAL_QUEUE_DEPTH"), 64),
+
+		LeaderElection: os.Getenv("LEADER_ELECTION") == "true",
+		LeaderLeaseTTL: parseDuration(os.Getenv("LEADER_LEASE_TTL"), 15*time.Second),
+
+		AccountExchangeCredentials: parseAccountExchangeCredentials(os.Environ()),
 	}
 
 	// Build Cloud SQL connection string if instance is specified
@@ -158,6 +295,79 @@ func parseInt(s string, defaultValue int) int {
 	return v
 }
 
+// parseDuration parses a Go duration string ("90s", "5m"). A bare integer is
+// treated as seconds.
+func parseDuration(s string, defaultValue time.Duration) time.Duration {
+	if s == "" {
+		return defaultValue
+	}
+	if secs, err := strconv.Atoi(s); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return defaultValue
+	}
+	return d
+}
+
+// accountCredentialVars maps per-account env var prefixes to (venue, field).
+var accountCredentialVars = []struct {
+	prefix string
+	venue  string
+	secret bool
+}{
+	{"BINANCE_API_KEY_", "binance", false},
+	{"BINANCE_API_SECRET_", "binance", true},
+	{"COINBASE_API_KEY_", "coinbase", false},
+	{"COINBASE_API_SECRET_", "coinbase", true},
+}
+
+// parseAccountExchangeCredentials collects per-account exchange credentials
+// from KEY=VALUE environment entries. Returns nil when none are set.
+func parseAccountExchangeCredentials(environ []string) map[string]map[string]VenueCredentials {
+	var out map[string]map[string]VenueCredentials
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || value == "" {
+			continue
+		}
+		for _, v := range accountCredentialVars {
+			if !strings.HasPrefix(name, v.prefix) || len(name) == len(v.prefix) {
+				continue
+			}
+			account := name[len(v.prefix):]
+			if out == nil {
+				out = make(map[string]map[string]VenueCredentials)
+			}
+			if out[account] == nil {
+				out[account] = make(map[string]VenueCredentials)
+			}
+			creds := out[account][v.venue]
+			if v.secret {
+				creds.APISecret = value
+			} else {
+				creds.APIKey = value
+			}
+			out[account][v.venue] = creds
+			break
+		}
+	}
+	return out
+}
+
+// AccountEnvSuffix returns the env var suffix for an account ID: upper-cased
+// with every character outside [A-Z0-9] replaced by "_" ("acme-live" → "ACME_LIVE").
+func AccountEnvSuffix(accountID string) string {
+	b := []byte(strings.ToUpper(accountID))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
 // parseStringList splits a comma-separated string into a trimmed slice.
 // Returns nil (not an empty slice) when s is blank so callers can
 // distinguish "not set" from "explicitly empty".
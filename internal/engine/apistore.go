@@ -2,7 +2,9 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -51,6 +53,45 @@ func (s *APIEngineStore) dailyPnLDocRef(accountID string) *firestore.DocumentRef
 	return s.firestore.Collection("engine-state").Doc(accountID).Collection("daily-pnl").Doc(docID)
 }
 
+// signalClaimDocRef returns the Firestore document reference for a signal claim.
+// Path: engine-state/{accountID}/signal-claims/{signalID}
+func (s *APIEngineStore) signalClaimDocRef(accountID, signalID string) *firestore.DocumentRef {
+	return s.firestore.Collection("engine-state").Doc(accountID).Collection("signal-claims").Doc(signalID)
+}
+
+// breakerDocRef returns the Firestore document reference for a circuit-breaker
+// scope. Path: engine-state/{accountID}/breakers/{account|strategy-<name>}
+func (s *APIEngineStore) breakerDocRef(accountID, strategy string) *firestore.DocumentRef {
+	docID := "account"
+	if strategy != "" {
+		docID = "strategy-" + strategy
+	}
+	return s.firestore.Collection("engine-state").Doc(accountID).Collection("breakers").Doc(docID)
+}
+
+// pendingEntryDocRef returns the Firestore document reference for a resting
+// entry order. Path: engine-state/{accountID}/pending-entries/{symbol}
+func (s *APIEngineStore) pendingEntryDocRef(accountID, symbol string) *firestore.DocumentRef {
+	return s.firestore.Collection("engine-state").Doc(accountID).Collection("pending-entries").Doc(symbol)
+}
+
+// pauseDocRef returns the Firestore document reference for an operator pause.
+// Path: engine-pauses/{tenantID}/scopes/{tenant|account:<id>|strategy:<name>|account:<id>:strategy:<name>}
+func (s *APIEngineStore) pauseDocRef(tenantID uuid.UUID, accountID, strategy string) *firestore.DocumentRef {
+	var parts []string
+	if accountID != "" {
+		parts = append(parts, "account:"+accountID)
+	}
+	if strategy != "" {
+		parts = append(parts, "strategy:"+strategy)
+	}
+	docID := "tenant"
+	if len(parts) > 0 {
+		docID = strings.Join(parts, ":")
+	}
+	return s.firestore.Collection("engine-pauses").Doc(tenantID.String()).Collection("scopes").Doc(docID)
+}
+
 // --- InsertPositionState (task 5.2) ---
 
 // InsertPositionState writes a Firestore document with all risk fields for an
@@ -61,6 +102,7 @@ func (s *APIEngineStore) InsertPositionState(ctx context.Context, tenantID uuid.
 		"account_id":    state.AccountID,
 		"symbol":        state.Symbol,
 		"market_type":   state.MarketType,
+		"exchange":      state.Exchange,
 		"side":          state.Side,
 		"entry_price":   state.EntryPrice,
 		"stop_loss":     state.StopLoss,
@@ -72,6 +114,17 @@ func (s *APIEngineStore) InsertPositionState(ctx context.Context, tenantID uuid.
 		"opened_at":     state.OpenedAt,
 		"peak_price":    state.PeakPrice,
 		"trailing_stop": state.TrailingStop,
+
+		"stop_order_id":        state.StopOrderID,
+		"take_profit_order_id": state.TakeProfitOrderID,
+
+		"quantity": state.Quantity,
+		"entries":  entriesData(state.Entries),
+		"ladder":   ladderData(state.Ladder),
+
+		"break_even_r":    state.BreakEvenR,
+		"break_even":      state.BreakEven,
+		"entry_stop_loss": state.EntryStopLoss,
 	}
 	_, err := s.posDocRef(state.AccountID, state.Symbol, state.MarketType).Set(ctx, data)
 	if err != nil {
@@ -82,14 +135,24 @@ func (s *APIEngineStore) InsertPositionState(ctx context.Context, tenantID uuid.
 
 // --- UpdatePositionState (task 5.3) ---
 
-// UpdatePositionState updates the trailing stop, peak price, stop loss, and
-// take profit fields on an existing Firestore position document.
+// UpdatePositionState updates the trailing stop, peak price, stop loss, take
+// profit, protective order ID, entry price, hard stop, quantity, entries,
+// ladder and break-even fields on an existing Firestore position document.
 func (s *APIEngineStore) UpdatePositionState(ctx context.Context, tenantID uuid.UUID, state *EnginePositionState) error {
 	updates := []firestore.Update{
 		{Path: "trailing_stop", Value: state.TrailingStop},
 		{Path: "peak_price", Value: state.PeakPrice},
 		{Path: "stop_loss", Value: state.StopLoss},
 		{Path: "take_profit", Value: state.TakeProfit},
+		{Path: "stop_order_id", Value: state.StopOrderID},
+		{Path: "take_profit_order_id", Value: state.TakeProfitOrderID},
+		{Path: "entry_price", Value: state.EntryPrice},
+		{Path: "hard_stop", Value: state.HardStop},
+		{Path: "quantity", Value: state.Quantity},
+		{Path: "entries", Value: entriesData(state.Entries)},
+		{Path: "ladder", Value: ladderData(state.Ladder)},
+		{Path: "break_even", Value: state.BreakEven},
+		{Path: "entry_stop_loss", Value: state.EntryStopLoss},
 	}
 	_, err := s.posDocRef(state.AccountID, state.Symbol, state.MarketType).Update(ctx, updates)
 	if err != nil {
@@ -132,6 +195,7 @@ func (s *APIEngineStore) LoadPositionStates(ctx context.Context, accountID strin
 		st.AccountID = stringVal(data, "account_id")
 		st.Symbol = stringVal(data, "symbol")
 		st.MarketType = stringVal(data, "market_type")
+		st.Exchange = stringVal(data, "exchange")
 		st.Side = stringVal(data, "side")
 		st.EntryPrice = float64Val(data, "entry_price")
 		st.StopLoss = float64Val(data, "stop_loss")
@@ -142,6 +206,14 @@ func (s *APIEngineStore) LoadPositionStates(ctx context.Context, accountID strin
 		st.Granularity = stringVal(data, "granularity")
 		st.PeakPrice = float64Val(data, "peak_price")
 		st.TrailingStop = float64Val(data, "trailing_stop")
+		st.StopOrderID = stringVal(data, "stop_order_id")
+		st.TakeProfitOrderID = stringVal(data, "take_profit_order_id")
+		st.Quantity = float64Val(data, "quantity")
+		st.Entries = entriesVal(data, "entries")
+		st.Ladder = ladderVal(data, "ladder")
+		st.BreakEvenR = float64Val(data, "break_even_r")
+		st.BreakEven, _ = data["break_even"].(bool)
+		st.EntryStopLoss = float64Val(data, "entry_stop_loss")
 		if ts, ok := data["opened_at"]; ok {
 			switch v := ts.(type) {
 			case time.Time:
@@ -208,6 +280,226 @@ func (s *APIEngineStore) incrementDailyPnL(ctx context.Context, accountID string
 	return nil
 }
 
+// --- Circuit breakers ---
+
+// LoadBreakerStates reads every document in the account's breakers
+// sub-collection.
+func (s *APIEngineStore) LoadBreakerStates(ctx context.Context, accountID string) ([]BreakerState, error) {
+	iter := s.firestore.Collection("engine-state").Doc(accountID).Collection("breakers").Documents(ctx)
+	defer iter.Stop()
+
+	var states []BreakerState
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load breaker states: %w", err)
+		}
+		data := doc.Data()
+		st := BreakerState{
+			AccountID: accountID,
+			Strategy:  stringVal(data, "strategy"),
+			Breaker:   stringVal(data, "breaker"),
+			Value:     float64Val(data, "value"),
+			Limit:     float64Val(data, "limit"),
+		}
+		st.TrippedAt, _ = data["tripped_at"].(time.Time)
+		st.Until, _ = data["until"].(time.Time)
+		st.ArmedAt, _ = data["armed_at"].(time.Time)
+		states = append(states, st)
+	}
+	return states, nil
+}
+
+// SaveBreakerState overwrites the breaker document for the state's scope.
+func (s *APIEngineStore) SaveBreakerState(ctx context.Context, st *BreakerState) error {
+	data := map[string]interface{}{
+		"strategy":   st.Strategy,
+		"breaker":    st.Breaker,
+		"value":      st.Value,
+		"limit":      st.Limit,
+		"tripped_at": st.TrippedAt,
+		"until":      st.Until,
+		"armed_at":   st.ArmedAt,
+	}
+	if _, err := s.breakerDocRef(st.AccountID, st.Strategy).Set(ctx, data); err != nil {
+		return fmt.Errorf("save breaker state: %w", err)
+	}
+	return nil
+}
+
+// --- Operator pauses ---
+
+// LoadPauses reads every document in the tenant's pause scopes collection.
+func (s *APIEngineStore) LoadPauses(ctx context.Context, tenantID uuid.UUID) ([]PauseState, error) {
+	iter := s.firestore.Collection("engine-pauses").Doc(tenantID.String()).Collection("scopes").Documents(ctx)
+	defer iter.Stop()
+
+	var pauses []PauseState
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load pauses: %w", err)
+		}
+		data := doc.Data()
+		p := PauseState{
+			AccountID: stringVal(data, "account_id"),
+			Strategy:  stringVal(data, "strategy"),
+			Reason:    stringVal(data, "reason"),
+		}
+		p.PausedAt, _ = data["paused_at"].(time.Time)
+		pauses = append(pauses, p)
+	}
+	return pauses, nil
+}
+
+// SavePause overwrites the pause document for the pause's scope.
+func (s *APIEngineStore) SavePause(ctx context.Context, tenantID uuid.UUID, p *PauseState) error {
+	data := map[string]interface{}{
+		"account_id": p.AccountID,
+		"strategy":   p.Strategy,
+		"reason":     p.Reason,
+		"paused_at":  p.PausedAt,
+	}
+	if _, err := s.pauseDocRef(tenantID, p.AccountID, p.Strategy).Set(ctx, data); err != nil {
+		return fmt.Errorf("save pause: %w", err)
+	}
+	return nil
+}
+
+// DeletePause deletes the pause document for the scope.
+func (s *APIEngineStore) DeletePause(ctx context.Context, tenantID uuid.UUID, accountID, strategy string) error {
+	if _, err := s.pauseDocRef(tenantID, accountID, strategy).Delete(ctx); err != nil {
+		return fmt.Errorf("delete pause: %w", err)
+	}
+	return nil
+}
+
+// --- Pending entries ---
+
+// LoadPendingEntries reads every document in the account's pending-entries
+// sub-collection.
+func (s *APIEngineStore) LoadPendingEntries(ctx context.Context, accountID string) ([]PendingEntryState, error) {
+	iter := s.firestore.Collection("engine-state").Doc(accountID).Collection("pending-entries").Documents(ctx)
+	defer iter.Stop()
+
+	var entries []PendingEntryState
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load pending entries: %w", err)
+		}
+		data := doc.Data()
+		p := PendingEntryState{
+			AccountID:    accountID,
+			Symbol:       stringVal(data, "symbol"),
+			Exchange:     stringVal(data, "exchange"),
+			OrderID:      stringVal(data, "order_id"),
+			SignalPrice:  float64Val(data, "signal_price"),
+			PositionSide: stringVal(data, "position_side"),
+			Leverage:     intVal(data, "leverage"),
+		}
+		if err := json.Unmarshal([]byte(stringVal(data, "trade")), &p.Trade); err != nil {
+			return nil, fmt.Errorf("load pending entry %s: decode trade: %w", doc.Ref.ID, err)
+		}
+		p.PlacedAt, _ = data["placed_at"].(time.Time)
+		p.ExpiresAt, _ = data["expires_at"].(time.Time)
+		entries = append(entries, p)
+	}
+	return entries, nil
+}
+
+// SavePendingEntry overwrites the pending-entry document for the symbol. The
+// prepared trade is stored as JSON.
+func (s *APIEngineStore) SavePendingEntry(ctx context.Context, p *PendingEntryState) error {
+	trade, err := json.Marshal(p.Trade)
+	if err != nil {
+		return fmt.Errorf("save pending entry: encode trade: %w", err)
+	}
+	data := map[string]interface{}{
+		"symbol":        p.Symbol,
+		"exchange":      p.Exchange,
+		"order_id":      p.OrderID,
+		"trade":         string(trade),
+		"signal_price":  p.SignalPrice,
+		"position_side": p.PositionSide,
+		"leverage":      p.Leverage,
+		"placed_at":     p.PlacedAt,
+		"expires_at":    p.ExpiresAt,
+	}
+	if _, err := s.pendingEntryDocRef(p.AccountID, p.Symbol).Set(ctx, data); err != nil {
+		return fmt.Errorf("save pending entry: %w", err)
+	}
+	return nil
+}
+
+// DeletePendingEntry deletes the pending-entry document for account+symbol.
+func (s *APIEngineStore) DeletePendingEntry(ctx context.Context, accountID, symbol string) error {
+	if _, err := s.pendingEntryDocRef(accountID, symbol).Delete(ctx); err != nil {
+		return fmt.Errorf("delete pending entry: %w", err)
+	}
+	return nil
+}
+
+// --- ClaimSignal ---
+
+// ClaimSignal creates the claim document for a signal. Create fails with
+// AlreadyExists when another delivery or instance got there first, which makes
+// the claim atomic across replicas. Claims carry an expires_at field for a
+// Firestore TTL policy; an expired claim that has not been swept yet is
+// replaced.
+func (s *APIEngineStore) ClaimSignal(ctx context.Context, accountID, signalID string, ttl time.Duration) (bool, error) {
+	ref := s.signalClaimDocRef(accountID, signalID)
+	now := time.Now().UTC()
+	data := map[string]interface{}{
+		"claimed_at": now,
+		"expires_at": now.Add(ttl),
+	}
+	_, err := ref.Create(ctx, data)
+	if err == nil {
+		return true, nil
+	}
+	if !isFirestoreAlreadyExists(err) {
+		return false, fmt.Errorf("claim signal: %w", err)
+	}
+
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return false, fmt.Errorf("claim signal: %w", err)
+	}
+	if exp, ok := doc.Data()["expires_at"].(time.Time); !ok || now.Before(exp) {
+		return false, nil
+	}
+	// Expired: take it over, guarded by the update time we just read.
+	updates := []firestore.Update{
+		{Path: "claimed_at", Value: now},
+		{Path: "expires_at", Value: now.Add(ttl)},
+	}
+	if _, err := ref.Update(ctx, updates, firestore.LastUpdateTime(doc.UpdateTime)); err != nil {
+		if containsCode(err, "FailedPrecondition") {
+			return false, nil
+		}
+		return false, fmt.Errorf("claim signal: %w", err)
+	}
+	return true, nil
+}
+
+// ReleaseSignal deletes the claim document for a signal.
+func (s *APIEngineStore) ReleaseSignal(ctx context.Context, accountID, signalID string) error {
+	if _, err := s.signalClaimDocRef(accountID, signalID).Delete(ctx); err != nil {
+		return fmt.Errorf("release signal: %w", err)
+	}
+	return nil
+}
+
 // --- InsertTradeAndUpdatePosition (task 7.1) ---
 
 // InsertTradeAndUpdatePosition submits a trade to the platform API. On success
@@ -369,6 +661,39 @@ func (s *APIEngineStore) ListOpenPositionsForAccount(ctx context.Context, accoun
 	return positions, nil
 }
 
+// --- ListClosingTrades ---
+
+// ListClosingTrades calls ListTrades and keeps the trades that carry an exit
+// reason or realised P&L, mapped to domain.Trade.
+func (s *APIEngineStore) ListClosingTrades(ctx context.Context, accountID string, since time.Time) ([]domain.Trade, error) {
+	trades, err := s.client.ListTrades(ctx, accountID, since)
+	if err != nil {
+		return nil, fmt.Errorf("list closing trades: %w", err)
+	}
+
+	var closing []domain.Trade
+	for _, t := range trades {
+		if t.ExitReason == nil && t.RealizedPnL == 0 {
+			continue
+		}
+		closing = append(closing, domain.Trade{
+			TradeID:     t.TradeID,
+			AccountID:   accountID,
+			Symbol:      t.Symbol,
+			Side:        domain.Side(t.Side),
+			Quantity:    t.Quantity,
+			Price:       t.Price,
+			MarketType:  domain.MarketType(t.MarketType),
+			Timestamp:   t.Timestamp,
+			CostBasis:   t.CostBasis,
+			RealizedPnL: t.RealizedPnL,
+			Strategy:    t.Strategy,
+			ExitReason:  t.ExitReason,
+		})
+	}
+	return closing, nil
+}
+
 // --- ListAccounts (task 7.6) ---
 
 // ListAccounts calls the platform API and maps platform.Account to domain.Account.
@@ -423,6 +748,76 @@ func intVal(data map[string]interface{}, key string) int {
 	return 0
 }
 
+// entriesData converts position entries to Firestore array values.
+func entriesData(entries []PositionEntry) []interface{} {
+	out := make([]interface{}, 0, len(entries))
+	for _, en := range entries {
+		out = append(out, map[string]interface{}{
+			"price":     en.Price,
+			"quantity":  en.Quantity,
+			"fee":       en.Fee,
+			"opened_at": en.At,
+		})
+	}
+	return out
+}
+
+// entriesVal reads position entries written by entriesData.
+func entriesVal(data map[string]interface{}, key string) []PositionEntry {
+	raw, _ := data[key].([]interface{})
+	var entries []PositionEntry
+	for _, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		en := PositionEntry{
+			Price:    float64Val(m, "price"),
+			Quantity: float64Val(m, "quantity"),
+			Fee:      float64Val(m, "fee"),
+		}
+		if t, ok := m["opened_at"].(time.Time); ok {
+			en.At = t
+		}
+		entries = append(entries, en)
+	}
+	return entries
+}
+
+// ladderData converts take-profit ladder rungs to Firestore array values.
+func ladderData(rungs []LadderRung) []interface{} {
+	out := make([]interface{}, 0, len(rungs))
+	for _, r := range rungs {
+		out = append(out, map[string]interface{}{
+			"r":         r.R,
+			"price":     r.Price,
+			"close_pct": r.ClosePct,
+			"filled":    r.Filled,
+		})
+	}
+	return out
+}
+
+// ladderVal reads take-profit ladder rungs written by ladderData.
+func ladderVal(data map[string]interface{}, key string) []LadderRung {
+	raw, _ := data[key].([]interface{})
+	var rungs []LadderRung
+	for _, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		filled, _ := m["filled"].(bool)
+		rungs = append(rungs, LadderRung{
+			R:        float64Val(m, "r"),
+			Price:    float64Val(m, "price"),
+			ClosePct: float64Val(m, "close_pct"),
+			Filled:   filled,
+		})
+	}
+	return rungs
+}
+
 // isFirestoreNotFound returns true when a Firestore error is "document not found".
 func isFirestoreNotFound(err error) bool {
 	if err == nil {
@@ -432,6 +827,12 @@ func isFirestoreNotFound(err error) bool {
 	return containsCode(err, "NotFound") || containsCode(err, "not found")
 }
 
+// isFirestoreAlreadyExists returns true when a Firestore create hit an
+// existing document.
+func isFirestoreAlreadyExists(err error) bool {
+	return containsCode(err, "AlreadyExists") || containsCode(err, "already exists")
+}
+
 func containsCode(err error, code string) bool {
 	if err == nil {
 		return false
@@ -29,6 +29,11 @@ type mockEngineStore struct {
 	listAccountsItems []domain.Account
 	dailyPnL          float64
 	dailyPnLErr       error
+	claimed           map[string]bool
+	claimErr          error
+	closingTrades     []domain.Trade
+	breakerStates     []engine.BreakerState
+	pauses            []engine.PauseState
 
 	// Captured calls
 	lastSubmittedTrade  *domain.Trade
@@ -93,6 +98,64 @@ func (m *mockEngineStore) DailyRealizedPnL(ctx context.Context, accountID string
 	return m.dailyPnL, m.dailyPnLErr
 }
 
+func (m *mockEngineStore) ListClosingTrades(ctx context.Context, accountID string, since time.Time) ([]domain.Trade, error) {
+	return m.closingTrades, nil
+}
+
+func (m *mockEngineStore) LoadBreakerStates(ctx context.Context, accountID string) ([]engine.BreakerState, error) {
+	return m.breakerStates, nil
+}
+
+func (m *mockEngineStore) SaveBreakerState(ctx context.Context, s *engine.BreakerState) error {
+	m.breakerStates = append(m.breakerStates, *s)
+	return nil
+}
+
+func (m *mockEngineStore) LoadPauses(ctx context.Context, tenantID uuid.UUID) ([]engine.PauseState, error) {
+	return m.pauses, nil
+}
+
+func (m *mockEngineStore) SavePause(ctx context.Context, tenantID uuid.UUID, p *engine.PauseState) error {
+	m.pauses = append(m.pauses, *p)
+	return nil
+}
+
+func (m *mockEngineStore) DeletePause(ctx context.Context, tenantID uuid.UUID, accountID, strategy string) error {
+	return nil
+}
+
+func (m *mockEngineStore) LoadPendingEntries(ctx context.Context, accountID string) ([]engine.PendingEntryState, error) {
+	return nil, nil
+}
+
+func (m *mockEngineStore) SavePendingEntry(ctx context.Context, p *engine.PendingEntryState) error {
+	return nil
+}
+
+func (m *mockEngineStore) DeletePendingEntry(ctx context.Context, accountID, symbol string) error {
+	return nil
+}
+
+func (m *mockEngineStore) ClaimSignal(ctx context.Context, accountID, signalID string, ttl time.Duration) (bool, error) {
+	if m.claimErr != nil {
+		return false, m.claimErr
+	}
+	if m.claimed == nil {
+		m.claimed = make(map[string]bool)
+	}
+	key := accountID + "/" + signalID
+	if m.claimed[key] {
+		return false, nil
+	}
+	m.claimed[key] = true
+	return true, nil
+}
+
+func (m *mockEngineStore) ReleaseSignal(ctx context.Context, accountID, signalID string) error {
+	delete(m.claimed, accountID+"/"+signalID)
+	return nil
+}
+
 // compile-time assertion that mockEngineStore satisfies EngineStore.
 var _ engine.EngineStore = (*mockEngineStore)(nil)
 
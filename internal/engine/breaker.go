@@ -0,0 +1,339 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// Circuit breakers beyond the daily loss limit.
+const (
+	breakerMaxDrawdown       = "max_drawdown"       // account: % below the equity peak in DRAWDOWN_WINDOW
+	breakerWeeklyLoss        = "weekly_loss"        // account: realised loss over the last 7 days
+	breakerConsecutiveLosses = "consecutive_losses" // strategy: losing closes in a row
+
+	breakerCheckInterval = time.Minute
+	weeklyLossWindow     = 7 * 24 * time.Hour
+)
+
+// BreakerState is the circuit-breaker state of an account, or of one
+// strategy on it. While a breaker is tripped no new positions are opened in
+// its scope; closes and risk exits continue. It is persisted so a trip
+// survives restarts.
+type BreakerState struct {
+	AccountID string    `json:"account_id"`
+	Strategy  string    `json:"strategy,omitempty"` // "" = the whole account
+	Breaker   string    `json:"breaker,omitempty"`  // tripped breaker; "" = armed
+	Value     float64   `json:"value,omitempty"`    // measured value when it tripped
+	Limit     float64   `json:"limit,omitempty"`
+	TrippedAt time.Time `json:"tripped_at,omitzero"`
+	Until     time.Time `json:"until,omitzero"` // end of the cooldown; zero = until re-armed
+
+	// ArmedAt is when the breaker was last re-armed; trades before it do
+	// not count towards a new trip.
+	ArmedAt time.Time `json:"armed_at,omitzero"`
+}
+
+// active reports whether the breaker is tripped and still cooling down.
+func (s *BreakerState) active(now time.Time) bool {
+	return s.Breaker != "" && (s.Until.IsZero() || now.Before(s.Until))
+}
+
+// breakerKey scopes a breaker to an account, or to a strategy on it.
+type breakerKey struct {
+	accountID string
+	strategy  string
+}
+
+// tradesSince returns the trades after since; trades must be sorted by time.
+func tradesSince(trades []domain.Trade, since time.Time) []domain.Trade {
+	i := sort.Search(len(trades), func(i int) bool { return trades[i].Timestamp.After(since) })
+	return trades[i:]
+}
+
+// drawdownPct returns how far equity is below its peak, in percent, with
+// equity rebuilt backwards from the current balance through each trade's
+// realised P&L.
+func drawdownPct(balance float64, trades []domain.Trade) float64 {
+	equity, peak := balance, balance
+	for i := len(trades) - 1; i >= 0; i-- {
+		equity -= trades[i].RealizedPnL
+		if equity > peak {
+			peak = equity
+		}
+	}
+	if peak <= 0 {
+		return 0
+	}
+	return (peak - balance) / peak * 100
+}
+
+// consecutiveLosses counts the losing trades at the end of trades.
+func consecutiveLosses(trades []domain.Trade) int {
+	n := 0
+	for i := len(trades) - 1; i >= 0 && trades[i].RealizedPnL < 0; i-- {
+		n++
+	}
+	return n
+}
+
+// breakerState returns a copy of the breaker state for the scope; the zero
+// state is armed.
+func (e *Engine) breakerState(accountID, strategy string) BreakerState {
+	e.breakerMu.RLock()
+	defer e.breakerMu.RUnlock()
+	if st, ok := e.breakers[breakerKey{accountID, strategy}]; ok {
+		return *st
+	}
+	return BreakerState{AccountID: accountID, Strategy: strategy}
+}
+
+// openBlockedBy returns the tripped breaker that pauses opens for strategy on
+// the account — the account's own first — or nil.
+func (e *Engine) openBlockedBy(accountID, strategy string) *BreakerState {
+	now := time.Now()
+	e.breakerMu.RLock()
+	defer e.breakerMu.RUnlock()
+	for _, key := range []breakerKey{{accountID, ""}, {accountID, strategy}} {
+		if st, ok := e.breakers[key]; ok && st.active(now) {
+			cp := *st
+			return &cp
+		}
+	}
+	return nil
+}
+
+// saveBreaker persists st and then makes it the in-memory state.
+func (e *Engine) saveBreaker(ctx context.Context, st BreakerState) error {
+	if err := e.repo.SaveBreakerState(ctx, &st); err != nil {
+		return err
+	}
+	e.breakerMu.Lock()
+	e.breakers[breakerKey{st.AccountID, st.Strategy}] = &st
+	e.breakerMu.Unlock()
+	return nil
+}
+
+// tripBreaker trips the scope's breaker for b, unless it was re-armed since
+// armedAt (the state the breach was measured against). Opens stay paused even
+// if the trip cannot be persisted.
+func (e *Engine) tripBreaker(ctx context.Context, accountID, strategy string, armedAt time.Time, b *limitBreach, now time.Time) {
+	if cur := e.breakerState(accountID, strategy); !cur.ArmedAt.Equal(armedAt) || cur.active(now) {
+		return
+	}
+	st := BreakerState{
+		AccountID: accountID,
+		Strategy:  strategy,
+		Breaker:   b.Limit,
+		Value:     b.Value,
+		Limit:     b.Max,
+		TrippedAt: now,
+		ArmedAt:   armedAt,
+	}
+	if e.cfg.BreakerCooldown > 0 {
+		st.Until = now.Add(e.cfg.BreakerCooldown)
+	}
+	logger := e.logger.With().Str("account", accountID).Str("strategy", strategy).Logger()
+	logger.Warn().
+		Str("breaker", b.Limit).
+		Float64("value", b.Value).
+		Float64("limit", b.Max).
+		Time("until", st.Until).
+		Msg("circuit breaker tripped — pausing new positions")
+	if err := e.saveBreaker(ctx, st); err != nil {
+		logger.Error().Err(err).Msg("failed to persist circuit breaker trip — pausing in memory only")
+		e.breakerMu.Lock()
+		e.breakers[breakerKey{accountID, strategy}] = &st
+		e.breakerMu.Unlock()
+	}
+}
+
+// rearmExpiredBreakers re-arms the account's breakers whose cooldown has
+// passed; trades up to the end of the cooldown no longer count.
+func (e *Engine) rearmExpiredBreakers(ctx context.Context, accountID string, now time.Time) {
+	e.breakerMu.RLock()
+	var expired []BreakerState
+	for key, st := range e.breakers {
+		if key.accountID == accountID && st.Breaker != "" && !st.active(now) {
+			expired = append(expired, *st)
+		}
+	}
+	e.breakerMu.RUnlock()
+
+	for _, st := range expired {
+		breaker := st.Breaker
+		st = BreakerState{AccountID: st.AccountID, Strategy: st.Strategy, ArmedAt: st.Until}
+		if err := e.saveBreaker(ctx, st); err != nil {
+			e.logger.Error().Err(err).Str("account", accountID).Str("strategy", st.Strategy).
+				Msg("failed to persist circuit breaker re-arm")
+			continue
+		}
+		e.logger.Info().Str("account", accountID).Str("strategy", st.Strategy).Str("breaker", breaker).
+			Msg("circuit breaker cooldown passed — re-armed")
+	}
+}
+
+// evaluateBreakers re-arms breakers whose cooldown has passed, then checks
+// the account's closing trades against the drawdown, weekly loss and
+// consecutive loss limits and trips any that are breached.
+func (e *Engine) evaluateBreakers(ctx context.Context, accountID string) error {
+	now := time.Now()
+	e.rearmExpiredBreakers(ctx, accountID, now)
+
+	window := max(e.cfg.DrawdownWindow, weeklyLossWindow)
+	trades, err := e.repo.ListClosingTrades(ctx, accountID, now.Add(-window))
+	if err != nil {
+		return fmt.Errorf("list closing trades: %w", err)
+	}
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].Timestamp.Before(trades[j].Timestamp) })
+
+	if acct := e.breakerState(accountID, ""); !acct.active(now) {
+		b, err := e.accountBreach(ctx, accountID, tradesSince(trades, acct.ArmedAt), now)
+		if err != nil {
+			return err
+		}
+		if b != nil {
+			e.tripBreaker(ctx, accountID, "", acct.ArmedAt, b, now)
+		}
+	}
+
+	if e.cfg.MaxConsecutiveLosses > 0 {
+		byStrategy := make(map[string][]domain.Trade)
+		for _, t := range trades {
+			if t.Strategy != nil && *t.Strategy != "" {
+				byStrategy[*t.Strategy] = append(byStrategy[*t.Strategy], t)
+			}
+		}
+		for strategy, st := range byStrategy {
+			state := e.breakerState(accountID, strategy)
+			if state.active(now) {
+				continue
+			}
+			if n := consecutiveLosses(tradesSince(st, state.ArmedAt)); n >= e.cfg.MaxConsecutiveLosses {
+				e.tripBreaker(ctx, accountID, strategy, state.ArmedAt, &limitBreach{
+					Limit: breakerConsecutiveLosses,
+					Value: float64(n),
+					Max:   float64(e.cfg.MaxConsecutiveLosses),
+				}, now)
+			}
+		}
+	}
+	return nil
+}
+
+// accountBreach returns the account-wide breaker breached by trades (sorted,
+// already limited to those since the last re-arm), or nil.
+func (e *Engine) accountBreach(ctx context.Context, accountID string, trades []domain.Trade, now time.Time) (*limitBreach, error) {
+	if e.cfg.WeeklyLossLimit > 0 {
+		var pnl float64
+		for _, t := range tradesSince(trades, now.Add(-weeklyLossWindow)) {
+			pnl += t.RealizedPnL
+		}
+		if -pnl >= e.cfg.WeeklyLossLimit {
+			return &limitBreach{Limit: breakerWeeklyLoss, Value: -pnl, Max: e.cfg.WeeklyLossLimit}, nil
+		}
+	}
+	if e.cfg.MaxDrawdownPct > 0 {
+		balance, err := e.repo.GetAccountBalance(ctx, e.tenantID(), accountID, "USD")
+		if err != nil {
+			return nil, fmt.Errorf("get balance: %w", err)
+		}
+		if balance != nil {
+			dd := drawdownPct(*balance, tradesSince(trades, now.Add(-e.cfg.DrawdownWindow)))
+			if dd >= e.cfg.MaxDrawdownPct {
+				return &limitBreach{Limit: breakerMaxDrawdown, Value: dd, Max: e.cfg.MaxDrawdownPct}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// breakersEnabled reports whether any circuit breaker is configured.
+func (e *Engine) breakersEnabled() bool {
+	return e.cfg.MaxDrawdownPct > 0 || e.cfg.WeeklyLossLimit > 0 || e.cfg.MaxConsecutiveLosses > 0
+}
+
+// startBreakerMonitor evaluates every managed account's circuit breakers
+// every minute. Blocks until ctx is cancelled.
+func (e *Engine) startBreakerMonitor(ctx context.Context) {
+	ticker := time.NewTicker(breakerCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, accountID := range e.accounts {
+			if err := e.evaluateBreakers(ctx, accountID); err != nil && ctx.Err() == nil {
+				e.logger.Warn().Err(err).Str("account", accountID).Msg("circuit breaker evaluation failed")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// loadBreakers restores the account's persisted breaker states.
+func (e *Engine) loadBreakers(ctx context.Context, accountID string) (int, error) {
+	states, err := e.repo.LoadBreakerStates(ctx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	tripped := 0
+	e.breakerMu.Lock()
+	for i := range states {
+		st := states[i]
+		e.breakers[breakerKey{accountID, st.Strategy}] = &st
+		if st.active(now) {
+			tripped++
+		}
+	}
+	e.breakerMu.Unlock()
+	return tripped, nil
+}
+
+// Breakers returns the tripped circuit breakers of every managed account.
+func (e *Engine) Breakers() []BreakerState {
+	now := time.Now()
+	e.breakerMu.RLock()
+	out := make([]BreakerState, 0, len(e.breakers))
+	for _, st := range e.breakers {
+		if st.active(now) {
+			out = append(out, *st)
+		}
+	}
+	e.breakerMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AccountID != out[j].AccountID {
+			return out[i].AccountID < out[j].AccountID
+		}
+		return out[i].Strategy < out[j].Strategy
+	})
+	return out
+}
+
+// RearmBreaker re-arms a tripped breaker for the account, or for one strategy
+// on it when strategy is set, so opens resume. Trades before now no longer
+// count towards a trip. Returns false when the scope is not tripped. Only the
+// leader evaluates breakers, and it reads them from the store only when it
+// wins the lease, so a re-arm on any other replica returns ErrNotLeader.
+func (e *Engine) RearmBreaker(ctx context.Context, accountID, strategy string) (bool, error) {
+	if !e.IsLeader() {
+		return false, ErrNotLeader
+	}
+	now := time.Now()
+	cur := e.breakerState(accountID, strategy)
+	if !cur.active(now) {
+		return false, nil
+	}
+	if err := e.saveBreaker(ctx, BreakerState{AccountID: accountID, Strategy: strategy, ArmedAt: now}); err != nil {
+		return false, fmt.Errorf("re-arm breaker: %w", err)
+	}
+	e.logger.Info().Str("account", accountID).Str("strategy", strategy).Str("breaker", cur.Breaker).
+		Msg("circuit breaker re-armed by operator")
+	return true, nil
+}
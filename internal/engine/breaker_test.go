@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// breakerStore serves closing trades and a balance, and records saved
+// breaker states.
+type breakerStore struct {
+	EngineStore
+	trades  []domain.Trade
+	balance *float64
+	saved   map[breakerKey]BreakerState
+}
+
+func (s *breakerStore) ListClosingTrades(_ context.Context, _ string, since time.Time) ([]domain.Trade, error) {
+	return tradesSince(s.trades, since), nil
+}
+
+func (s *breakerStore) GetAccountBalance(_ context.Context, _ uuid.UUID, _, _ string) (*float64, error) {
+	return s.balance, nil
+}
+
+func (s *breakerStore) LoadBreakerStates(_ context.Context, accountID string) ([]BreakerState, error) {
+	var out []BreakerState
+	for key, st := range s.saved {
+		if key.accountID == accountID {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+func (s *breakerStore) SaveBreakerState(_ context.Context, st *BreakerState) error {
+	if s.saved == nil {
+		s.saved = make(map[breakerKey]BreakerState)
+	}
+	s.saved[breakerKey{st.AccountID, st.Strategy}] = *st
+	return nil
+}
+
+// closed returns a closing trade for strategy with pnl, ago before now.
+func closed(strategy string, pnl float64, ago time.Duration) domain.Trade {
+	return domain.Trade{Symbol: "BTC-USD", Strategy: &strategy, RealizedPnL: pnl, CostBasis: 1000, Timestamp: time.Now().Add(-ago)}
+}
+
+func TestDrawdownPctAndConsecutiveLosses(t *testing.T) {
+	// Equity 1000 → 1200 → 900 → 950 now.
+	trades := []domain.Trade{{RealizedPnL: 200}, {RealizedPnL: -300}, {RealizedPnL: 50}}
+	assertFloat(t, "drawdown from 1200", 250.0/1200*100, drawdownPct(950, trades))
+	assertFloat(t, "no trades", 0, drawdownPct(950, nil))
+
+	if n := consecutiveLosses([]domain.Trade{{RealizedPnL: -1}, {RealizedPnL: 2}, {RealizedPnL: -1}, {RealizedPnL: -3}}); n != 2 {
+		t.Errorf("want 2 trailing losses, got %d", n)
+	}
+}
+
+func TestEvaluateBreakers_TripsPersistsAndRearms(t *testing.T) {
+	cfg := &config.Config{WeeklyLossLimit: 500, MaxConsecutiveLosses: 3, DrawdownWindow: 30 * 24 * time.Hour, BreakerCooldown: time.Hour}
+	store := &breakerStore{trades: []domain.Trade{
+		closed("trend", -200, 10*24*time.Hour), // outside the week
+		closed("trend", -150, 3*time.Hour),
+		closed("scalp", -100, 2*time.Hour),
+		closed("trend", -150, time.Hour),
+		closed("trend", -150, time.Minute),
+	}}
+	e := makeEngine(cfg)
+	e.repo = store
+	ctx := context.Background()
+
+	if err := e.evaluateBreakers(ctx, "acc"); err != nil {
+		t.Fatal(err)
+	}
+	acct := store.saved[breakerKey{"acc", ""}]
+	assertEq(t, "account breaker", breakerWeeklyLoss, acct.Breaker)
+	assertFloat(t, "weekly loss", 550, acct.Value)
+	if acct.Until.IsZero() {
+		t.Error("trip should carry the cooldown")
+	}
+	assertEq(t, "strategy breaker", breakerConsecutiveLosses, store.saved[breakerKey{"acc", "trend"}].Breaker)
+	if _, ok := store.saved[breakerKey{"acc", "scalp"}]; ok {
+		t.Error("one loss should not trip scalp")
+	}
+	if st := e.openBlockedBy("acc", "scalp"); st == nil || st.Breaker != breakerWeeklyLoss {
+		t.Fatalf("account trip should block every strategy, got %+v", st)
+	}
+
+	// A restarted engine restores the trips from the store.
+	restarted := makeEngine(cfg)
+	restarted.repo = store
+	if n, err := restarted.loadBreakers(ctx, "acc"); err != nil || n != 2 {
+		t.Fatalf("want 2 tripped breakers restored, got %d %v", n, err)
+	}
+
+	// Only the leader re-arms: a follower's breakers are not the ones
+	// blocking opens.
+	if _, err := restarted.RearmBreaker(ctx, "acc", ""); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("follower re-arm: want ErrNotLeader, got %v", err)
+	}
+
+	// Re-arming clears the trip, and the losses before it no longer count.
+	e.leading.Store(true)
+	for _, strategy := range []string{"", "trend"} {
+		if ok, err := e.RearmBreaker(ctx, "acc", strategy); !ok || err != nil {
+			t.Fatalf("re-arm %q: %v %v", strategy, ok, err)
+		}
+	}
+	if ok, _ := e.RearmBreaker(ctx, "acc", "scalp"); ok {
+		t.Error("an armed scope cannot be re-armed")
+	}
+	if err := e.evaluateBreakers(ctx, "acc"); err != nil {
+		t.Fatal(err)
+	}
+	if st := e.openBlockedBy("acc", "trend"); st != nil {
+		t.Errorf("re-armed breakers should not trip again on old trades, got %+v", st)
+	}
+}
+
+func TestEvaluateBreakers_CooldownExpiryRearms(t *testing.T) {
+	cfg := &config.Config{MaxConsecutiveLosses: 2, BreakerCooldown: time.Hour}
+	trippedAt := time.Now().Add(-2 * time.Hour)
+	store := &breakerStore{
+		trades: []domain.Trade{closed("trend", -10, 3*time.Hour), closed("trend", -10, 150*time.Minute)},
+		saved: map[breakerKey]BreakerState{{"acc", "trend"}: {
+			AccountID: "acc", Strategy: "trend", Breaker: breakerConsecutiveLosses, TrippedAt: trippedAt, Until: trippedAt.Add(time.Hour),
+		}},
+	}
+	e := makeEngine(cfg)
+	e.repo = store
+	if _, err := e.loadBreakers(context.Background(), "acc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.evaluateBreakers(context.Background(), "acc"); err != nil {
+		t.Fatal(err)
+	}
+	st := store.saved[breakerKey{"acc", "trend"}]
+	if st.Breaker != "" || !st.ArmedAt.Equal(trippedAt.Add(time.Hour)) {
+		t.Errorf("expired trip should re-arm at the end of its cooldown, got %+v", st)
+	}
+}
+
+func TestHandleOpenSignal_SkipsWhileBreakerTripped(t *testing.T) {
+	cfg := &config.Config{TradingMode: "paper", PortfolioSize: 10000, PositionSizePct: 10}
+	e := makeEngine(cfg)
+	e.exchange = NewNoopExchange(cfg)
+	e.exchanges = NewPaperExchangeRegistry(e.exchange)
+	e.repo = &breakerStore{}
+	e.breakers[breakerKey{"acc", "trend"}] = &BreakerState{AccountID: "acc", Strategy: "trend", Breaker: breakerConsecutiveLosses}
+
+	// Reaching balance or ledger calls on the store would panic.
+	e.handleOpenSignal(context.Background(), SignalPayload{Action: "BUY", Price: 50000}, "BTC-USD", "trend", "acc", &TradingConfig{Exchange: "binance"})
+
+	if _, open := e.posState[posKey("acc", "BTC-USD")]; open {
+		t.Error("a tripped strategy must not open positions")
+	}
+}
@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Signal-ngn/risk"
+)
+
+// StopMovedEvent is published on the trade stream when the engine moves a
+// position's stop loss. Trade events have no "event" field.
+type StopMovedEvent struct {
+	Event      string    `json:"event"` // "stop_moved"
+	AccountID  string    `json:"account_id"`
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"position_side"`
+	EntryPrice float64   `json:"entry_price"`
+	OldStop    float64   `json:"old_stop"`
+	NewStop    float64   `json:"new_stop"`
+	Price      float64   `json:"price"`
+	Reason     string    `json:"reason"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// breakEvenPrice returns the exit price at which the position nets zero after
+// fees: the weighted entry shifted by the entry fees paid plus an exit fee at
+// the same rate. Without recorded fees it is the entry price.
+func breakEvenPrice(ps *PositionState) float64 {
+	var fees, notional float64
+	for _, en := range ps.Entries {
+		fees += en.Fee
+		notional += en.Price * en.Quantity
+	}
+	var feeRate float64
+	if notional > 0 {
+		feeRate = fees / notional
+	}
+	if ps.Side == "short" {
+		return ps.EntryPrice * (1 - 2*feeRate)
+	}
+	return ps.EntryPrice * (1 + 2*feeRate)
+}
+
+// breakEvenDue reports whether price has moved BreakEvenR multiples of the
+// entry risk in the position's favour and the stop has not been moved yet.
+// R is the distance from entry to the stop loss, or 4% of entry without one.
+func breakEvenDue(ps *PositionState, price float64) bool {
+	if ps.BreakEvenR <= 0 || ps.BreakEven || ps.EntryPrice <= 0 {
+		return false
+	}
+	unit := ps.EntryPrice * 0.04
+	if ps.StopLoss > 0 {
+		unit = math.Abs(ps.EntryPrice - ps.StopLoss)
+	}
+	move := ps.BreakEvenR * unit
+	if ps.Side == "short" {
+		return price <= ps.EntryPrice-move
+	}
+	return price >= ps.EntryPrice+move
+}
+
+// breakEvenStopHit returns the exit decision when a moved break-even stop has
+// been crossed. The risk library is evaluated against the entry stop so its
+// trailing distance is unaffected by the move; this check enforces the
+// tighter stop.
+func breakEvenStopHit(ps *PositionState, price float64) (risk.ExitDecision, bool) {
+	if !ps.BreakEven || ps.StopLoss <= 0 {
+		return risk.ExitDecision{}, false
+	}
+	if (ps.Side == "long" && price > ps.StopLoss) || (ps.Side == "short" && price < ps.StopLoss) {
+		return risk.ExitDecision{}, false
+	}
+	detail := fmt.Sprintf("price $%.4f hit stop $%.4f", price, ps.StopLoss)
+	return risk.ExitDecision{
+		Layer:      1,
+		Label:      "break-even stop",
+		Detail:     detail,
+		ExitReason: "Layer 1: break-even stop — " + detail,
+	}, true
+}
+
+// moveStopToBreakEven moves the stop loss to entry plus fees, replaces the
+// exchange-side stop, persists the change and publishes a stop_moved event.
+func (e *Engine) moveStopToBreakEven(ctx context.Context, ps *PositionState, price float64) {
+	logger := e.logger.With().
+		Str("account", ps.AccountID).
+		Str("symbol", ps.Symbol).
+		Logger()
+
+	e.posStateMu.Lock()
+	if !breakEvenDue(ps, price) {
+		e.posStateMu.Unlock()
+		return
+	}
+	oldSL := ps.StopLoss
+	oldStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, ps.TrailingStop)
+	ps.EntryStopLoss = oldSL
+	ps.StopLoss = breakEvenPrice(ps)
+	ps.BreakEven = true
+	newStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, ps.TrailingStop)
+	e.posStateMu.Unlock()
+
+	if newStop != oldStop {
+		id := e.replaceProtectiveStop(ctx, ps, newStop)
+		e.posStateMu.Lock()
+		ps.StopOrderID = id
+		e.posStateMu.Unlock()
+	}
+
+	e.posStateMu.RLock()
+	rec := ps.record()
+	e.posStateMu.RUnlock()
+	if err := e.repo.UpdatePositionState(ctx, e.tenantID(), rec); err != nil {
+		logger.Warn().Err(err).Msg("failed to persist break-even stop")
+	}
+
+	reason := fmt.Sprintf("price moved %gR in favour", ps.BreakEvenR)
+	logger.Info().
+		Float64("old_stop", oldSL).
+		Float64("new_stop", rec.StopLoss).
+		Float64("price", price).
+		Str("reason", reason).
+		Msg("stop moved to break-even")
+
+	if e.publisher != nil {
+		e.publisher.Publish(ps.AccountID, StopMovedEvent{
+			Event:      "stop_moved",
+			AccountID:  ps.AccountID,
+			Symbol:     ps.Symbol,
+			Side:       ps.Side,
+			EntryPrice: rec.EntryPrice,
+			OldStop:    oldSL,
+			NewStop:    rec.StopLoss,
+			Price:      price,
+			Reason:     reason,
+			Timestamp:  time.Now().UTC(),
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Signal-ngn/trader/internal/config"
+)
+
+// recordingPublisher captures stream events.
+type recordingPublisher struct {
+	events []interface{}
+}
+
+func (p *recordingPublisher) Publish(_ string, payload interface{}) {
+	p.events = append(p.events, payload)
+}
+
+func TestBreakEvenPrice_IncludesFees(t *testing.T) {
+	ps := &PositionState{Side: "long", EntryPrice: 100, Entries: []PositionEntry{{Price: 100, Quantity: 10, Fee: 1}}}
+	assertFloat(t, "long", 100.2, breakEvenPrice(ps))
+
+	ps.Side = "short"
+	assertFloat(t, "short", 99.8, breakEvenPrice(ps))
+
+	noFees := &PositionState{Side: "long", EntryPrice: 100}
+	assertFloat(t, "no fees", 100, breakEvenPrice(noFees))
+}
+
+func TestBreakEvenDue(t *testing.T) {
+	ps := &PositionState{Side: "long", EntryPrice: 100, StopLoss: 95, BreakEvenR: 1.5}
+	if breakEvenDue(ps, 107) {
+		t.Error("1.4R should not trigger")
+	}
+	if !breakEvenDue(ps, 107.5) {
+		t.Error("1.5R should trigger")
+	}
+
+	short := &PositionState{Side: "short", EntryPrice: 100, BreakEvenR: 1}
+	if !breakEvenDue(short, 96) {
+		t.Error("short: 1R with the 4% fallback should trigger")
+	}
+
+	ps.BreakEven = true
+	if breakEvenDue(ps, 200) {
+		t.Error("already moved")
+	}
+	if breakEvenDue(&PositionState{Side: "long", EntryPrice: 100, StopLoss: 95}, 200) {
+		t.Error("rule disabled without break_even_r")
+	}
+}
+
+func TestMoveStopToBreakEven_PersistsAndPublishes(t *testing.T) {
+	store := &stateStore{}
+	pub := &recordingPublisher{}
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = store
+	e.publisher = pub
+	ps := &PositionState{
+		AccountID: "acc", Symbol: "ETH-USD", Side: "long",
+		EntryPrice: 100, StopLoss: 95, BreakEvenR: 1,
+		Entries: []PositionEntry{{Price: 100, Quantity: 10, Fee: 1}},
+	}
+
+	e.moveStopToBreakEven(context.Background(), ps, 105)
+
+	if len(store.updated) != 1 {
+		t.Fatalf("want one state update, got %d", len(store.updated))
+	}
+	rec := store.updated[0]
+	assertFloat(t, "stop loss", 100.2, rec.StopLoss)
+	assertFloat(t, "entry stop kept", 95, rec.EntryStopLoss)
+	if !rec.BreakEven {
+		t.Error("break-even flag not persisted")
+	}
+
+	if len(pub.events) != 1 {
+		t.Fatalf("want one event, got %d", len(pub.events))
+	}
+	ev := pub.events[0].(StopMovedEvent)
+	assertEq(t, "event", "stop_moved", ev.Event)
+	assertFloat(t, "old stop", 95, ev.OldStop)
+	assertFloat(t, "new stop", 100.2, ev.NewStop)
+
+	// A second trigger is a no-op.
+	e.moveStopToBreakEven(context.Background(), ps, 110)
+	if len(store.updated) != 1 || len(pub.events) != 1 {
+		t.Error("stop should only move once")
+	}
+}
+
+func TestBreakEvenStopHit(t *testing.T) {
+	ps := &PositionState{Side: "long", EntryPrice: 100, StopLoss: 100.2, EntryStopLoss: 95, BreakEven: true}
+	if _, hit := breakEvenStopHit(ps, 101); hit {
+		t.Error("above the stop should not exit")
+	}
+	d, hit := breakEvenStopHit(ps, 100.1)
+	if !hit || d.Layer != 1 || d.Label != "break-even stop" {
+		t.Errorf("want layer-1 break-even exit, got %+v hit=%v", d, hit)
+	}
+
+	ps.BreakEven = false
+	if _, hit := breakEvenStopHit(ps, 90); hit {
+		t.Error("an unmoved stop is left to the risk library")
+	}
+}
@@ -0,0 +1,355 @@
+package engine
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// ── fake Advanced Trade API ───────────────────────────────────────────────────
+
+// fakeCoinbase is an httptest stand-in for the Advanced Trade endpoints the
+// adapter uses. It verifies every request's JWT against the public key.
+type fakeCoinbase struct {
+	t   *testing.T
+	pub *ecdsa.PublicKey
+
+	mu         sync.Mutex
+	orders     []map[string]any // create-order request bodies
+	orderState map[string]coinbaseOrder
+	cancelled  []string
+	usd        string
+	products   map[string]map[string]string // product ID → increments
+	productGet int
+}
+
+func (f *fakeCoinbase) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.verifyJWT(r)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v3/brokerage/orders":
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.orders = append(f.orders, body)
+		id := "cb-order-" + strconv.Itoa(len(f.orders))
+		if _, ok := f.orderState[id]; !ok {
+			f.orderState[id] = coinbaseOrder{
+				OrderID: id, Status: "FILLED",
+				AverageFilledPrice: "50000", FilledSize: "0.02",
+				FilledValue: "1000", TotalFees: "6",
+			}
+		}
+		writeJSON(w, map[string]any{"success": true, "success_response": map[string]string{"order_id": id}})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v3/brokerage/orders/historical/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/v3/brokerage/orders/historical/")
+		writeJSON(w, map[string]any{"order": f.orderState[id]})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v3/brokerage/orders/batch_cancel":
+		var body struct {
+			OrderIDs []string `json:"order_ids"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.cancelled = append(f.cancelled, body.OrderIDs...)
+		writeJSON(w, map[string]any{"results": []map[string]any{{"success": true, "order_id": body.OrderIDs[0]}}})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v3/brokerage/products/"):
+		f.productGet++
+		p, ok := f.products[strings.TrimPrefix(r.URL.Path, "/api/v3/brokerage/products/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, p)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v3/brokerage/accounts":
+		writeJSON(w, map[string]any{"accounts": []map[string]any{
+			{"currency": "USD", "available_balance": map[string]string{"value": f.usd}},
+			{"currency": "BTC", "available_balance": map[string]string{"value": "0.5"}},
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// verifyJWT checks the ES256 signature and the uri claim.
+func (f *fakeCoinbase) verifyJWT(r *http.Request) {
+	f.t.Helper()
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		f.t.Errorf("malformed JWT %q", token)
+		return
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(f.pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		f.t.Errorf("JWT signature does not verify for %s %s", r.Method, r.URL.Path)
+	}
+	var claims struct {
+		Sub string `json:"sub"`
+		Iss string `json:"iss"`
+		URI string `json:"uri"`
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	_ = json.Unmarshal(raw, &claims)
+	if want := r.Method + " " + r.Host + r.URL.Path; claims.URI != want {
+		f.t.Errorf("JWT uri: want %q, got %q", want, claims.URI)
+	}
+	if claims.Iss != "cdp" || claims.Sub != "organizations/org/apiKeys/key" {
+		f.t.Errorf("JWT claims: got iss=%q sub=%q", claims.Iss, claims.Sub)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newCoinbaseTest(t *testing.T) (*CoinbaseSpotExchange, *fakeCoinbase) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+
+	fake := &fakeCoinbase{t: t, pub: &key.PublicKey, orderState: make(map[string]coinbaseOrder), usd: "2500.50"}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	ex := NewCoinbaseSpotExchange(&config.Config{
+		CoinbaseAPIKey: "organizations/org/apiKeys/key",
+		// Single-line env form with escaped newlines.
+		CoinbaseAPISecret: strings.ReplaceAll(pemKey, "\n", `\n`),
+		CoinbaseAPIURL:    srv.URL,
+	})
+	ex.fillPollInterval = time.Millisecond
+	return ex, fake
+}
+
+// ── tests ─────────────────────────────────────────────────────────────────────
+
+func TestCoinbase_MarketBuyReportsFillAndFees(t *testing.T) {
+	ex, fake := newCoinbaseTest(t)
+
+	res, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol:  "BTC-USD",
+		Side:    domain.PositionSideLong,
+		SizeUSD: 1000,
+		Price:   50000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertFloat(t, "FillPrice", 50000, res.FillPrice)
+	assertFloat(t, "Quantity", 0.02, res.Quantity)
+	assertFloat(t, "Fee", 6, res.Fee)
+	assertEq(t, "Status", string(domain.OrderStatusFilled), string(res.Status))
+
+	body := fake.orders[0]
+	assertEq(t, "product_id", "BTC-USD", body["product_id"].(string))
+	assertEq(t, "side", "BUY", body["side"].(string))
+	cfg := body["order_configuration"].(map[string]any)["market_market_ioc"].(map[string]any)
+	assertEq(t, "quote_size", "1000.00", cfg["quote_size"].(string))
+}
+
+func TestCoinbase_MarketOrderPolledUntilFilled(t *testing.T) {
+	ex, fake := newCoinbaseTest(t)
+	fake.orderState["cb-order-1"] = coinbaseOrder{OrderID: "cb-order-1", Status: "PENDING"}
+	go func() {
+		time.Sleep(3 * time.Millisecond)
+		fake.mu.Lock()
+		fake.orderState["cb-order-1"] = coinbaseOrder{OrderID: "cb-order-1", Status: "FILLED", AverageFilledPrice: "3000", FilledSize: "1", TotalFees: "1.8"}
+		fake.mu.Unlock()
+	}()
+	ex.fillPollAttempts = 1000
+
+	res, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol: "ETH-USD", Side: domain.PositionSideLong, SizeUSD: 3000, Price: 3000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertFloat(t, "FillPrice", 3000, res.FillPrice)
+	assertFloat(t, "Fee", 1.8, res.Fee)
+}
+
+func TestCoinbase_LimitEntryRestsAndCancels(t *testing.T) {
+	ex, fake := newCoinbaseTest(t)
+	fake.orderState["cb-order-1"] = coinbaseOrder{OrderID: "cb-order-1", Status: "OPEN"}
+	ctx := context.Background()
+
+	res, err := ex.OpenPosition(ctx, OpenPositionRequest{
+		Symbol: "BTC-USD", Side: domain.PositionSideLong, SizeUSD: 1000, Price: 50000,
+		OrderType: OrderTypePostOnly, LimitPrice: 49000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Pending() || res.OrderID != "cb-order-1" {
+		t.Fatalf("want resting order cb-order-1, got status=%s id=%s", res.Status, res.OrderID)
+	}
+	lim := fake.orders[0]["order_configuration"].(map[string]any)["limit_limit_gtc"].(map[string]any)
+	if lim["post_only"] != true || lim["limit_price"] != "49000" {
+		t.Errorf("limit config: got %v", lim)
+	}
+
+	if err := ex.CancelOrder(ctx, "BTC-USD", res.OrderID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	assertEq(t, "cancelled", "cb-order-1", fake.cancelled[0])
+}
+
+func TestCoinbase_CloseSellsLedgerQuantity(t *testing.T) {
+	ex, fake := newCoinbaseTest(t)
+
+	_, err := ex.ClosePosition(context.Background(), ClosePositionRequest{
+		Symbol: "BTC-USD", Side: domain.PositionSideLong, MarketType: domain.MarketTypeSpot, Quantity: 0.0212345678,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body := fake.orders[0]
+	assertEq(t, "side", "SELL", body["side"].(string))
+	cfg := body["order_configuration"].(map[string]any)["market_market_ioc"].(map[string]any)
+	assertEq(t, "base_size", "0.02123456", cfg["base_size"].(string))
+}
+
+func TestCoinbase_OrdersRoundedToProductIncrements(t *testing.T) {
+	ex, fake := newCoinbaseTest(t)
+	fake.products = map[string]map[string]string{
+		"ETH-USD": {"quote_increment": "0.01", "base_increment": "0.0001"},
+	}
+	fake.orderState["cb-order-1"] = coinbaseOrder{OrderID: "cb-order-1", Status: "OPEN"}
+	ctx := context.Background()
+
+	if _, err := ex.OpenPosition(ctx, OpenPositionRequest{
+		Symbol: "ETH-USD", Side: domain.PositionSideLong, SizeUSD: 1000, Price: 3000,
+		OrderType: OrderTypeLimit, LimitPrice: 2999.4567,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lim := fake.orders[0]["order_configuration"].(map[string]any)["limit_limit_gtc"].(map[string]any)
+	assertEq(t, "limit_price", "2999.45", lim["limit_price"].(string))
+	assertEq(t, "base_size", "0.3333", lim["base_size"].(string))
+
+	if _, err := ex.ClosePosition(ctx, ClosePositionRequest{
+		Symbol: "ETH-USD", Side: domain.PositionSideLong, MarketType: domain.MarketTypeSpot, Quantity: 0.33349,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := fake.orders[1]["order_configuration"].(map[string]any)["market_market_ioc"].(map[string]any)
+	assertEq(t, "close base_size", "0.3334", cfg["base_size"].(string))
+	if fake.productGet != 1 {
+		t.Errorf("product increments should be fetched once, got %d fetches", fake.productGet)
+	}
+}
+
+func TestCoinbase_UnfilledCloseIsCancelled(t *testing.T) {
+	ex, fake := newCoinbaseTest(t)
+	ex.fillPollAttempts = 1
+	fake.orderState["cb-order-1"] = coinbaseOrder{OrderID: "cb-order-1", Status: "OPEN"}
+	fake.orderState["cb-order-2"] = coinbaseOrder{OrderID: "cb-order-2", Status: "OPEN", AverageFilledPrice: "50000", FilledSize: "0.01"}
+	ctx := context.Background()
+	req := ClosePositionRequest{Symbol: "BTC-USD", Side: domain.PositionSideLong, MarketType: domain.MarketTypeSpot, Quantity: 0.02}
+
+	if _, err := ex.ClosePosition(ctx, req); err == nil {
+		t.Fatal("an unfilled close should fail")
+	}
+	res, err := ex.ClosePosition(ctx, req)
+	if err != nil {
+		t.Fatalf("partially filled close: %v", err)
+	}
+	if len(fake.cancelled) != 2 || fake.cancelled[0] != "cb-order-1" || fake.cancelled[1] != "cb-order-2" {
+		t.Errorf("both open close orders should be cancelled, got %v", fake.cancelled)
+	}
+	assertEq(t, "status", string(domain.OrderStatusCancelled), string(res.Status))
+	assertFloat(t, "sold", 0.01, res.Quantity)
+}
+
+func TestExecuteCloseTrade_PartialCoinbaseFillKeepsRemainder(t *testing.T) {
+	ex, fake := newCoinbaseTest(t)
+	ex.fillPollAttempts = 1
+	fake.orderState["cb-order-1"] = coinbaseOrder{OrderID: "cb-order-1", Status: "OPEN", AverageFilledPrice: "50000", FilledSize: "0.01", TotalFees: "3"}
+	store := &ladderStore{position: domain.Position{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot,
+		Side: domain.PositionSideLong, Quantity: 0.02, AvgEntryPrice: 48000,
+	}}
+	e := makeEngine(&config.Config{TradingMode: "live"})
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	e.exchanges = NewExchangeRegistry()
+	e.exchanges.Register(venueCoinbase, ex)
+	ps := &PositionState{AccountID: "acc", Symbol: "BTC-USD", MarketType: "spot", Exchange: venueCoinbase, Side: "long", EntryPrice: 48000, Quantity: 0.02}
+	e.posState[posKey("acc", "BTC-USD")] = ps
+
+	if !e.executeCloseTrade(context.Background(), ps, 50000, "Stop loss hit") {
+		t.Fatal("the partial fill should be recorded")
+	}
+	if len(store.trades) != 1 {
+		t.Fatalf("want one close trade, got %d", len(store.trades))
+	}
+	assertFloat(t, "recorded qty", 0.01, store.trades[0].Quantity)
+	assertFloat(t, "recorded fee", 3, store.trades[0].Fee)
+	if _, open := e.posState[posKey("acc", "BTC-USD")]; !open {
+		t.Fatal("the unsold remainder should stay managed")
+	}
+	assertFloat(t, "remaining qty", 0.01, ps.Quantity)
+}
+
+func TestCoinbase_GetBalanceAndShortRejected(t *testing.T) {
+	ex, _ := newCoinbaseTest(t)
+
+	bal, err := ex.GetBalance(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertFloat(t, "balance", 2500.50, bal)
+
+	if _, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol: "BTC-USD", Side: domain.PositionSideShort, SizeUSD: 100, Price: 50000,
+	}); err == nil {
+		t.Error("expected short entry to be rejected on spot")
+	}
+}
+
+func TestCoinbase_BadSecretFailsOnRequest(t *testing.T) {
+	ex := NewCoinbaseSpotExchange(&config.Config{CoinbaseAPIKey: "k", CoinbaseAPISecret: "not a key", CoinbaseAPIURL: "http://127.0.0.1:1"})
+	if _, err := ex.GetBalance(context.Background()); err == nil || !strings.Contains(err.Error(), "COINBASE_API_SECRET") {
+		t.Errorf("want secret parse error, got %v", err)
+	}
+}
+
+func TestCoinbaseProductID(t *testing.T) {
+	cases := map[string]string{
+		"BTC-USD": "BTC-USD",
+		"btcusd":  "BTC-USD",
+		"ETHUSDC": "ETH-USDC",
+		"SOLUSDT": "SOL-USDT",
+	}
+	for in, want := range cases {
+		assertEq(t, in, want, coinbaseProductID(in))
+	}
+}
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+)
+
+// ConfigChangeEvent is published on CONFIG_UPDATES_SUBJECT when a trading
+// config is created, changed or deleted. Every field is optional; an empty
+// event refreshes the cache unconditionally.
+type ConfigChangeEvent struct {
+	TenantID  string `json:"tenant_id"`
+	AccountID string `json:"account_id"`
+	Exchange  string `json:"exchange"`
+	ProductID string `json:"product_id"`
+}
+
+// handleConfigChange filters a config-change notification down to this
+// tenant and its managed accounts and schedules a cache refresh.
+func (e *Engine) handleConfigChange(msg *nats.Msg) {
+	var ev ConfigChangeEvent
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, &ev); err != nil {
+			e.logger.Warn().Err(err).Str("subject", msg.Subject).Msg("unreadable config change event — refreshing anyway")
+		}
+	}
+	if ev.TenantID != "" && ev.TenantID != e.tenantID().String() {
+		return
+	}
+	if ev.AccountID != "" && !e.managesAccount(ev.AccountID) {
+		return
+	}
+	e.logger.Debug().
+		Str("account", ev.AccountID).
+		Str("exchange", ev.Exchange).
+		Str("product", ev.ProductID).
+		Msg("trading config change notified")
+	e.requestConfigRefresh()
+}
+
+// managesAccount reports whether accountID is traded by this engine.
+func (e *Engine) managesAccount(accountID string) bool {
+	for _, a := range e.accounts {
+		if a == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// requestConfigRefresh schedules a trading config refresh. Requests made
+// while one is already pending are coalesced into it.
+func (e *Engine) requestConfigRefresh() {
+	select {
+	case e.configRefreshReq <- struct{}{}:
+	default:
+	}
+}
+
+// startConfigRefreshWorker applies refresh requests from config change
+// notifications until ctx is cancelled.
+func (e *Engine) startConfigRefreshWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.configRefreshReq:
+			status, err := e.refreshTradingConfigs(ctx)
+			if err != nil {
+				e.logger.Warn().Err(err).Msg("failed to apply trading config change — next timed refresh will retry")
+				continue
+			}
+			if status.Changed {
+				e.logger.Info().Int("configs", status.Configs).Int("slots", status.Slots).Msg("trading config change applied")
+			}
+		}
+	}
+}
+
+// runConfigUpdates subscribes to CONFIG_UPDATES_SUBJECT on the platform NATS
+// (NATS_URLS) so config changes apply within seconds; the timed refresh
+// remains the fallback. Uses exponential backoff (10s → 5m) on connection
+// failure and blocks until ctx is cancelled.
+func (e *Engine) runConfigUpdates(ctx context.Context) {
+	go e.startConfigRefreshWorker(ctx)
+
+	opts := []nats.Option{
+		nats.Name("trader-engine-config"),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(5 * time.Second),
+		// Changes made while disconnected were missed; catch up on reconnect.
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			e.logger.Info().Str("url", nc.ConnectedUrl()).Msg("reconnected to config update NATS")
+			e.requestConfigRefresh()
+		}),
+	}
+	creds, err := platformNATSCreds(e.cfg.NATSCredsFile, e.cfg.NATSCreds)
+	if err != nil {
+		e.logger.Error().Err(err).Msg("failed to resolve NATS credentials — config updates fall back to polling")
+		return
+	}
+	if creds != "" {
+		opts = append(opts, nats.UserCredentials(creds))
+	}
+
+	backoff := 10 * time.Second
+	maxBackoff := 5 * time.Minute
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		nc, err := nats.Connect(e.cfg.NATSURLs, opts...)
+		if err != nil {
+			e.logger.Warn().Err(err).Dur("retry_in", backoff).Msg("failed to connect to config update NATS, retrying")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = time.Duration(math.Min(float64(backoff*2), float64(maxBackoff)))
+			continue
+		}
+
+		sub, err := nc.Subscribe(e.cfg.ConfigUpdatesSubject, e.handleConfigChange)
+		if err != nil {
+			e.logger.Error().Err(err).Str("subject", e.cfg.ConfigUpdatesSubject).Msg("failed to subscribe to config updates")
+			nc.Close()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		e.logger.Info().Str("url", nc.ConnectedUrl()).Str("subject", e.cfg.ConfigUpdatesSubject).Msg("subscribed to trading config updates")
+		// Anything changed between the startup fetch and the subscription.
+		e.requestConfigRefresh()
+
+		<-ctx.Done()
+		_ = sub.Unsubscribe()
+		nc.Close()
+		return
+	}
+}
+
+// platformNATSCreds returns the credentials file for the platform NATS:
+// NATS_CREDS_FILE as is, or the inline NATS_CREDS written to a temp file.
+// Returns "" when neither is set.
+func platformNATSCreds(file, inline string) (string, error) {
+	if file != "" || inline == "" {
+		return file, nil
+	}
+	tmp, err := os.CreateTemp("", "engine-platform-nats-*.creds")
+	if err != nil {
+		return "", fmt.Errorf("create temp creds file: %w", err)
+	}
+	if _, err := tmp.WriteString(inline); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("write creds: %w", err)
+	}
+	tmp.Close()
+	return tmp.Name(), nil
+}
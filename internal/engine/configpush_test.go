@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/Signal-ngn/trader/internal/config"
+)
+
+func TestHandleConfigChange_FiltersAndCoalesces(t *testing.T) {
+	e := makeEngine(&config.Config{})
+	e.tenantUUID = uuid.New()
+	e.accounts = []string{"acc"}
+	e.configRefreshReq = make(chan struct{}, 1)
+	notify := func(data string) {
+		e.handleConfigChange(&nats.Msg{Subject: "trader.config.trading.x", Data: []byte(data)})
+	}
+
+	notify(`{"tenant_id":"` + uuid.NewString() + `"}`)
+	notify(`{"account_id":"someone-else"}`)
+	if len(e.configRefreshReq) != 0 {
+		t.Fatal("changes for other tenants or accounts must be ignored")
+	}
+
+	notify(`{"tenant_id":"` + e.tenantUUID.String() + `","account_id":"acc","product_id":"BTC-USD"}`)
+	notify(``)
+	if len(e.configRefreshReq) != 1 {
+		t.Fatalf("want one coalesced refresh request, got %d", len(e.configRefreshReq))
+	}
+}
+
+func TestConfigChange_DisablesProductWithoutWaitingForPoll(t *testing.T) {
+	cs := &configServer{etag: `"v1"`, configs: []TradingConfig{
+		{AccountID: "acc", ProductID: "BTC-USD", Exchange: "binance", Granularity: "ONE_HOUR", StrategiesLong: []string{"trend"}, Enabled: true},
+	}}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	e := makeEngine(&config.Config{TraderAPIURL: srv.URL})
+	e.accounts = []string{"acc"}
+	e.configRefreshReq = make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := e.refreshTradingConfigs(ctx); err != nil {
+		t.Fatal(err)
+	}
+	go e.startConfigRefreshWorker(ctx)
+
+	cs.etag = `"v2"`
+	cs.configs[0].Enabled = false
+	e.handleConfigChange(&nats.Msg{Data: []byte(`{"account_id":"acc","product_id":"BTC-USD"}`)})
+
+	waitFor(t, "config change to apply", func() bool {
+		_, ok := e.tradingConfig("acc", "BTC-USD")
+		return !ok
+	})
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	if e.allowlist.allows("binance", "BTC-USD", "ONE_HOUR", "trend") {
+		t.Error("disabled product should leave the allowlist")
+	}
+}
@@ -0,0 +1,217 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// minCorrelationReturns is the number of overlapping returns two symbols need
+// before their correlation is trusted; pairs with fewer count as uncorrelated.
+const minCorrelationReturns = 10
+
+// pricePoint is the last price observed for a symbol within one bucket.
+type pricePoint struct {
+	at    time.Time // bucket start
+	price float64
+}
+
+// priceHistory keeps a rolling, fixed-interval close price per symbol from
+// which return correlations between symbols are computed.
+type priceHistory struct {
+	mu       sync.Mutex
+	interval time.Duration
+	window   int // returns kept per symbol
+	series   map[string][]pricePoint
+}
+
+func newPriceHistory(interval time.Duration, window int) *priceHistory {
+	return &priceHistory{interval: interval, window: window, series: make(map[string][]pricePoint)}
+}
+
+// observe records price as the latest close of symbol's bucket containing at.
+func (h *priceHistory) observe(symbol string, price float64, at time.Time) {
+	if price <= 0 || h.interval <= 0 {
+		return
+	}
+	bucket := at.Truncate(h.interval)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.series[symbol]
+	if n := len(s); n > 0 && !bucket.After(s[n-1].at) {
+		if bucket.Equal(s[n-1].at) {
+			s[n-1].price = price
+		}
+		return
+	}
+	s = append(s, pricePoint{at: bucket, price: price})
+	if len(s) > h.window+1 {
+		s = append([]pricePoint(nil), s[len(s)-h.window-1:]...)
+	}
+	h.series[symbol] = s
+}
+
+// returns maps each bucket to the log return from the bucket before it.
+// Returns across a gap in the series are skipped.
+func (h *priceHistory) returns(symbol string) map[time.Time]float64 {
+	s := h.series[symbol]
+	r := make(map[time.Time]float64, len(s))
+	for i := 1; i < len(s); i++ {
+		if s[i].at.Sub(s[i-1].at) == h.interval {
+			r[s[i].at] = math.Log(s[i].price / s[i-1].price)
+		}
+	}
+	return r
+}
+
+// correlation returns the Pearson correlation of the returns of a and b over
+// the buckets both have, and how many buckets that was. A flat series has no
+// defined correlation and reports 0.
+func (h *priceHistory) correlation(a, b string) (float64, int) {
+	h.mu.Lock()
+	ra, rb := h.returns(a), h.returns(b)
+	h.mu.Unlock()
+
+	var xs, ys []float64
+	for at, x := range ra {
+		if y, ok := rb[at]; ok {
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+	}
+	n := len(xs)
+	if n < 2 {
+		return 0, n
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(n)
+	my /= float64(n)
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0, n
+	}
+	return cov / math.Sqrt(vx*vy), n
+}
+
+// observePrice feeds a price into the correlation history.
+func (e *Engine) observePrice(symbol string, price float64) {
+	e.prices.observe(symbol, price, time.Now())
+}
+
+// startCorrelationSampler records a price for every traded product once per
+// CORRELATION_INTERVAL so each symbol has a gap-free series even between
+// signals. The last signal price is used when there is one, otherwise the SN
+// price API. Blocks until ctx is cancelled.
+func (e *Engine) startCorrelationSampler(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.CorrelationInterval)
+	defer ticker.Stop()
+
+	for {
+		e.sampleCorrelationPrices(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Engine) sampleCorrelationPrices(ctx context.Context) {
+	e.configMu.RLock()
+	products := make(map[string]string) // product → exchange
+	for key := range e.allowlist {
+		products[key.product] = key.exchange
+	}
+	e.configMu.RUnlock()
+
+	for product, exchange := range products {
+		e.lastPriceMu.RLock()
+		price := e.lastPrice[product]
+		e.lastPriceMu.RUnlock()
+		if price <= 0 {
+			p, err := fetchCurrentPrice(ctx, e.cfg, exchange, product)
+			if err != nil {
+				e.logger.Debug().Err(err).Str("symbol", product).Msg("correlation sampler: price API fetch failed")
+				continue
+			}
+			price = p
+		}
+		e.observePrice(product, price)
+	}
+}
+
+// clusterMember is an open position correlated with a new entry.
+type clusterMember struct {
+	Symbol      string
+	Correlation float64
+}
+
+func (m clusterMember) String() string {
+	return fmt.Sprintf("%s (%.2f)", m.Symbol, m.Correlation)
+}
+
+// correlatedCluster returns the account's open positions on side whose
+// symbol's returns correlate with symbol's at CORRELATION_THRESHOLD or more,
+// most correlated first.
+func (e *Engine) correlatedCluster(ctx context.Context, accountID, symbol string, side domain.PositionSide) ([]clusterMember, error) {
+	positions, err := e.repo.ListOpenPositionsForAccount(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("list open positions for %s: %w", accountID, err)
+	}
+	var members []clusterMember
+	seen := make(map[string]bool)
+	for _, p := range positions {
+		if p.Side != side || p.Symbol == symbol || seen[p.Symbol] {
+			continue
+		}
+		seen[p.Symbol] = true
+		corr, n := e.prices.correlation(symbol, p.Symbol)
+		if n < min(minCorrelationReturns, e.prices.window) || corr < e.cfg.CorrelationThreshold {
+			continue
+		}
+		members = append(members, clusterMember{Symbol: p.Symbol, Correlation: corr})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Correlation > members[j].Correlation })
+	return members, nil
+}
+
+// checkCorrelation reports a breach of MAX_CORRELATED_POSITIONS when opening
+// side on symbol would give the account more same-direction positions in one
+// correlated cluster than allowed, together with the cluster's open members.
+func (e *Engine) checkCorrelation(ctx context.Context, accountID, symbol string, side domain.PositionSide) (*limitBreach, []clusterMember, error) {
+	if e.cfg.MaxCorrelatedPositions <= 0 {
+		return nil, nil, nil
+	}
+	members, err := e.correlatedCluster(ctx, accountID, symbol, side)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(members)+1 <= e.cfg.MaxCorrelatedPositions {
+		return nil, members, nil
+	}
+	cluster := make([]string, len(members))
+	for i, m := range members {
+		cluster[i] = m.Symbol
+	}
+	return &limitBreach{
+		Limit:   "max_correlated_positions",
+		Value:   float64(len(members) + 1),
+		Max:     float64(e.cfg.MaxCorrelatedPositions),
+		Cluster: cluster,
+	}, members, nil
+}
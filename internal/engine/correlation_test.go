@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// feedPrices observes n hourly prices per symbol, each following moves.
+func feedPrices(h *priceHistory, start time.Time, n int, moves map[string]func(i int) float64) {
+	for i := 0; i < n; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		for symbol, move := range moves {
+			h.observe(symbol, 100*math.Exp(move(i)), at)
+		}
+	}
+}
+
+func TestPriceHistory_Correlation(t *testing.T) {
+	h := newPriceHistory(time.Hour, 20)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	wave := func(i int) float64 { return 0.05 * math.Sin(float64(i)) }
+	feedPrices(h, start, 30, map[string]func(int) float64{
+		"BTC-USD": wave,
+		"ETH-USD": func(i int) float64 { return 2 * wave(i) },
+		"XAU-USD": func(i int) float64 { return -wave(i) },
+	})
+	// A later observation in the same bucket replaces its close.
+	h.observe("BTC-USD", 100*math.Exp(wave(29)), start.Add(29*time.Hour+30*time.Minute))
+
+	corr, n := h.correlation("BTC-USD", "ETH-USD")
+	assertFloat(t, "btc/eth", 1, corr)
+	if n != 20 {
+		t.Errorf("want the window of 20 returns, got %d", n)
+	}
+	if corr, _ := h.correlation("BTC-USD", "XAU-USD"); math.Abs(corr+1) > 1e-9 {
+		t.Errorf("want -1, got %v", corr)
+	}
+	if _, n := h.correlation("BTC-USD", "SOL-USD"); n != 0 {
+		t.Errorf("unseen symbol should share no returns, got %d", n)
+	}
+}
+
+func TestHandleOpenSignal_RejectsCorrelatedCluster(t *testing.T) {
+	cfg := &config.Config{TradingMode: "paper", PortfolioSize: 10000, PositionSizePct: 10,
+		MaxCorrelatedPositions: 2, CorrelationThreshold: 0.7, CorrelationInterval: time.Hour, CorrelationWindow: 48}
+	pub := &recordingPublisher{}
+	e := makeEngine(cfg)
+	e.exchange = NewNoopExchange(cfg)
+	e.exchanges = NewPaperExchangeRegistry(e.exchange)
+	e.publisher = pub
+	e.accounts = []string{"acc"}
+	e.repo = &exposureStore{positions: map[string][]domain.Position{"acc": {
+		{Symbol: "ETH-USD", Side: domain.PositionSideLong, Quantity: 1, AvgEntryPrice: 2000},
+		{Symbol: "SOL-USD", Side: domain.PositionSideLong, Quantity: 10, AvgEntryPrice: 150},
+		{Symbol: "XAU-USD", Side: domain.PositionSideLong, Quantity: 1, AvgEntryPrice: 2500},
+	}}}
+	wave := func(i int) float64 { return 0.05 * math.Sin(float64(i)) }
+	feedPrices(e.prices, time.Now().Add(-30*time.Hour), 30, map[string]func(int) float64{
+		"BTC-USD": wave,
+		"ETH-USD": func(i int) float64 { return 1.5 * wave(i) },
+		"SOL-USD": func(i int) float64 { return 2*wave(i) + 0.01*math.Cos(float64(i)) },
+		"XAU-USD": func(i int) float64 { return -wave(i) },
+	})
+	tc := &TradingConfig{Exchange: "binance"}
+	signal := SignalPayload{Action: "BUY", Price: 50000, Confidence: 0.9, ID: "sig-1"}
+
+	// BTC would be the third long in the BTC/ETH/SOL cluster; gold is not in it.
+	e.handleOpenSignal(context.Background(), signal, "BTC-USD", "trend", "acc", tc)
+
+	if len(pub.events) != 1 {
+		t.Fatalf("want one rejected event, got %d", len(pub.events))
+	}
+	ev := pub.events[0].(RejectedEvent)
+	assertEq(t, "limit", "max_correlated_positions", ev.Limit)
+	assertFloat(t, "value", 3, ev.Value)
+	if len(ev.Cluster) != 2 || ev.Cluster[0] != "ETH-USD" || ev.Cluster[1] != "SOL-USD" {
+		t.Errorf("want cluster [ETH-USD SOL-USD], got %v", ev.Cluster)
+	}
+
+	// A short on BTC has no same-direction cluster and is not rejected here.
+	breach, _, err := e.checkCorrelation(context.Background(), "acc", "BTC-USD", domain.PositionSideShort)
+	if err != nil || breach != nil {
+		t.Errorf("short should pass, got %v %v", breach, err)
+	}
+}
@@ -6,7 +6,9 @@ package engine
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +17,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
 	"github.com/Signal-ngn/trader/internal/platform"
 )
 
@@ -24,6 +27,7 @@ type PositionState struct {
 	AccountID    string
 	Symbol       string
 	MarketType   string
+	Exchange     string  // venue holding the position ("binance", "coinbase"); "" = unknown
 	Side         string  // "long" or "short"
 	EntryPrice   float64
 	StopLoss     float64
@@ -36,6 +40,89 @@ type PositionState struct {
 	PeakPrice    float64
 	TrailingStop float64
 	Closing      bool // true when a close is already in-flight; prevents double-close
+
+	// Exchange-side protective order IDs (live mode); "" = none placed.
+	StopOrderID       string
+	TakeProfitOrderID string
+
+	// Open quantity and layered entries; EntryPrice is their weighted average.
+	// Quantity is 0 for positions opened before layering was tracked.
+	Quantity float64
+	Entries  []PositionEntry
+
+	// Take-profit ladder; nil when the position uses a single take-profit.
+	Ladder []LadderRung
+
+	// Break-even stop rule and state; see EnginePositionState.
+	BreakEvenR    float64
+	BreakEven     bool
+	EntryStopLoss float64
+}
+
+// positionStateFrom builds the in-memory state for a persisted position.
+func positionStateFrom(s *EnginePositionState) *PositionState {
+	return &PositionState{
+		ID:           s.ID,
+		AccountID:    s.AccountID,
+		Symbol:       s.Symbol,
+		MarketType:   s.MarketType,
+		Exchange:     s.Exchange,
+		Side:         s.Side,
+		EntryPrice:   s.EntryPrice,
+		StopLoss:     s.StopLoss,
+		TakeProfit:   s.TakeProfit,
+		HardStop:     s.HardStop,
+		Leverage:     s.Leverage,
+		Strategy:     s.Strategy,
+		Granularity:  s.Granularity,
+		OpenedAt:     s.OpenedAt,
+		PeakPrice:    s.PeakPrice,
+		TrailingStop: s.TrailingStop,
+
+		StopOrderID:       s.StopOrderID,
+		TakeProfitOrderID: s.TakeProfitOrderID,
+
+		Quantity: s.Quantity,
+		Entries:  append([]PositionEntry(nil), s.Entries...),
+		Ladder:   append([]LadderRung(nil), s.Ladder...),
+
+		BreakEvenR:    s.BreakEvenR,
+		BreakEven:     s.BreakEven,
+		EntryStopLoss: s.EntryStopLoss,
+	}
+}
+
+// record returns the persisted form of the position state.
+func (ps *PositionState) record() *EnginePositionState {
+	return &EnginePositionState{
+		ID:           ps.ID,
+		AccountID:    ps.AccountID,
+		Symbol:       ps.Symbol,
+		MarketType:   ps.MarketType,
+		Exchange:     ps.Exchange,
+		Side:         ps.Side,
+		EntryPrice:   ps.EntryPrice,
+		StopLoss:     ps.StopLoss,
+		TakeProfit:   ps.TakeProfit,
+		HardStop:     ps.HardStop,
+		Leverage:     ps.Leverage,
+		Strategy:     ps.Strategy,
+		Granularity:  ps.Granularity,
+		OpenedAt:     ps.OpenedAt,
+		PeakPrice:    ps.PeakPrice,
+		TrailingStop: ps.TrailingStop,
+
+		StopOrderID:       ps.StopOrderID,
+		TakeProfitOrderID: ps.TakeProfitOrderID,
+
+		Quantity: ps.Quantity,
+		Entries:  append([]PositionEntry(nil), ps.Entries...),
+		Ladder:   append([]LadderRung(nil), ps.Ladder...),
+
+		BreakEvenR:    ps.BreakEvenR,
+		BreakEven:     ps.BreakEven,
+		EntryStopLoss: ps.EntryStopLoss,
+	}
 }
 
 // posKey returns the map key for a (accountID, symbol) pair.
@@ -53,12 +140,20 @@ type cooldownKey struct {
 type Engine struct {
 	cfg      *config.Config
 	repo     EngineStore
-	exchange Exchange
+	exchange Exchange // NoopExchange — simulates every venue in paper mode
+
+	// exchanges resolves the adapter for an (account, venue) pair. In paper
+	// mode every venue resolves to exchange.
+	exchanges *ExchangeRegistry
 
 	// tenantUUID is resolved once at Start from the SN API key.
 	// Falls back to the middleware default tenant if the key is not found.
 	tenantUUID uuid.UUID
 
+	// tenantReady publishes tenantUUID to other goroutines once Start has
+	// resolved it.
+	tenantReady atomic.Pointer[uuid.UUID]
+
 	// accounts is the resolved list of account IDs this engine instance trades.
 	// Populated at Start time (from cfg.TraderAccounts or all tenant accounts).
 	accounts []string
@@ -67,7 +162,7 @@ type Engine struct {
 	publisher TradePublisher
 
 	// NGS NATS connection (separate from the ledger NATS connection)
-	ngsConn *nats.Conn
+	ngsConn atomic.Pointer[nats.Conn]
 
 	// In-memory risk state cache — keyed by posKey(accountID, symbol)
 	posStateMu sync.RWMutex
@@ -81,15 +176,71 @@ type Engine struct {
 	conflictMu sync.Mutex
 	conflict   map[string]string
 
-	// Signal allowlist — rebuilt every 5 minutes from the SN API
-	allowlistMu sync.RWMutex
-	allowlist   signalAllowlist
+	// Trading config cache — the signal allowlist and the per-account config
+	// index, both built from one fetch of GET /config/trading and revalidated
+	// with If-None-Match every CONFIG_REFRESH_INTERVAL
+	configMu         sync.RWMutex
+	allowlist        signalAllowlist
+	tradingConfigs   tradingConfigByProduct
+	configETag       string
+	configFetchedAt  time.Time
+	configRefreshMu  sync.Mutex    // serializes refreshes
+	configRefreshReq chan struct{} // pending refresh from a config change notification
 
 	// Last observed signal price per symbol — used as current price in risk loop.
 	// Updated on every signal received from NGS.
 	lastPriceMu sync.RWMutex
 	lastPrice   map[string]float64 // symbol → last signal price
 
+	// Rolling fixed-interval prices per symbol for return correlations.
+	prices *priceHistory
+
+	// Closed-trade statistics per strategy+symbol for Kelly sizing,
+	// refreshed every KELLY_REFRESH_INTERVAL.
+	kellyMu    sync.RWMutex
+	kellyStats map[kellyKey]tradeStats
+
+	// Circuit-breaker state per account and per account+strategy, mirrored
+	// in the store so trips survive restarts.
+	breakerMu sync.RWMutex
+	breakers  map[breakerKey]*BreakerState
+
+	// UTC day each account was last flattened on FLATTEN_LOSS_LIMIT; opens
+	// stay paused for the rest of that day.
+	flattenMu sync.Mutex
+	flattened map[string]string
+
+	// Operator pauses on new positions, mirrored from the store every
+	// pauseRefreshInterval.
+	pauseMu sync.RWMutex
+	pauses  map[pauseKey]*PauseState
+
+	// Last allowlisted signal per NGS subject, for status introspection.
+	signalsMu sync.RWMutex
+	signals   map[string]SignalRecord
+
+	// When the risk loop last finished a pass over all positions (Unix nanos).
+	lastRiskLoop atomic.Int64
+
+	// Resting limit/stop entry orders awaiting a fill — keyed by posKey(accountID, symbol)
+	pendingMu sync.Mutex
+	pending   map[string]*pendingEntry
+
+	// Durable queue of ledger trades not yet accepted by the platform API;
+	// nil when OUTBOX_DIR is empty.
+	outbox *Outbox
+
+	// Last exchange ↔ ledger reconciliation report (live mode)
+	reconcileMu   sync.RWMutex
+	lastReconcile *ReconcileReport
+
+	// Leader election between instances of the same tenant; nil = disabled.
+	leases  LeaseStore
+	leading atomic.Bool // true while this instance runs the trading loops
+
+	// Per-(accountID, symbol) ordered work queues; nil when not trading.
+	queues atomic.Pointer[symbolQueues]
+
 	// (No in-memory daily loss counter — queried from DB on each check so it
 	// survives restarts and reflects trades from all sources, not just the engine.)
 
@@ -102,29 +253,74 @@ type TradePublisher interface {
 	Publish(accountID string, payload interface{})
 }
 
-// New creates a new Engine. The Exchange is selected based on cfg.TradingMode.
-// publisher may be nil; when set, every filled trade is fanned out to SSE subscribers.
+// New creates a new Engine. In live mode the exchange registry holds an
+// adapter for every venue and account with credentials configured; orders are
+// routed per account and trading config by exchangeFor. publisher may be nil;
+// when set, every filled trade is fanned out to SSE subscribers.
 func New(cfg *config.Config, repo EngineStore, publisher TradePublisher) *Engine {
-	var ex Exchange
+	noop := NewNoopExchange(cfg)
+	exchanges := NewPaperExchangeRegistry(noop)
 	if cfg.TradingMode == "live" {
-		ex = NewBinanceFuturesExchange(cfg)
-	} else {
-		ex = NewNoopExchange(cfg)
+		exchanges = buildExchangeRegistry(cfg)
 	}
 
 	return &Engine{
 		cfg:       cfg,
 		repo:      repo,
-		exchange:  ex,
+		exchange:  noop,
+		exchanges: exchanges,
 		publisher: publisher,
 		posState:  make(map[string]*PositionState),
 		cooldown:  make(map[cooldownKey]time.Time),
 		conflict:  make(map[string]string),
 		lastPrice: make(map[string]float64),
+		prices:    newPriceHistory(cfg.CorrelationInterval, cfg.CorrelationWindow),
+		pending:   make(map[string]*pendingEntry),
+		breakers:  make(map[breakerKey]*BreakerState),
+		flattened: make(map[string]string),
+		pauses:    make(map[pauseKey]*PauseState),
+		signals:   make(map[string]SignalRecord),
 		logger:    log.With().Str("component", "engine").Logger(),
+
+		configRefreshReq: make(chan struct{}, 1),
 	}
 }
 
+// Venue names as used in trading configs and signal subjects.
+const (
+	venueBinance  = "binance"
+	venueCoinbase = "coinbase"
+)
+
+// resolveVenue returns the venue that executes orders for a trading config's
+// exchange and market type. Futures always execute on Binance, the only
+// futures venue; spot executes on the configured exchange.
+func resolveVenue(exchange string, marketType domain.MarketType) string {
+	if marketType == domain.MarketTypeFutures {
+		return venueBinance
+	}
+	return strings.ToLower(exchange)
+}
+
+// exchangeFor returns the adapter and resolved venue for an account trading
+// an exchange and market type. Unknown venues fail closed.
+func (e *Engine) exchangeFor(accountID, exchange string, marketType domain.MarketType) (Exchange, string, error) {
+	venue := resolveVenue(exchange, marketType)
+	ex, err := e.exchanges.Resolve(accountID, venue)
+	return ex, venue, err
+}
+
+// positionExchange returns the adapter holding an open position. States
+// persisted before the venue was recorded fall back to the allowlist
+// exchange for the symbol.
+func (e *Engine) positionExchange(accountID, venue, marketType, symbol string) (Exchange, error) {
+	if venue == "" {
+		venue = e.exchangeForProduct(symbol)
+	}
+	ex, _, err := e.exchangeFor(accountID, venue, domain.MarketType(marketType))
+	return ex, err
+}
+
 // Start initialises the engine and runs the signal and risk loops.
 // It blocks until ctx is cancelled.
 func (e *Engine) Start(ctx context.Context) error {
@@ -158,6 +354,8 @@ func (e *Engine) Start(ctx context.Context) error {
 		e.tenantUUID = tenantUUID
 		e.logger.Info().Str("tenant_id", e.tenantUUID.String()).Msg("resolved tenant from platform API")
 	}
+	tenant := e.tenantUUID
+	e.tenantReady.Store(&tenant)
 
 	// Resolve the account list: use cfg.TraderAccounts if set, otherwise load
 	// all accounts for the tenant from the platform API.
@@ -185,27 +383,42 @@ func (e *Engine) Start(ctx context.Context) error {
 
 	// Validate live-mode credentials before doing anything else.
 	if e.cfg.TradingMode == "live" {
-		if e.cfg.BinanceAPIKey == "" || e.cfg.BinanceAPISecret == "" {
-			e.logger.Error().Msg("BINANCE_API_KEY and BINANCE_API_SECRET are required in live mode — engine aborted")
+		adapters := e.exchanges.All()
+		if len(adapters) == 0 {
+			e.logger.Error().Msg("live mode requires BINANCE_API_KEY/BINANCE_API_SECRET and/or COINBASE_API_KEY/COINBASE_API_SECRET — engine aborted")
 			return nil
 		}
-		if _, err := e.exchange.GetBalance(ctx); err != nil {
-			e.logger.Error().Err(err).Msg("Binance credential validation failed — engine aborted")
+		for _, a := range adapters {
+			if _, err := a.Exchange.GetBalance(ctx); err != nil {
+				e.logger.Error().Err(err).Str("venue", a.Venue).Str("account", a.AccountID).
+					Msg("exchange credential validation failed — engine aborted")
+				return nil
+			}
+			e.logger.Info().Str("venue", a.Venue).Str("account", a.AccountID).Msg("exchange credentials validated")
+		}
+	}
+
+	// Open the trade outbox before any order can be placed. Shadow trades
+	// are written locally and must never pick up a real engine's queue.
+	if e.cfg.OutboxDir != "" && e.cfg.TradingMode != "shadow" {
+		ob, err := OpenOutbox(e.cfg.OutboxDir)
+		if err != nil {
+			e.logger.Error().Err(err).Str("dir", e.cfg.OutboxDir).Msg("failed to open trade outbox — engine aborted")
 			return nil
 		}
-		e.logger.Info().Msg("Binance credentials validated")
+		e.outbox = ob
+		if depth := ob.Depth(); depth > 0 {
+			e.logger.Warn().Int("depth", depth).Msg("trade outbox holds unacknowledged trades from a previous run — retrying")
+		}
 	}
 
-	// Fetch initial allowlist.
-	al, err := fetchAllowlist(ctx, e.cfg)
+	// Load the trading configs and the signal allowlist built from them.
+	status, err := e.refreshTradingConfigs(ctx)
 	if err != nil {
-		e.logger.Error().Err(err).Msg("failed to fetch signal allowlist — engine aborted")
+		e.logger.Error().Err(err).Msg("failed to fetch trading configs — engine aborted")
 		return nil
 	}
-	e.allowlistMu.Lock()
-	e.allowlist = al
-	e.allowlistMu.Unlock()
-	e.logger.Info().Int("slots", len(al)).Msg("loaded signal allowlist")
+	e.logger.Info().Int("configs", status.Configs).Int("slots", status.Slots).Msg("loaded trading configs")
 
 	// Load startup state from DB.
 	if err := e.loadStartupState(ctx); err != nil {
@@ -213,14 +426,30 @@ func (e *Engine) Start(ctx context.Context) error {
 		return nil
 	}
 
-	// Start allowlist refresh goroutine.
-	go e.startAllowlistRefresher(ctx)
+	// Keep the trading config cache fresh: pushed changes apply within
+	// seconds, the timed refresh catches anything missed.
+	go e.startConfigRefresher(ctx)
+	if e.cfg.ConfigUpdatesSubject != "" {
+		go e.runConfigUpdates(ctx)
+	}
 
-	// Start risk loop goroutine.
-	go e.startRiskLoop(ctx)
+	// Sample prices for the correlated-position limit.
+	if e.cfg.MaxCorrelatedPositions > 0 && e.cfg.CorrelationInterval > 0 {
+		go e.startCorrelationSampler(ctx)
+	}
 
-	// Connect to NGS and run signal loop (blocks until ctx cancelled).
-	e.runSignalLoop(ctx)
+	// Retry ledger writes queued in the outbox.
+	if e.outbox != nil {
+		go e.startOutboxWorker(ctx)
+	}
+
+	// Run the signal, risk and reconcile loops — directly, or only while
+	// this instance holds the tenant's lease (blocks until ctx cancelled).
+	if e.leases != nil {
+		e.runElection(ctx, e.runTrading)
+	} else {
+		e.runTrading(ctx)
+	}
 
 	e.logger.Info().Msg("trading engine stopped")
 	return nil
@@ -228,7 +457,7 @@ func (e *Engine) Start(ctx context.Context) error {
 
 // loadStartupState seeds the conflict guard and position state cache for all accounts.
 func (e *Engine) loadStartupState(ctx context.Context) error {
-	totalPositions, totalStates := 0, 0
+	totalPositions, totalStates, totalTripped, totalPending := 0, 0, 0, 0
 
 	for _, accountID := range e.accounts {
 		// Seed conflict guard from open ledger positions.
@@ -249,33 +478,32 @@ func (e *Engine) loadStartupState(ctx context.Context) error {
 			return fmt.Errorf("load position states for %s: %w", accountID, err)
 		}
 		e.posStateMu.Lock()
-		for _, s := range posStates {
-			ps := &PositionState{
-				ID:           s.ID,
-				AccountID:    s.AccountID,
-				Symbol:       s.Symbol,
-				MarketType:   s.MarketType,
-				Side:         s.Side,
-				EntryPrice:   s.EntryPrice,
-				StopLoss:     s.StopLoss,
-				TakeProfit:   s.TakeProfit,
-				HardStop:     s.HardStop,
-				Leverage:     s.Leverage,
-				Strategy:     s.Strategy,
-				Granularity:  s.Granularity,
-				OpenedAt:     s.OpenedAt,
-				PeakPrice:    s.PeakPrice,
-				TrailingStop: s.TrailingStop,
-			}
-			e.posState[posKey(accountID, s.Symbol)] = ps
+		for i := range posStates {
+			e.posState[posKey(accountID, posStates[i].Symbol)] = positionStateFrom(&posStates[i])
 		}
 		e.posStateMu.Unlock()
 		totalStates += len(posStates)
+
+		// Restore circuit-breaker trips.
+		tripped, err := e.loadBreakers(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("load circuit breakers for %s: %w", accountID, err)
+		}
+		totalTripped += tripped
+
+		// Resume polling resting live entry orders.
+		pending, err := e.loadPendingEntries(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("load pending entries for %s: %w", accountID, err)
+		}
+		totalPending += pending
 	}
 
 	e.logger.Info().
 		Int("open_positions", totalPositions).
 		Int("position_states", totalStates).
+		Int("tripped_breakers", totalTripped).
+		Int("pending_entries", totalPending).
 		Msg("loaded startup state")
 	return nil
 }
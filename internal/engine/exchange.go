@@ -2,14 +2,50 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog/log"
+
 	"github.com/Signal-ngn/trader/internal/config"
 	"github.com/Signal-ngn/trader/internal/domain"
 )
 
+// OrderType selects how an entry order is placed on the exchange.
+type OrderType string
+
+const (
+	OrderTypeMarket     OrderType = "market"      // fill immediately at the best available price
+	OrderTypeLimit      OrderType = "limit"       // rest at LimitPrice until filled, cancelled or expired
+	OrderTypeStopMarket OrderType = "stop_market" // market order triggered when price crosses StopPrice
+	OrderTypePostOnly   OrderType = "post_only"   // limit order rejected if it would take liquidity
+)
+
+// TimeInForce controls how long a limit order stays on the book.
+type TimeInForce string
+
+const (
+	TimeInForceGTC TimeInForce = "GTC" // good till cancelled
+	TimeInForceIOC TimeInForce = "IOC" // immediate or cancel
+	TimeInForceFOK TimeInForce = "FOK" // fill or kill
+)
+
+// parseOrderType maps a config string to an OrderType, defaulting to market.
+func parseOrderType(s string) OrderType {
+	switch OrderType(s) {
+	case OrderTypeLimit, OrderTypeStopMarket, OrderTypePostOnly:
+		return OrderType(s)
+	default:
+		return OrderTypeMarket
+	}
+}
+
 // OpenPositionRequest contains the parameters for opening a position.
 type OpenPositionRequest struct {
 	Symbol   string
@@ -17,6 +53,28 @@ type OpenPositionRequest struct {
 	SizeUSD  float64
 	Leverage int
 	Price    float64 // signal price (used by NoopExchange)
+
+	// Order type parameters. A zero OrderType is treated as market.
+	OrderType   OrderType
+	LimitPrice  float64     // limit and post-only orders; 0 = use Price
+	StopPrice   float64     // stop-market orders; 0 = use Price
+	TimeInForce TimeInForce // limit orders; "" = GTC
+}
+
+// limitPrice returns the effective limit price for the request.
+func (r OpenPositionRequest) limitPrice() float64 {
+	if r.LimitPrice > 0 {
+		return r.LimitPrice
+	}
+	return r.Price
+}
+
+// stopPrice returns the effective trigger price for a stop-market request.
+func (r OpenPositionRequest) stopPrice() float64 {
+	if r.StopPrice > 0 {
+		return r.StopPrice
+	}
+	return r.Price
 }
 
 // ClosePositionRequest contains the parameters for closing a position.
@@ -24,22 +82,91 @@ type ClosePositionRequest struct {
 	Symbol     string
 	Side       domain.PositionSide // position side to close
 	MarketType domain.MarketType
+	Quantity   float64 // ledger quantity; venues that can query the live position (Binance) use that instead
+	Fraction   float64 // portion of the live position to close on venues that query it; 0 = all
 }
 
 // OrderResult contains the fill details from an exchange order.
+//
+// Market orders come back filled. Limit, post-only and stop orders may come
+// back resting (Pending() == true) with an OrderID the engine polls via
+// GetOrder until the order fills, or cancels via CancelOrder once it expires.
 type OrderResult struct {
+	OrderID   string
+	Status    domain.OrderStatus // "" is treated as filled
 	FillPrice float64
 	Quantity  float64
 	Fee       float64
 	Margin    float64
 }
 
+// Pending reports whether the order is still resting on the book.
+func (r *OrderResult) Pending() bool {
+	return r.Status == domain.OrderStatusOpen || r.Status == domain.OrderStatusPartiallyFilled
+}
+
 // Exchange is the interface over exchange APIs.
-// Paper mode uses NoopExchange; live mode uses BinanceFuturesExchange.
+// Paper mode uses NoopExchange for every venue; live mode uses
+// BinanceFuturesExchange for futures and CoinbaseSpotExchange for Coinbase spot.
 type Exchange interface {
 	OpenPosition(ctx context.Context, req OpenPositionRequest) (*OrderResult, error)
 	ClosePosition(ctx context.Context, req ClosePositionRequest) (*OrderResult, error)
 	GetBalance(ctx context.Context) (float64, error)
+
+	// GetOrder returns the current state of a previously placed order.
+	GetOrder(ctx context.Context, symbol, orderID string) (*OrderResult, error)
+	// CancelOrder cancels a resting order. Cancelling an order that has
+	// already filled or been cancelled is not an error.
+	CancelOrder(ctx context.Context, symbol, orderID string) error
+}
+
+// ProtectiveOrderKind distinguishes the two exchange-side exit orders kept
+// alongside a live position.
+type ProtectiveOrderKind string
+
+const (
+	ProtectiveStop       ProtectiveOrderKind = "stop"        // stop-loss / hard stop / trailing stop
+	ProtectiveTakeProfit ProtectiveOrderKind = "take_profit" // take-profit
+)
+
+// ProtectiveOrderRequest describes a reduce-only exit order that closes the
+// whole position when TriggerPrice is reached.
+type ProtectiveOrderRequest struct {
+	Symbol       string
+	Side         domain.PositionSide // side of the position being protected
+	Kind         ProtectiveOrderKind
+	TriggerPrice float64
+}
+
+// ProtectiveOrderExchange is implemented by exchanges that can hold stop and
+// take-profit orders for an open position, so the position stays protected
+// while the engine is down. Orders are polled with GetOrder and removed with
+// CancelOrder.
+type ProtectiveOrderExchange interface {
+	PlaceProtectiveOrder(ctx context.Context, req ProtectiveOrderRequest) (orderID string, err error)
+	GetOrder(ctx context.Context, symbol, orderID string) (*OrderResult, error)
+	CancelOrder(ctx context.Context, symbol, orderID string) error
+}
+
+// ExchangePosition is an open position as reported by the exchange.
+type ExchangePosition struct {
+	Symbol     string // exchange symbol, e.g. "BTCUSDT"
+	Side       domain.PositionSide
+	Quantity   float64 // absolute size in base units
+	EntryPrice float64
+	Leverage   int
+}
+
+// PositionLister is implemented by exchanges that can report their open
+// positions (Binance Futures). The reconciler compares them with the ledger.
+type PositionLister interface {
+	ListPositions(ctx context.Context) ([]ExchangePosition, error)
+}
+
+// priceObserver is implemented by exchanges that simulate fills against the
+// engine's price feed (NoopExchange). The engine forwards every signal price.
+type priceObserver interface {
+	ObservePrice(symbol string, price float64)
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
@@ -47,30 +174,150 @@ type Exchange interface {
 // ──────────────────────────────────────────────────────────────────────────────
 
 // NoopExchange returns synthetic fills at signal price with zero fees.
+//
+// Market orders fill immediately. Limit, post-only and stop-market orders rest
+// in memory and are filled by ObservePrice when a later price crosses them.
 type NoopExchange struct {
 	cfg *config.Config
+
+	mu     sync.Mutex
+	nextID int
+	orders map[string]*noopOrder
+}
+
+// noopOrder is a simulated resting order.
+type noopOrder struct {
+	req    OpenPositionRequest
+	result OrderResult
 }
 
 // NewNoopExchange creates a new NoopExchange.
 func NewNoopExchange(cfg *config.Config) *NoopExchange {
-	return &NoopExchange{cfg: cfg}
+	return &NoopExchange{cfg: cfg, orders: make(map[string]*noopOrder)}
 }
 
 func (n *NoopExchange) OpenPosition(_ context.Context, req OpenPositionRequest) (*OrderResult, error) {
+	switch req.OrderType {
+	case OrderTypeLimit, OrderTypePostOnly, OrderTypeStopMarket:
+	default:
+		return noopFill(req, req.Price), nil
+	}
+
+	// A post-only order priced through the market would take liquidity and
+	// is rejected, mirroring Binance GTX behaviour. An order at the market
+	// price joins the book and fills on a later price.
+	if req.OrderType == OrderTypePostOnly && noopLimitThrough(req, req.Price) {
+		return nil, fmt.Errorf("post-only order for %s would take liquidity at %v", req.Symbol, req.Price)
+	}
+	// Marketable limit orders fill immediately at the better of the two prices.
+	if req.OrderType == OrderTypeLimit && noopLimitThrough(req, req.Price) {
+		return noopFill(req, req.Price), nil
+	}
+	// IOC/FOK limit orders that cannot fill immediately are cancelled.
+	if req.OrderType != OrderTypeStopMarket && (req.TimeInForce == TimeInForceIOC || req.TimeInForce == TimeInForceFOK) {
+		return &OrderResult{Status: domain.OrderStatusCancelled}, nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nextID++
+	id := "noop-" + strconv.Itoa(n.nextID)
+	o := &noopOrder{req: req, result: OrderResult{OrderID: id, Status: domain.OrderStatusOpen}}
+	n.orders[id] = o
+	res := o.result
+	return &res, nil
+}
+
+// ObservePrice fills any resting orders for symbol that price has crossed.
+func (n *NoopExchange) ObservePrice(symbol string, price float64) {
+	if price <= 0 {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, o := range n.orders {
+		if o.req.Symbol != symbol || o.result.Status != domain.OrderStatusOpen {
+			continue
+		}
+		switch o.req.OrderType {
+		case OrderTypeStopMarket:
+			if noopStopTriggered(o.req, price) {
+				o.result = *noopFill(o.req, price)
+			}
+		default:
+			if noopLimitCrossed(o.req, price) {
+				// A resting limit order fills at its own price, not the crossing price.
+				o.result = *noopFill(o.req, o.req.limitPrice())
+			}
+		}
+	}
+}
+
+func (n *NoopExchange) GetOrder(_ context.Context, _, orderID string) (*OrderResult, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	o, ok := n.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("unknown order %s", orderID)
+	}
+	res := o.result
+	res.OrderID = orderID
+	return &res, nil
+}
+
+func (n *NoopExchange) CancelOrder(_ context.Context, _, orderID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if o, ok := n.orders[orderID]; ok && o.result.Status == domain.OrderStatusOpen {
+		o.result.Status = domain.OrderStatusCancelled
+	}
+	return nil
+}
+
+// noopFill builds a synthetic fill for req at price.
+func noopFill(req OpenPositionRequest, price float64) *OrderResult {
 	qty := 0.0
-	if req.Price > 0 {
-		qty = req.SizeUSD / req.Price
+	if price > 0 {
+		qty = req.SizeUSD / price
 	}
 	margin := req.SizeUSD
 	if req.Leverage > 1 {
 		margin = req.SizeUSD / float64(req.Leverage)
 	}
 	return &OrderResult{
-		FillPrice: req.Price,
+		Status:    domain.OrderStatusFilled,
+		FillPrice: price,
 		Quantity:  qty,
 		Fee:       0,
 		Margin:    margin,
-	}, nil
+	}
+}
+
+// noopLimitCrossed reports whether price is at or through the request's limit
+// price: at or below for a long entry, at or above for a short entry.
+func noopLimitCrossed(req OpenPositionRequest, price float64) bool {
+	if req.Side == domain.PositionSideShort {
+		return price >= req.limitPrice()
+	}
+	return price <= req.limitPrice()
+}
+
+// noopLimitThrough reports whether price is strictly through the request's
+// limit price, so the order would take liquidity when placed.
+func noopLimitThrough(req OpenPositionRequest, price float64) bool {
+	if req.Side == domain.PositionSideShort {
+		return price > req.limitPrice()
+	}
+	return price < req.limitPrice()
+}
+
+// noopStopTriggered reports whether price has reached a stop-market entry's
+// trigger: at or above for a long (breakout), at or below for a short.
+func noopStopTriggered(req OpenPositionRequest, price float64) bool {
+	if req.Side == domain.PositionSideShort {
+		return price <= req.stopPrice()
+	}
+	return price >= req.stopPrice()
 }
 
 func (n *NoopExchange) ClosePosition(_ context.Context, req ClosePositionRequest) (*OrderResult, error) {
@@ -93,8 +340,9 @@ func (n *NoopExchange) GetBalance(_ context.Context) (float64, error) {
 // BinanceFuturesExchange calls the Binance Futures API.
 // Uses the go-binance/v2 SDK.
 type BinanceFuturesExchange struct {
-	cfg    *config.Config
-	client binanceFuturesClient
+	cfg     *config.Config
+	client  binanceFuturesClient
+	filters *binanceFilterCache
 }
 
 // binanceFuturesClient is the internal interface over the Binance Futures HTTP API.
@@ -102,24 +350,86 @@ type BinanceFuturesExchange struct {
 // the Exchange interface. Tests within package engine can implement this directly.
 // Tests outside the package should use the Exchange interface with a mock Exchange.
 type binanceFuturesClient interface {
-	newOrder(ctx context.Context, symbol, side, positionSide, orderType, quantity string) (*binanceOrderResult, error)
+	newOrder(ctx context.Context, p binanceOrderParams) (*binanceOrderResult, error)
+	getOrder(ctx context.Context, symbol string, orderID int64) (*binanceOrderResult, error)
+	cancelOrder(ctx context.Context, symbol string, orderID int64) error
 	setLeverage(ctx context.Context, symbol string, leverage int) error
 	getBalance(ctx context.Context) (float64, error)
 	getPositionQty(ctx context.Context, symbol string) (float64, error)
+	getPositions(ctx context.Context) ([]binancePosition, error)
+	getPriceFilters(ctx context.Context) (map[string]binancePriceFilter, error)
+}
+
+// binancePriceFilter is a symbol's PRICE_FILTER from GET /fapi/v1/exchangeInfo.
+// Order prices that are not a multiple of the tick size are rejected.
+type binancePriceFilter struct {
+	tick     float64
+	decimals int // decimal places of the tick size
+}
+
+// binanceFilterCache holds the price filters of every symbol. It is loaded on
+// the first order for a symbol it does not hold yet.
+type binanceFilterCache struct {
+	mu      sync.Mutex
+	filters map[string]binancePriceFilter
+}
+
+// binanceOrderParams holds the query parameters for POST /fapi/v1/order.
+// Empty optional fields are omitted from the request.
+type binanceOrderParams struct {
+	Symbol       string
+	Side         string // "BUY" or "SELL"
+	PositionSide string // "LONG" or "SHORT"
+	Type         string // "MARKET", "LIMIT", "STOP_MARKET", "TAKE_PROFIT_MARKET"
+	Quantity     string // omitted when ClosePosition is set
+	Price        string // LIMIT only
+	StopPrice    string // STOP_MARKET and TAKE_PROFIT_MARKET only
+	TimeInForce  string // LIMIT only: "GTC", "IOC", "FOK", "GTX" (post-only)
+
+	// ClosePosition makes a STOP_MARKET / TAKE_PROFIT_MARKET order close the
+	// entire position — the hedge-mode equivalent of reduce-only.
+	ClosePosition bool
+	WorkingType   string // trigger price source: "MARK_PRICE" or "CONTRACT_PRICE"
+}
+
+// binancePosition is one row of GET /fapi/v2/positionRisk.
+type binancePosition struct {
+	Symbol       string
+	PositionSide string  // "LONG", "SHORT" (hedge mode) or "BOTH" (one-way mode)
+	Amount       float64 // signed: negative for one-way shorts
+	EntryPrice   float64
+	Leverage     int
 }
 
 type binanceOrderResult struct {
+	OrderID  int64
+	Status   string // NEW, PARTIALLY_FILLED, FILLED, CANCELED, EXPIRED, REJECTED
 	AvgPrice float64
 	Quantity float64
 	Fee      float64
 }
 
+// orderStatus maps a Binance order status to the domain order status.
+func (r *binanceOrderResult) orderStatus() domain.OrderStatus {
+	switch r.Status {
+	case "NEW":
+		return domain.OrderStatusOpen
+	case "PARTIALLY_FILLED":
+		return domain.OrderStatusPartiallyFilled
+	case "CANCELED", "EXPIRED", "REJECTED", "EXPIRED_IN_MATCH":
+		return domain.OrderStatusCancelled
+	default:
+		return domain.OrderStatusFilled
+	}
+}
+
 // NewBinanceFuturesExchange creates a new Binance Futures adapter using the
 // default HTTP client. The client can be swapped for testing via WithClient.
 func NewBinanceFuturesExchange(cfg *config.Config) *BinanceFuturesExchange {
 	return &BinanceFuturesExchange{
-		cfg:    cfg,
-		client: newBinanceHTTPClient(cfg),
+		cfg:     cfg,
+		client:  newBinanceHTTPClient(cfg),
+		filters: &binanceFilterCache{},
 	}
 }
 
@@ -133,9 +443,52 @@ func NewBinanceFuturesExchange(cfg *config.Config) *BinanceFuturesExchange {
 func (b *BinanceFuturesExchange) WithClient(c binanceFuturesClient) *BinanceFuturesExchange {
 	cp := *b
 	cp.client = c
+	cp.filters = &binanceFilterCache{}
 	return &cp
 }
 
+// priceFilter returns the symbol's price filter, loading exchangeInfo when
+// the symbol has not been seen. Reports false when the tick size is unknown.
+func (b *BinanceFuturesExchange) priceFilter(ctx context.Context, symbol string) (binancePriceFilter, bool) {
+	b.filters.mu.Lock()
+	defer b.filters.mu.Unlock()
+	if f, ok := b.filters.filters[symbol]; ok {
+		return f, f.tick > 0
+	}
+
+	var filters map[string]binancePriceFilter
+	if err := b.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		filters, err = b.client.getPriceFilters(ctx)
+		return err
+	}); err != nil {
+		log.Warn().Err(err).Str("symbol", symbol).Msg("failed to load Binance price filters — sending price unrounded")
+		return binancePriceFilter{}, false
+	}
+	if b.filters.filters == nil {
+		b.filters.filters = make(map[string]binancePriceFilter, len(filters))
+	}
+	for sym, f := range filters {
+		b.filters.filters[sym] = f
+	}
+	f, ok := b.filters.filters[symbol]
+	if !ok {
+		b.filters.filters[symbol] = f // unknown symbol: do not reload for every order
+	}
+	return f, f.tick > 0
+}
+
+// formatPrice rounds p to a multiple of the symbol's tick size — up when up
+// is set, else down — and formats it for the order API. Without a known tick
+// size p is sent as is.
+func (b *BinanceFuturesExchange) formatPrice(ctx context.Context, symbol string, p float64, up bool) string {
+	f, ok := b.priceFilter(ctx, symbol)
+	if !ok {
+		return formatBinancePrice(p)
+	}
+	return strconv.FormatFloat(roundToTick(p, f.tick, up), 'f', f.decimals, 64)
+}
+
 func (b *BinanceFuturesExchange) OpenPosition(ctx context.Context, req OpenPositionRequest) (*OrderResult, error) {
 	// Set leverage first. Default to 1 when not specified (spot-like signals
 	// carry Leverage=0; explicitly setting 1× prevents the account from
@@ -157,31 +510,153 @@ func (b *BinanceFuturesExchange) OpenPosition(ctx context.Context, req OpenPosit
 		positionSide = "SHORT"
 	}
 
+	// Size limit and stop orders against the price they will execute at.
+	sizePrice := req.Price
+	switch req.OrderType {
+	case OrderTypeLimit, OrderTypePostOnly:
+		sizePrice = req.limitPrice()
+	case OrderTypeStopMarket:
+		sizePrice = req.stopPrice()
+	}
 	qty := "0"
-	if req.Price > 0 && req.SizeUSD > 0 {
-		qty = fmt.Sprintf("%.6f", req.SizeUSD/req.Price)
+	if sizePrice > 0 && req.SizeUSD > 0 {
+		qty = fmt.Sprintf("%.6f", req.SizeUSD/sizePrice)
+	}
+
+	params := binanceOrderParams{
+		Symbol:       binanceSymbol(req.Symbol),
+		Side:         side,
+		PositionSide: positionSide,
+		Type:         "MARKET",
+		Quantity:     qty,
+	}
+	// Limit prices round away from the market so the order stays passive;
+	// stop triggers round beyond the requested breakout.
+	buy := req.Side != domain.PositionSideShort
+	switch req.OrderType {
+	case OrderTypeLimit:
+		params.Type = "LIMIT"
+		params.Price = b.formatPrice(ctx, params.Symbol, req.limitPrice(), !buy)
+		params.TimeInForce = string(TimeInForceGTC)
+		if req.TimeInForce != "" {
+			params.TimeInForce = string(req.TimeInForce)
+		}
+	case OrderTypePostOnly:
+		// GTX ("good till crossing") is Binance Futures' post-only time-in-force.
+		params.Type = "LIMIT"
+		params.Price = b.formatPrice(ctx, params.Symbol, req.limitPrice(), !buy)
+		params.TimeInForce = "GTX"
+	case OrderTypeStopMarket:
+		params.Type = "STOP_MARKET"
+		params.StopPrice = b.formatPrice(ctx, params.Symbol, req.stopPrice(), buy)
 	}
 
 	var result *binanceOrderResult
 	if err := b.withRetry(ctx, func(ctx context.Context) error {
 		var err error
-		result, err = b.client.newOrder(ctx, binanceSymbol(req.Symbol), side, positionSide, "MARKET", qty)
+		result, err = b.client.newOrder(ctx, params)
 		return err
 	}); err != nil {
 		return nil, fmt.Errorf("binance open position: %w", err)
 	}
 
-	margin := result.AvgPrice * result.Quantity
-	if req.Leverage > 1 {
-		margin /= float64(req.Leverage)
+	return b.orderResult(result, req.Leverage), nil
+}
+
+// GetOrder returns the current state of an entry order placed by OpenPosition.
+func (b *BinanceFuturesExchange) GetOrder(ctx context.Context, symbol, orderID string) (*OrderResult, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid binance order id %q", orderID)
 	}
+	var result *binanceOrderResult
+	if err := b.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = b.client.getOrder(ctx, binanceSymbol(symbol), id)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("binance get order: %w", err)
+	}
+	return b.orderResult(result, 0), nil
+}
 
+// CancelOrder cancels a resting order on Binance.
+func (b *BinanceFuturesExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid binance order id %q", orderID)
+	}
+	if err := b.withRetry(ctx, func(ctx context.Context) error {
+		return b.client.cancelOrder(ctx, binanceSymbol(symbol), id)
+	}); err != nil {
+		return fmt.Errorf("binance cancel order: %w", err)
+	}
+	return nil
+}
+
+// PlaceProtectiveOrder places a closePosition STOP_MARKET or
+// TAKE_PROFIT_MARKET order triggered on the mark price, so the exit fires on
+// Binance even when the engine is not running.
+func (b *BinanceFuturesExchange) PlaceProtectiveOrder(ctx context.Context, req ProtectiveOrderRequest) (string, error) {
+	if req.TriggerPrice <= 0 {
+		return "", fmt.Errorf("protective %s order for %s: trigger price must be positive", req.Kind, req.Symbol)
+	}
+
+	// Exit side is opposite of the position side.
+	side := "SELL"
+	positionSide := "LONG"
+	if req.Side == domain.PositionSideShort {
+		side = "BUY"
+		positionSide = "SHORT"
+	}
+	orderType := "STOP_MARKET"
+	if req.Kind == ProtectiveTakeProfit {
+		orderType = "TAKE_PROFIT_MARKET"
+	}
+	// Round the trigger away from the position: a long's stop down and its
+	// take-profit up, a short's the other way round.
+	symbol := binanceSymbol(req.Symbol)
+	up := (req.Side == domain.PositionSideShort) != (req.Kind == ProtectiveTakeProfit)
+	trigger := b.formatPrice(ctx, symbol, req.TriggerPrice, up)
+
+	var result *binanceOrderResult
+	if err := b.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = b.client.newOrder(ctx, binanceOrderParams{
+			Symbol:        symbol,
+			Side:          side,
+			PositionSide:  positionSide,
+			Type:          orderType,
+			StopPrice:     trigger,
+			ClosePosition: true,
+			WorkingType:   "MARK_PRICE",
+		})
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("binance protective %s order: %w", req.Kind, err)
+	}
+	return strconv.FormatInt(result.OrderID, 10), nil
+}
+
+// orderResult converts a raw Binance order into an OrderResult. Margin is
+// derived from the filled notional and leverage (0 or 1 = unlevered).
+func (b *BinanceFuturesExchange) orderResult(result *binanceOrderResult, leverage int) *OrderResult {
+	margin := result.AvgPrice * result.Quantity
+	if leverage > 1 {
+		margin /= float64(leverage)
+	}
+	var orderID string
+	if result.OrderID != 0 {
+		orderID = strconv.FormatInt(result.OrderID, 10)
+	}
 	return &OrderResult{
+		OrderID:   orderID,
+		Status:    result.orderStatus(),
 		FillPrice: result.AvgPrice,
 		Quantity:  result.Quantity,
 		Fee:       result.Fee,
 		Margin:    margin,
-	}, nil
+	}
 }
 
 func (b *BinanceFuturesExchange) ClosePosition(ctx context.Context, req ClosePositionRequest) (*OrderResult, error) {
@@ -206,11 +681,21 @@ func (b *BinanceFuturesExchange) ClosePosition(ctx context.Context, req ClosePos
 		closePosSide = "SHORT"
 	}
 
+	if req.Fraction > 0 && req.Fraction < 1 {
+		openQty *= req.Fraction
+	}
+
 	qtyStr := fmt.Sprintf("%.6f", openQty)
 	var result *binanceOrderResult
 	if err := b.withRetry(ctx, func(ctx context.Context) error {
 		var err error
-		result, err = b.client.newOrder(ctx, binanceSymbol(req.Symbol), closeSide, closePosSide, "MARKET", qtyStr)
+		result, err = b.client.newOrder(ctx, binanceOrderParams{
+			Symbol:       binanceSymbol(req.Symbol),
+			Side:         closeSide,
+			PositionSide: closePosSide,
+			Type:         "MARKET",
+			Quantity:     qtyStr,
+		})
 		return err
 	}); err != nil {
 		return nil, fmt.Errorf("binance close position: %w", err)
@@ -235,6 +720,37 @@ func (b *BinanceFuturesExchange) GetBalance(ctx context.Context) (float64, error
 	return balance, nil
 }
 
+// ListPositions returns every non-zero futures position on the account.
+func (b *BinanceFuturesExchange) ListPositions(ctx context.Context) ([]ExchangePosition, error) {
+	var raw []binancePosition
+	if err := b.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		raw, err = b.client.getPositions(ctx)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("binance list positions: %w", err)
+	}
+
+	var out []ExchangePosition
+	for _, p := range raw {
+		if p.Amount == 0 {
+			continue
+		}
+		side := domain.PositionSideLong
+		if p.PositionSide == "SHORT" || (p.PositionSide != "LONG" && p.Amount < 0) {
+			side = domain.PositionSideShort
+		}
+		out = append(out, ExchangePosition{
+			Symbol:     p.Symbol,
+			Side:       side,
+			Quantity:   math.Abs(p.Amount),
+			EntryPrice: p.EntryPrice,
+			Leverage:   p.Leverage,
+		})
+	}
+	return out, nil
+}
+
 // withRetry retries the function once after 1 second on a 429 rate-limit error.
 type binanceRateLimitError struct{}
 
@@ -273,6 +789,35 @@ func binanceSymbol(product string) string {
 	return product
 }
 
+// formatBinancePrice formats a price for the Binance order API without
+// exponent notation or trailing zeros.
+func formatBinancePrice(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// roundToTick rounds p to a multiple of tick, up or down. A price already on
+// the grid, within float error, is kept.
+func roundToTick(p, tick float64, up bool) float64 {
+	n := p / tick
+	if r := math.Round(n); math.Abs(n-r) < 1e-9 {
+		return r * tick
+	}
+	if up {
+		return math.Ceil(n) * tick
+	}
+	return math.Floor(n) * tick
+}
+
+// tickDecimals returns the number of decimal places in a tick size string
+// such as "0.10".
+func tickDecimals(tick string) int {
+	tick = strings.TrimRight(tick, "0")
+	if i := strings.IndexByte(tick, '.'); i >= 0 {
+		return len(tick) - i - 1
+	}
+	return 0
+}
+
 // ──────────────────────────────────────────────────────────────────────────────
 // binanceHTTPClient — raw HTTP implementation
 // ──────────────────────────────────────────────────────────────────────────────
@@ -294,13 +839,53 @@ func newBinanceHTTPClient(cfg *config.Config) *binanceHTTPClient {
 
 const binanceFuturesBaseURL = "https://fapi.binance.com"
 
-func (c *binanceHTTPClient) newOrder(ctx context.Context, symbol, side, positionSide, orderType, quantity string) (*binanceOrderResult, error) {
-	params := fmt.Sprintf("symbol=%s&side=%s&positionSide=%s&type=%s&quantity=%s&timestamp=%d",
-		symbol, side, positionSide, orderType, quantity, time.Now().UnixMilli())
+func (c *binanceHTTPClient) newOrder(ctx context.Context, p binanceOrderParams) (*binanceOrderResult, error) {
+	params := fmt.Sprintf("symbol=%s&side=%s&positionSide=%s&type=%s",
+		p.Symbol, p.Side, p.PositionSide, p.Type)
+	if p.Quantity != "" {
+		params += "&quantity=" + p.Quantity
+	}
+	if p.ClosePosition {
+		params += "&closePosition=true"
+	}
+	if p.WorkingType != "" {
+		params += "&workingType=" + p.WorkingType
+	}
+	if p.Price != "" {
+		params += "&price=" + p.Price
+	}
+	if p.StopPrice != "" {
+		params += "&stopPrice=" + p.StopPrice
+	}
+	if p.TimeInForce != "" {
+		params += "&timeInForce=" + p.TimeInForce
+	}
+	params += fmt.Sprintf("&newOrderRespType=RESULT&timestamp=%d", time.Now().UnixMilli())
+	return c.orderRequest(ctx, http.MethodPost, params)
+}
+
+func (c *binanceHTTPClient) getOrder(ctx context.Context, symbol string, orderID int64) (*binanceOrderResult, error) {
+	params := fmt.Sprintf("symbol=%s&orderId=%d&timestamp=%d", symbol, orderID, time.Now().UnixMilli())
+	return c.orderRequest(ctx, http.MethodGet, params)
+}
+
+func (c *binanceHTTPClient) cancelOrder(ctx context.Context, symbol string, orderID int64) error {
+	params := fmt.Sprintf("symbol=%s&orderId=%d&timestamp=%d", symbol, orderID, time.Now().UnixMilli())
+	_, err := c.orderRequest(ctx, http.MethodDelete, params)
+	var apiErr *binanceAPIError
+	if errors.As(err, &apiErr) && apiErr.Code == binanceErrUnknownOrder {
+		return nil // already filled or cancelled
+	}
+	return err
+}
+
+// orderRequest signs params and calls /fapi/v1/order with the given method,
+// decoding the order object Binance returns for create, query and cancel.
+func (c *binanceHTTPClient) orderRequest(ctx context.Context, method, params string) (*binanceOrderResult, error) {
 	sig := hmacSHA256(c.cfg.BinanceAPISecret, params)
 	url := fmt.Sprintf("%s/fapi/v1/order?%s&signature=%s", binanceFuturesBaseURL, params, sig)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -316,10 +901,13 @@ func (c *binanceHTTPClient) newOrder(ctx context.Context, symbol, side, position
 		return nil, &binanceRateLimitError{}
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("binance order API returned %d", resp.StatusCode)
+		apiErr := &binanceAPIError{StatusCode: resp.StatusCode}
+		_ = decodeJSON(resp.Body, apiErr)
+		return nil, apiErr
 	}
 
 	var body struct {
+		OrderID     int64  `json:"orderId"`
 		AvgPrice    string `json:"avgPrice"`
 		ExecutedQty string `json:"executedQty"`
 		Status      string `json:"status"`
@@ -328,12 +916,30 @@ func (c *binanceHTTPClient) newOrder(ctx context.Context, symbol, side, position
 		return nil, err
 	}
 
-	result := &binanceOrderResult{}
+	result := &binanceOrderResult{OrderID: body.OrderID, Status: body.Status}
 	fmt.Sscanf(body.AvgPrice, "%f", &result.AvgPrice)
 	fmt.Sscanf(body.ExecutedQty, "%f", &result.Quantity)
 	return result, nil
 }
 
+// binanceErrUnknownOrder is the Binance error code for an order that no
+// longer exists (already filled, cancelled or expired).
+const binanceErrUnknownOrder = -2011
+
+// binanceAPIError is a non-200, non-429 response from the Binance API.
+type binanceAPIError struct {
+	StatusCode int
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+}
+
+func (e *binanceAPIError) Error() string {
+	if e.Msg != "" {
+		return fmt.Sprintf("binance order API returned %d: %s (code %d)", e.StatusCode, e.Msg, e.Code)
+	}
+	return fmt.Sprintf("binance order API returned %d", e.StatusCode)
+}
+
 func (c *binanceHTTPClient) setLeverage(ctx context.Context, symbol string, leverage int) error {
 	params := fmt.Sprintf("symbol=%s&leverage=%d&timestamp=%d", symbol, leverage, time.Now().UnixMilli())
 	sig := hmacSHA256(c.cfg.BinanceAPISecret, params)
@@ -447,3 +1053,99 @@ func (c *binanceHTTPClient) getPositionQty(ctx context.Context, symbol string) (
 	}
 	return 0, nil
 }
+
+func (c *binanceHTTPClient) getPositions(ctx context.Context) ([]binancePosition, error) {
+	params := fmt.Sprintf("timestamp=%d", time.Now().UnixMilli())
+	sig := hmacSHA256(c.cfg.BinanceAPISecret, params)
+	url := fmt.Sprintf("%s/fapi/v2/positionRisk?%s&signature=%s", binanceFuturesBaseURL, params, sig)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-MBX-APIKEY", c.cfg.BinanceAPIKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &binanceRateLimitError{}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("binance position risk API returned %d", resp.StatusCode)
+	}
+
+	var rows []struct {
+		Symbol       string `json:"symbol"`
+		PositionAmt  string `json:"positionAmt"`
+		EntryPrice   string `json:"entryPrice"`
+		Leverage     string `json:"leverage"`
+		PositionSide string `json:"positionSide"`
+	}
+	if err := decodeJSON(resp.Body, &rows); err != nil {
+		return nil, err
+	}
+	positions := make([]binancePosition, 0, len(rows))
+	for _, r := range rows {
+		amt, _ := strconv.ParseFloat(r.PositionAmt, 64)
+		entry, _ := strconv.ParseFloat(r.EntryPrice, 64)
+		lev, _ := strconv.Atoi(r.Leverage)
+		positions = append(positions, binancePosition{
+			Symbol:       r.Symbol,
+			PositionSide: r.PositionSide,
+			Amount:       amt,
+			EntryPrice:   entry,
+			Leverage:     lev,
+		})
+	}
+	return positions, nil
+}
+
+// getPriceFilters reads every symbol's PRICE_FILTER from the public
+// exchangeInfo endpoint.
+func (c *binanceHTTPClient) getPriceFilters(ctx context.Context) (map[string]binancePriceFilter, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, binanceFuturesBaseURL+"/fapi/v1/exchangeInfo", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &binanceRateLimitError{}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("binance exchange info API returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Symbols []struct {
+			Symbol  string `json:"symbol"`
+			Filters []struct {
+				FilterType string `json:"filterType"`
+				TickSize   string `json:"tickSize"`
+			} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := decodeJSON(resp.Body, &body); err != nil {
+		return nil, err
+	}
+	filters := make(map[string]binancePriceFilter, len(body.Symbols))
+	for _, sym := range body.Symbols {
+		for _, f := range sym.Filters {
+			if f.FilterType != "PRICE_FILTER" {
+				continue
+			}
+			tick, _ := strconv.ParseFloat(f.TickSize, 64)
+			filters[sym.Symbol] = binancePriceFilter{tick: tick, decimals: tickDecimals(f.TickSize)}
+		}
+	}
+	return filters, nil
+}
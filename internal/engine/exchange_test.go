@@ -22,6 +22,11 @@ type mockBinanceFuturesClient struct {
 	// newOrder result
 	orderResult *binanceOrderResult
 	orderErr    error
+	lastOrder   binanceOrderParams
+
+	// getOrder / cancelOrder
+	getOrderResult *binanceOrderResult
+	cancelledID    int64
 
 	// getBalance result
 	balance    float64
@@ -30,6 +35,13 @@ type mockBinanceFuturesClient struct {
 	// getPositionQty result
 	positionQty    float64
 	positionQtyErr error
+
+	// getPositions result
+	positions []binancePosition
+
+	// getPriceFilters result
+	priceFilters map[string]binancePriceFilter
+	filterLoads  int
 }
 
 func (m *mockBinanceFuturesClient) setLeverage(_ context.Context, symbol string, leverage int) error {
@@ -38,13 +50,26 @@ func (m *mockBinanceFuturesClient) setLeverage(_ context.Context, symbol string,
 	return m.leverageErr
 }
 
-func (m *mockBinanceFuturesClient) newOrder(_ context.Context, _, _, _, _, _ string) (*binanceOrderResult, error) {
+func (m *mockBinanceFuturesClient) newOrder(_ context.Context, p binanceOrderParams) (*binanceOrderResult, error) {
+	m.lastOrder = p
 	if m.orderResult != nil {
 		return m.orderResult, m.orderErr
 	}
 	return &binanceOrderResult{AvgPrice: 50000, Quantity: 0.002}, m.orderErr
 }
 
+func (m *mockBinanceFuturesClient) getOrder(_ context.Context, _ string, orderID int64) (*binanceOrderResult, error) {
+	if m.getOrderResult != nil {
+		return m.getOrderResult, nil
+	}
+	return &binanceOrderResult{OrderID: orderID, Status: "NEW"}, nil
+}
+
+func (m *mockBinanceFuturesClient) cancelOrder(_ context.Context, _ string, orderID int64) error {
+	m.cancelledID = orderID
+	return nil
+}
+
 func (m *mockBinanceFuturesClient) getBalance(_ context.Context) (float64, error) {
 	return m.balance, m.balanceErr
 }
@@ -53,6 +78,15 @@ func (m *mockBinanceFuturesClient) getPositionQty(_ context.Context, _ string) (
 	return m.positionQty, m.positionQtyErr
 }
 
+func (m *mockBinanceFuturesClient) getPositions(_ context.Context) ([]binancePosition, error) {
+	return m.positions, nil
+}
+
+func (m *mockBinanceFuturesClient) getPriceFilters(_ context.Context) (map[string]binancePriceFilter, error) {
+	m.filterLoads++
+	return m.priceFilters, nil
+}
+
 // ── helpers ───────────────────────────────────────────────────────────────────
 
 func newTestExchange(mock *mockBinanceFuturesClient) *BinanceFuturesExchange {
@@ -129,3 +163,220 @@ func TestOpenPosition_FillQuantityFromExecutedQty(t *testing.T) {
 		t.Errorf("Quantity: want %v, got %v", want, result.Quantity)
 	}
 }
+
+// ── order type tests ──────────────────────────────────────────────────────────
+
+func TestOpenPosition_LimitOrderParams(t *testing.T) {
+	mock := &mockBinanceFuturesClient{
+		orderResult: &binanceOrderResult{OrderID: 42, Status: "NEW"},
+	}
+	ex := newTestExchange(mock)
+
+	result, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol:     "BTC-USD",
+		Side:       domain.PositionSideLong,
+		SizeUSD:    1000,
+		Price:      50000,
+		OrderType:  OrderTypeLimit,
+		LimitPrice: 49500,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := mock.lastOrder
+	if p.Type != "LIMIT" || p.TimeInForce != "GTC" || p.Price != "49500" {
+		t.Errorf("order params: got type=%s tif=%s price=%s", p.Type, p.TimeInForce, p.Price)
+	}
+	if p.Quantity != "0.020202" {
+		t.Errorf("Quantity: want sized at limit price 0.020202, got %s", p.Quantity)
+	}
+	if !result.Pending() || result.OrderID != "42" {
+		t.Errorf("result: want pending order 42, got status=%s id=%s", result.Status, result.OrderID)
+	}
+}
+
+func TestOpenPosition_PostOnlyUsesGTX(t *testing.T) {
+	mock := &mockBinanceFuturesClient{}
+	ex := newTestExchange(mock)
+
+	_, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol:      "ETH-USD",
+		Side:        domain.PositionSideShort,
+		SizeUSD:     1000,
+		Price:       2500,
+		OrderType:   OrderTypePostOnly,
+		TimeInForce: TimeInForceIOC, // ignored for post-only
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := mock.lastOrder
+	if p.Type != "LIMIT" || p.TimeInForce != "GTX" || p.Side != "SELL" || p.PositionSide != "SHORT" {
+		t.Errorf("order params: got %+v", p)
+	}
+}
+
+func TestOpenPosition_StopMarketParams(t *testing.T) {
+	mock := &mockBinanceFuturesClient{}
+	ex := newTestExchange(mock)
+
+	_, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol:    "BTC-USD",
+		Side:      domain.PositionSideLong,
+		SizeUSD:   1000,
+		Price:     50000,
+		OrderType: OrderTypeStopMarket,
+		StopPrice: 51000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := mock.lastOrder
+	if p.Type != "STOP_MARKET" || p.StopPrice != "51000" || p.Price != "" || p.TimeInForce != "" {
+		t.Errorf("order params: got %+v", p)
+	}
+}
+
+func TestOpenPosition_EntryPricesRoundedToTick(t *testing.T) {
+	mock := &mockBinanceFuturesClient{priceFilters: map[string]binancePriceFilter{"BTCUSDT": {tick: 0.1, decimals: 1}}}
+	ex := newTestExchange(mock)
+	ctx := context.Background()
+
+	cases := []struct {
+		side      domain.PositionSide
+		orderType OrderType
+		want      string
+	}{
+		{domain.PositionSideLong, OrderTypeLimit, "49500.1"},       // buy limit rounds down
+		{domain.PositionSideShort, OrderTypePostOnly, "49500.2"},   // sell limit rounds up
+		{domain.PositionSideLong, OrderTypeStopMarket, "49500.2"},  // buy stop rounds up
+		{domain.PositionSideShort, OrderTypeStopMarket, "49500.1"}, // sell stop rounds down
+	}
+	for _, tc := range cases {
+		req := OpenPositionRequest{Symbol: "BTC-USD", Side: tc.side, SizeUSD: 1000, Price: 50000, OrderType: tc.orderType}
+		if tc.orderType == OrderTypeStopMarket {
+			req.StopPrice = 49500.15
+		} else {
+			req.LimitPrice = 49500.15
+		}
+		if _, err := ex.OpenPosition(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		got := mock.lastOrder.Price
+		if tc.orderType == OrderTypeStopMarket {
+			got = mock.lastOrder.StopPrice
+		}
+		assertEq(t, string(tc.side)+" "+string(tc.orderType), tc.want, got)
+	}
+}
+
+func TestCancelOrder_ParsesOrderID(t *testing.T) {
+	mock := &mockBinanceFuturesClient{}
+	ex := newTestExchange(mock)
+
+	if err := ex.CancelOrder(context.Background(), "BTC-USD", "12345"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.cancelledID != 12345 {
+		t.Errorf("cancelOrder: want 12345, got %d", mock.cancelledID)
+	}
+	if err := ex.CancelOrder(context.Background(), "BTC-USD", "noop-1"); err == nil {
+		t.Error("expected error for non-numeric order id")
+	}
+}
+
+// ── NoopExchange order simulation ─────────────────────────────────────────────
+
+func TestNoopExchange_LimitRestsUntilPriceCrosses(t *testing.T) {
+	ex := NewNoopExchange(&config.Config{})
+	ctx := context.Background()
+
+	res, err := ex.OpenPosition(ctx, OpenPositionRequest{
+		Symbol:     "BTC-USD",
+		Side:       domain.PositionSideLong,
+		SizeUSD:    1000,
+		Price:      50000,
+		OrderType:  OrderTypeLimit,
+		LimitPrice: 49000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Pending() {
+		t.Fatalf("want resting order, got status %s", res.Status)
+	}
+
+	ex.ObservePrice("BTC-USD", 49500) // not crossed
+	got, _ := ex.GetOrder(ctx, "BTC-USD", res.OrderID)
+	if !got.Pending() {
+		t.Fatalf("order filled before price crossed the limit")
+	}
+
+	ex.ObservePrice("BTC-USD", 48800)
+	got, _ = ex.GetOrder(ctx, "BTC-USD", res.OrderID)
+	if got.Status != domain.OrderStatusFilled {
+		t.Fatalf("want filled, got %s", got.Status)
+	}
+	if got.FillPrice != 49000 {
+		t.Errorf("FillPrice: want limit price 49000, got %v", got.FillPrice)
+	}
+}
+
+func TestNoopExchange_PostOnlyCrossingRejected(t *testing.T) {
+	ex := NewNoopExchange(&config.Config{})
+
+	_, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol:     "BTC-USD",
+		Side:       domain.PositionSideLong,
+		SizeUSD:    1000,
+		Price:      50000,
+		OrderType:  OrderTypePostOnly,
+		LimitPrice: 50100, // above market — would take liquidity
+	})
+	if err == nil {
+		t.Fatal("expected post-only order that crosses the book to be rejected")
+	}
+}
+
+func TestNoopExchange_PostOnlyAtMarketRests(t *testing.T) {
+	ex := NewNoopExchange(&config.Config{})
+
+	res, err := ex.OpenPosition(context.Background(), OpenPositionRequest{
+		Symbol:    "BTC-USD",
+		Side:      domain.PositionSideShort,
+		SizeUSD:   1000,
+		Price:     50000,
+		OrderType: OrderTypePostOnly, // limit defaults to the signal price
+	})
+	if err != nil {
+		t.Fatalf("a post-only order at the market should join the book: %v", err)
+	}
+	if !res.Pending() {
+		t.Errorf("want resting order, got status %s", res.Status)
+	}
+}
+
+func TestNoopExchange_CancelledOrderDoesNotFill(t *testing.T) {
+	ex := NewNoopExchange(&config.Config{})
+	ctx := context.Background()
+
+	res, err := ex.OpenPosition(ctx, OpenPositionRequest{
+		Symbol:    "ETH-USD",
+		Side:      domain.PositionSideShort,
+		SizeUSD:   1000,
+		Price:     2500,
+		OrderType: OrderTypeStopMarket,
+		StopPrice: 2400,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ex.CancelOrder(ctx, "ETH-USD", res.OrderID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	ex.ObservePrice("ETH-USD", 2300)
+	got, _ := ex.GetOrder(ctx, "ETH-USD", res.OrderID)
+	if got.Status != domain.OrderStatusCancelled || got.Quantity != 0 {
+		t.Errorf("want cancelled with no fill, got status=%s qty=%v", got.Status, got.Quantity)
+	}
+}
@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// RejectedEvent is published on the trade stream when the engine refuses an
+// entry signal because a portfolio limit would be exceeded.
+type RejectedEvent struct {
+	Event     string    `json:"event"` // "rejected"
+	AccountID string    `json:"account_id"`
+	Symbol    string    `json:"symbol"`
+	Action    string    `json:"action"`
+	Strategy  string    `json:"strategy,omitempty"`
+	SignalID  string    `json:"signal_id,omitempty"`
+	Limit     string    `json:"limit"` // which limit fired, e.g. "max_total_exposure"
+	Value     float64   `json:"value"` // the limited quantity had the trade gone through
+	Max       float64   `json:"max"`
+	Cluster   []string  `json:"cluster,omitempty"` // correlated open symbols, for max_correlated_positions
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// limitBreach names the limit an entry would break.
+type limitBreach struct {
+	Limit   string
+	Value   float64
+	Max     float64
+	Cluster []string // open symbols correlated with the entry, if any
+}
+
+func (b *limitBreach) String() string {
+	s := fmt.Sprintf("%s: %.2f would exceed %.2f", b.Limit, b.Value, b.Max)
+	if len(b.Cluster) > 0 {
+		s += " (correlated with " + strings.Join(b.Cluster, ", ") + ")"
+	}
+	return s
+}
+
+// exposureLimits are the portfolio-wide caps in USD; zero disables a cap.
+type exposureLimits struct {
+	total     float64 // gross notional across all positions
+	perSymbol float64 // gross notional per symbol across accounts
+	netDelta  float64 // |long notional − short notional|
+	margin    float64 // futures margin in use
+}
+
+func (l exposureLimits) enabled() bool {
+	return l.total > 0 || l.perSymbol > 0 || l.netDelta > 0 || l.margin > 0
+}
+
+// exposure is the open notional across every managed account.
+type exposure struct {
+	total    float64
+	bySymbol map[string]float64
+	net      float64 // long minus short
+	margin   float64
+}
+
+// add counts a position of notional USD (and margin, for futures) on side.
+func (x *exposure) add(symbol string, side domain.PositionSide, notional, margin float64) {
+	x.total += notional
+	x.bySymbol[symbol] += notional
+	if side == domain.PositionSideShort {
+		x.net -= notional
+	} else {
+		x.net += notional
+	}
+	x.margin += margin
+}
+
+// breach reports the first limit a new entry of notional (and margin) on
+// symbol and side would break, checked in the order total, per-symbol, net
+// delta, margin. An entry that shrinks the net delta never breaks that limit.
+func (x *exposure) breach(l exposureLimits, symbol string, side domain.PositionSide, notional, margin float64) *limitBreach {
+	if l.total > 0 && x.total+notional > l.total {
+		return &limitBreach{Limit: "max_total_exposure", Value: x.total + notional, Max: l.total}
+	}
+	if l.perSymbol > 0 && x.bySymbol[symbol]+notional > l.perSymbol {
+		return &limitBreach{Limit: "max_symbol_exposure", Value: x.bySymbol[symbol] + notional, Max: l.perSymbol}
+	}
+	if l.netDelta > 0 {
+		net := x.net + notional
+		if side == domain.PositionSideShort {
+			net = x.net - notional
+		}
+		if math.Abs(net) > l.netDelta && math.Abs(net) > math.Abs(x.net) {
+			return &limitBreach{Limit: "max_net_exposure", Value: math.Abs(net), Max: l.netDelta}
+		}
+	}
+	if l.margin > 0 && margin > 0 && x.margin+margin > l.margin {
+		return &limitBreach{Limit: "max_margin_usage", Value: x.margin + margin, Max: l.margin}
+	}
+	return nil
+}
+
+func (e *Engine) exposureLimits() exposureLimits {
+	return exposureLimits{
+		total:     e.cfg.MaxTotalExposure,
+		perSymbol: e.cfg.MaxSymbolExposure,
+		netDelta:  e.cfg.MaxNetExposure,
+		margin:    e.cfg.MaxMarginUsage,
+	}
+}
+
+// portfolioExposure sums the open ledger positions of every managed account.
+// Positions are marked at the last signal price for their symbol, or at
+// their average entry price before one has been seen. Futures margin is the
+// recorded margin, or notional over leverage when none was recorded.
+func (e *Engine) portfolioExposure(ctx context.Context) (*exposure, error) {
+	x := &exposure{bySymbol: make(map[string]float64)}
+	for _, accountID := range e.accounts {
+		positions, err := e.repo.ListOpenPositionsForAccount(ctx, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("list open positions for %s: %w", accountID, err)
+		}
+		for _, p := range positions {
+			e.lastPriceMu.RLock()
+			price := e.lastPrice[p.Symbol]
+			e.lastPriceMu.RUnlock()
+			if price <= 0 {
+				price = p.AvgEntryPrice
+			}
+			notional := math.Abs(p.Quantity) * price
+			var margin float64
+			if p.MarketType == domain.MarketTypeFutures {
+				switch {
+				case p.Margin != nil:
+					margin = *p.Margin
+				case p.Leverage != nil && *p.Leverage > 0:
+					margin = notional / float64(*p.Leverage)
+				default:
+					margin = notional
+				}
+			}
+			x.add(p.Symbol, p.Side, notional, margin)
+		}
+	}
+	return x, nil
+}
+
+// checkExposure reports the portfolio limit an entry would break, or nil.
+// Returns an error when open positions cannot be loaded; callers fail closed.
+func (e *Engine) checkExposure(ctx context.Context, symbol string, side domain.PositionSide, notional, margin float64) (*limitBreach, error) {
+	limits := e.exposureLimits()
+	if !limits.enabled() {
+		return nil, nil
+	}
+	x, err := e.portfolioExposure(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return x.breach(limits, symbol, side, notional, margin), nil
+}
+
+// publishRejected fans out a RejectedEvent for an entry signal refused by a
+// portfolio limit.
+func (e *Engine) publishRejected(accountID, product, strategy string, signal SignalPayload, b *limitBreach) {
+	if e.publisher == nil {
+		return
+	}
+	e.publisher.Publish(accountID, RejectedEvent{
+		Event:     "rejected",
+		AccountID: accountID,
+		Symbol:    product,
+		Action:    signal.Action,
+		Strategy:  strategy,
+		SignalID:  signal.ID,
+		Limit:     b.Limit,
+		Value:     b.Value,
+		Max:       b.Max,
+		Cluster:   b.Cluster,
+		Reason:    b.String(),
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// exposureStore serves open ledger positions per account.
+type exposureStore struct {
+	EngineStore
+	positions map[string][]domain.Position
+}
+
+func (s *exposureStore) ListOpenPositionsForAccount(_ context.Context, accountID string) ([]domain.Position, error) {
+	return s.positions[accountID], nil
+}
+
+func (s *exposureStore) GetAccountBalance(_ context.Context, _ uuid.UUID, _, _ string) (*float64, error) {
+	return nil, nil
+}
+
+func TestExposureBreach(t *testing.T) {
+	x := &exposure{bySymbol: map[string]float64{"BTC-USD": 4000, "ETH-USD": 2000}, total: 6000, net: 2000, margin: 1500}
+	long, short := domain.PositionSideLong, domain.PositionSideShort
+
+	tests := []struct {
+		name     string
+		limits   exposureLimits
+		symbol   string
+		side     domain.PositionSide
+		notional float64
+		margin   float64
+		want     string
+	}{
+		{"no limits", exposureLimits{}, "BTC-USD", long, 1e9, 1e9, ""},
+		{"total within", exposureLimits{total: 7000}, "SOL-USD", long, 1000, 0, ""},
+		{"total exceeded", exposureLimits{total: 7000}, "SOL-USD", long, 1001, 0, "max_total_exposure"},
+		{"symbol exceeded", exposureLimits{perSymbol: 5000}, "BTC-USD", long, 1500, 0, "max_symbol_exposure"},
+		{"other symbol fine", exposureLimits{perSymbol: 5000}, "ETH-USD", long, 1500, 0, ""},
+		{"net delta exceeded long", exposureLimits{netDelta: 2500}, "SOL-USD", long, 1000, 0, "max_net_exposure"},
+		{"short reduces net delta", exposureLimits{netDelta: 1000}, "SOL-USD", short, 1500, 0, ""},
+		{"short overshooting the other way", exposureLimits{netDelta: 1000}, "SOL-USD", short, 5000, 0, "max_net_exposure"},
+		{"margin exceeded", exposureLimits{margin: 2000}, "SOL-USD", long, 3000, 600, "max_margin_usage"},
+		{"spot uses no margin", exposureLimits{margin: 1000}, "SOL-USD", long, 3000, 0, ""},
+		{"total is checked first", exposureLimits{total: 6500, perSymbol: 4200}, "BTC-USD", long, 1000, 0, "max_total_exposure"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := x.breach(tt.limits, tt.symbol, tt.side, tt.notional, tt.margin)
+			got := ""
+			if b != nil {
+				got = b.Limit
+			}
+			if got != tt.want {
+				t.Errorf("want %q, got %q (%v)", tt.want, got, b)
+			}
+		})
+	}
+}
+
+func TestPortfolioExposure_SumsAccountsAtMarkPrice(t *testing.T) {
+	lev, margin := 5, 300.0
+	e := makeEngine(&config.Config{})
+	e.accounts = []string{"a", "b"}
+	e.repo = &exposureStore{positions: map[string][]domain.Position{
+		"a": {{Symbol: "BTC-USD", MarketType: domain.MarketTypeFutures, Side: domain.PositionSideLong, Quantity: 0.1, AvgEntryPrice: 20000, Leverage: &lev}},
+		"b": {
+			{Symbol: "BTC-USD", MarketType: domain.MarketTypeFutures, Side: domain.PositionSideShort, Quantity: 0.05, AvgEntryPrice: 21000, Margin: &margin},
+			{Symbol: "ETH-USD", MarketType: domain.MarketTypeSpot, Side: domain.PositionSideLong, Quantity: 1, AvgEntryPrice: 1500},
+		},
+	}}
+	e.lastPrice["BTC-USD"] = 30000
+
+	x, err := e.portfolioExposure(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "BTC gross", 4500, x.bySymbol["BTC-USD"])
+	assertFloat(t, "total", 6000, x.total)
+	assertFloat(t, "net", 3000+1500-1500, x.net)
+	assertFloat(t, "margin", 3000.0/5+300, x.margin)
+}
+
+func TestHandleOpenSignal_RejectsOverExposureAndPublishes(t *testing.T) {
+	cfg := &config.Config{TradingMode: "paper", PortfolioSize: 10000, PositionSizePct: 10, MaxSymbolExposure: 1400}
+	pub := &recordingPublisher{}
+	e := makeEngine(cfg)
+	e.exchange = NewNoopExchange(cfg)
+	e.exchanges = NewPaperExchangeRegistry(e.exchange)
+	e.publisher = pub
+	e.accounts = []string{"acc", "other"}
+	e.repo = &exposureStore{positions: map[string][]domain.Position{
+		"other": {{Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot, Side: domain.PositionSideLong, Quantity: 0.01, AvgEntryPrice: 50000}},
+	}}
+	tc := &TradingConfig{Exchange: "binance"}
+	signal := SignalPayload{Action: "BUY", Price: 50000, Confidence: 0.9, ID: "sig-1"}
+
+	// 500 held by another account + a 1000 entry > 1400.
+	e.handleOpenSignal(context.Background(), signal, "BTC-USD", "trend", "acc", tc)
+
+	if len(pub.events) != 1 {
+		t.Fatalf("want one rejected event, got %d", len(pub.events))
+	}
+	ev := pub.events[0].(RejectedEvent)
+	assertEq(t, "event", "rejected", ev.Event)
+	assertEq(t, "limit", "max_symbol_exposure", ev.Limit)
+	assertEq(t, "signal", "sig-1", ev.SignalID)
+	assertFloat(t, "value", 1500, ev.Value)
+	assertFloat(t, "max", 1400, ev.Max)
+	if _, open := e.posState[posKey("acc", "BTC-USD")]; open {
+		t.Error("rejected signal must not open a position")
+	}
+}
@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// Kelly sizing, selected per trading config with sizing_mode "kelly".
+const (
+	sizingKelly = "kelly"
+
+	defaultKellyFraction = 0.5 // half-Kelly
+	defaultKellyMaxPct   = 25  // cap, % of equity
+)
+
+// kellyKey identifies the closed-trade sample a Kelly bet is computed from.
+type kellyKey struct {
+	strategy string
+	symbol   string
+}
+
+// tradeStats summarises closed trades by their return on cost basis.
+type tradeStats struct {
+	Trades  int
+	Wins    int
+	AvgWin  float64 // mean return of winning trades, e.g. 0.04 = 4%
+	AvgLoss float64 // mean magnitude of losing trades' returns
+}
+
+func (s tradeStats) winRate() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Trades)
+}
+
+// kelly returns the full-Kelly fraction W − (1−W)/R, where R is the ratio of
+// the average win to the average loss. Without losses it is 1; without wins
+// it is negative.
+func (s tradeStats) kelly() float64 {
+	w := s.winRate()
+	if s.AvgLoss == 0 {
+		return w
+	}
+	if s.AvgWin == 0 {
+		return -1
+	}
+	return w - (1-w)/(s.AvgWin/s.AvgLoss)
+}
+
+// buildTradeStats groups closing trades by strategy and symbol. Each trade's
+// return is its realised P&L over its cost basis; trades without a strategy,
+// cost basis or P&L are skipped.
+func buildTradeStats(trades []domain.Trade) map[kellyKey]tradeStats {
+	type sums struct {
+		tradeStats
+		win, loss float64
+	}
+	acc := make(map[kellyKey]*sums)
+	for _, t := range trades {
+		if t.Strategy == nil || *t.Strategy == "" || t.CostBasis <= 0 || t.RealizedPnL == 0 {
+			continue
+		}
+		key := kellyKey{strategy: *t.Strategy, symbol: t.Symbol}
+		s := acc[key]
+		if s == nil {
+			s = &sums{}
+			acc[key] = s
+		}
+		r := t.RealizedPnL / t.CostBasis
+		s.Trades++
+		if r > 0 {
+			s.Wins++
+			s.win += r
+		} else {
+			s.loss += -r
+		}
+	}
+
+	stats := make(map[kellyKey]tradeStats, len(acc))
+	for key, s := range acc {
+		st := s.tradeStats
+		if st.Wins > 0 {
+			st.AvgWin = s.win / float64(st.Wins)
+		}
+		if losses := st.Trades - st.Wins; losses > 0 {
+			st.AvgLoss = s.loss / float64(losses)
+		}
+		stats[key] = st
+	}
+	return stats
+}
+
+// kellyPct returns the position size as a percentage of equity for a Kelly
+// sized trading config: the config's kelly_fraction of the full-Kelly bet,
+// capped at kelly_max_pct. ok is false when the strategy has fewer than
+// KELLY_MIN_TRADES closed trades on the symbol, in which case the caller
+// sizes from POSITION_SIZE_PCT. A strategy without an edge gets 0.
+func (e *Engine) kellyPct(strategy, symbol string, tc *TradingConfig) (pct float64, stats tradeStats, ok bool) {
+	e.kellyMu.RLock()
+	stats = e.kellyStats[kellyKey{strategy: strategy, symbol: symbol}]
+	e.kellyMu.RUnlock()
+	if stats.Trades == 0 || stats.Trades < e.cfg.KellyMinTrades {
+		return 0, stats, false
+	}
+
+	fraction := tc.KellyFraction
+	if fraction <= 0 {
+		fraction = defaultKellyFraction
+	}
+	maxPct := tc.KellyMaxPct
+	if maxPct <= 0 {
+		maxPct = defaultKellyMaxPct
+	}
+	pct = math.Max(stats.kelly(), 0) * fraction * 100
+	return math.Min(pct, maxPct), stats, true
+}
+
+// usesKellySizing reports whether any cached trading config sizes by Kelly.
+func (e *Engine) usesKellySizing() bool {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	for _, tc := range e.tradingConfigs {
+		if tc.SizingMode == sizingKelly {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshKellyStats rebuilds the per-strategy+symbol statistics from the
+// closing trades of every managed account within KELLY_LOOKBACK. The previous
+// statistics are kept when any account fails to load.
+func (e *Engine) refreshKellyStats(ctx context.Context) error {
+	since := time.Now().Add(-e.cfg.KellyLookback)
+	var trades []domain.Trade
+	for _, accountID := range e.accounts {
+		t, err := e.repo.ListClosingTrades(ctx, accountID, since)
+		if err != nil {
+			return fmt.Errorf("list closing trades for %s: %w", accountID, err)
+		}
+		trades = append(trades, t...)
+	}
+	stats := buildTradeStats(trades)
+
+	e.kellyMu.Lock()
+	e.kellyStats = stats
+	e.kellyMu.Unlock()
+	e.logger.Debug().Int("trades", len(trades)).Int("samples", len(stats)).Msg("refreshed Kelly sizing statistics")
+	return nil
+}
+
+// startKellyRefresher refreshes the Kelly statistics now and then every
+// KELLY_REFRESH_INTERVAL while any trading config uses Kelly sizing. Blocks
+// until ctx is cancelled.
+func (e *Engine) startKellyRefresher(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.KellyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if e.usesKellySizing() {
+			if err := e.refreshKellyStats(ctx); err != nil && ctx.Err() == nil {
+				e.logger.Warn().Err(err).Msg("failed to refresh Kelly sizing statistics — keeping previous")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// closingTradeStore serves ledger closing trades per account.
+type closingTradeStore struct {
+	EngineStore
+	trades map[string][]domain.Trade
+}
+
+func (s *closingTradeStore) ListClosingTrades(_ context.Context, accountID string, _ time.Time) ([]domain.Trade, error) {
+	return s.trades[accountID], nil
+}
+
+// closes returns n closing trades for strategy on symbol, each returning r
+// on a $1,000 cost basis.
+func closes(strategy, symbol string, n int, r float64) []domain.Trade {
+	trades := make([]domain.Trade, n)
+	for i := range trades {
+		trades[i] = domain.Trade{Symbol: symbol, Strategy: &strategy, CostBasis: 1000, RealizedPnL: 1000 * r}
+	}
+	return trades
+}
+
+func TestBuildTradeStats_Kelly(t *testing.T) {
+	var trades []domain.Trade
+	trades = append(trades, closes("trend", "BTC-USD", 6, 0.04)...)
+	trades = append(trades, closes("trend", "BTC-USD", 4, -0.02)...)
+	trades = append(trades, closes("trend", "ETH-USD", 3, -0.01)...)
+	trades = append(trades, domain.Trade{Symbol: "BTC-USD", CostBasis: 1000, RealizedPnL: 50}) // no strategy
+
+	stats := buildTradeStats(trades)
+	btc := stats[kellyKey{strategy: "trend", symbol: "BTC-USD"}]
+	if btc.Trades != 10 || btc.Wins != 6 {
+		t.Fatalf("want 10 trades with 6 wins, got %+v", btc)
+	}
+	assertFloat(t, "avg win", 0.04, btc.AvgWin)
+	assertFloat(t, "avg loss", 0.02, btc.AvgLoss)
+	// W − (1−W)/R = 0.6 − 0.4/2 = 0.4
+	assertFloat(t, "kelly", 0.4, btc.kelly())
+
+	if eth := stats[kellyKey{strategy: "trend", symbol: "ETH-USD"}]; eth.kelly() >= 0 {
+		t.Errorf("all-loss sample should have a negative Kelly, got %v", eth.kelly())
+	}
+}
+
+func TestKellyPct_FractionCapAndMinTrades(t *testing.T) {
+	e := makeEngine(&config.Config{KellyMinTrades: 10})
+	e.accounts = []string{"a", "b"}
+	e.repo = &closingTradeStore{trades: map[string][]domain.Trade{
+		"a": append(closes("trend", "BTC-USD", 3, 0.04), closes("trend", "BTC-USD", 2, -0.02)...),
+		"b": append(closes("trend", "BTC-USD", 3, 0.04), append(closes("trend", "BTC-USD", 2, -0.02), closes("scalp", "BTC-USD", 5, 0.01)...)...),
+	}}
+	if err := e.refreshKellyStats(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both accounts pool into one sample: Kelly 0.4, half-Kelly by default.
+	pct, _, ok := e.kellyPct("trend", "BTC-USD", &TradingConfig{SizingMode: "kelly"})
+	if !ok {
+		t.Fatal("10 trades should be enough")
+	}
+	assertFloat(t, "half-Kelly pct", 20, pct)
+	pct, _, _ = e.kellyPct("trend", "BTC-USD", &TradingConfig{SizingMode: "kelly", KellyFraction: 1, KellyMaxPct: 30})
+	assertFloat(t, "capped pct", 30, pct)
+
+	if _, stats, ok := e.kellyPct("scalp", "BTC-USD", &TradingConfig{SizingMode: "kelly"}); ok || stats.Trades != 5 {
+		t.Errorf("5 trades should fall back, got ok=%v %+v", ok, stats)
+	}
+}
+
+func TestHandleOpenSignal_KellyWithoutEdgeSkips(t *testing.T) {
+	cfg := &config.Config{TradingMode: "paper", PortfolioSize: 10000, PositionSizePct: 10, KellyMinTrades: 3}
+	e := makeEngine(cfg)
+	e.exchange = NewNoopExchange(cfg)
+	e.exchanges = NewPaperExchangeRegistry(e.exchange)
+	e.accounts = []string{"acc"}
+	store := &closingTradeStore{trades: map[string][]domain.Trade{"acc": closes("trend", "BTC-USD", 5, -0.02)}}
+	e.repo = store
+	if err := e.refreshKellyStats(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tc := &TradingConfig{Exchange: "binance", SizingMode: "kelly"}
+	signal := SignalPayload{Action: "BUY", Price: 50000, Confidence: 0.9}
+
+	// The store has no balance or trade methods; reaching them would panic.
+	e.handleOpenSignal(context.Background(), signal, "BTC-USD", "trend", "acc", tc)
+
+	if _, open := e.posState[posKey("acc", "BTC-USD")]; open {
+		t.Error("a strategy without an edge must not open a position")
+	}
+}
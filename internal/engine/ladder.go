@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// buildLadder resolves a trading config's take-profit ladder to trigger
+// prices for a new position. R is the distance from entry to the stop loss,
+// or 4% of entry when the signal carried no stop — the same fallback the risk
+// library uses for trailing stops. Rungs without a positive R or close_pct
+// are ignored.
+func buildLadder(rungs []TakeProfitRung, side string, entryPrice, stopLoss float64) []LadderRung {
+	if len(rungs) == 0 || entryPrice <= 0 {
+		return nil
+	}
+	unit := entryPrice * 0.04
+	if stopLoss > 0 {
+		unit = math.Abs(entryPrice - stopLoss)
+	}
+
+	var ladder []LadderRung
+	for _, r := range rungs {
+		if r.R <= 0 || r.ClosePct <= 0 {
+			continue
+		}
+		price := entryPrice + r.R*unit
+		if side == "short" {
+			price = entryPrice - r.R*unit
+		}
+		ladder = append(ladder, LadderRung{R: r.R, Price: price, ClosePct: math.Min(r.ClosePct, 1)})
+	}
+	sort.Slice(ladder, func(i, j int) bool { return ladder[i].R < ladder[j].R })
+	return ladder
+}
+
+// nextLadderRung returns the index of the first unfilled rung that price has
+// reached, or -1.
+func nextLadderRung(side string, ladder []LadderRung, price float64) int {
+	for i, r := range ladder {
+		if r.Filled {
+			continue
+		}
+		if (side == "long" && price >= r.Price) || (side == "short" && price <= r.Price) {
+			return i
+		}
+		return -1
+	}
+	return -1
+}
+
+// ladderCloseFraction converts rung i's share of the position as entered into
+// a fraction of what is still open. Returns 1 when the rung takes everything
+// that is left.
+func ladderCloseFraction(ladder []LadderRung, i int) float64 {
+	remaining := 1.0
+	for _, r := range ladder {
+		if r.Filled {
+			remaining -= r.ClosePct
+		}
+	}
+	if remaining <= ladder[i].ClosePct+1e-9 {
+		return 1
+	}
+	return ladder[i].ClosePct / remaining
+}
+
+// takeLadderProfit closes the next take-profit rung reached at price as its
+// own trade. The rung is marked filled and persisted with the reduced
+// quantity so the ladder resumes where it left off after a restart. Reports
+// whether a rung fired.
+func (e *Engine) takeLadderProfit(ctx context.Context, ps *PositionState, price float64) bool {
+	key := posKey(ps.AccountID, ps.Symbol)
+	e.posStateMu.Lock()
+	psInMap, exists := e.posState[key]
+	if !exists || psInMap.Closing {
+		e.posStateMu.Unlock()
+		return false
+	}
+	i := nextLadderRung(ps.Side, ps.Ladder, price)
+	if i < 0 {
+		e.posStateMu.Unlock()
+		return false
+	}
+	rung := ps.Ladder[i]
+	fraction := ladderCloseFraction(ps.Ladder, i)
+	psInMap.Closing = true
+	e.posStateMu.Unlock()
+
+	reason := fmt.Sprintf("Take-profit ladder: rung %d/%d (%gR) — price $%.4f hit $%.4f", i+1, len(ps.Ladder), rung.R, price, rung.Price)
+	e.logger.Info().
+		Str("account", ps.AccountID).
+		Str("symbol", ps.Symbol).
+		Str("exit_reason", reason).
+		Float64("close_pct", fraction).
+		Msg("take-profit rung reached")
+
+	closed := e.executePartialClose(ctx, ps, price, reason, fraction, "")
+	if closed && fraction >= 1 {
+		return true
+	}
+
+	e.posStateMu.Lock()
+	ps.Closing = false
+	if closed {
+		ps.Ladder[i].Filled = true
+	}
+	rec := ps.record()
+	e.posStateMu.Unlock()
+	if !closed {
+		return true
+	}
+	if err := e.repo.UpdatePositionState(ctx, e.tenantID(), rec); err != nil {
+		e.logger.Warn().Err(err).
+			Str("account", ps.AccountID).
+			Str("symbol", ps.Symbol).
+			Msg("failed to persist take-profit ladder progress")
+	}
+	return true
+}
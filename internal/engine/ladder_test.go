@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// ladderStore serves one open ledger position, shrinking it as close trades
+// are recorded.
+type ladderStore struct {
+	stateStore
+	position domain.Position
+	trades   []*domain.Trade
+}
+
+func (s *ladderStore) ListOpenPositionsForAccount(_ context.Context, _ string) ([]domain.Position, error) {
+	if s.position.Quantity <= 0 {
+		return nil, nil
+	}
+	return []domain.Position{s.position}, nil
+}
+
+func (s *ladderStore) GetAvgEntryPrice(_ context.Context, _ uuid.UUID, _, _ string, _ domain.MarketType) (float64, error) {
+	return s.position.AvgEntryPrice, nil
+}
+
+func (s *ladderStore) InsertTradeAndUpdatePosition(_ context.Context, _ uuid.UUID, trade *domain.Trade) (bool, error) {
+	s.trades = append(s.trades, trade)
+	s.position.Quantity -= trade.Quantity
+	return true, nil
+}
+
+func (s *ladderStore) DeletePositionState(_ context.Context, _ uuid.UUID, _, _, _ string) error {
+	return nil
+}
+
+func TestBuildLadder_PricesFromEntryRisk(t *testing.T) {
+	rungs := []TakeProfitRung{{R: 2, ClosePct: 0.3}, {R: 1, ClosePct: 0.5}, {R: 3, ClosePct: 0}}
+
+	long := buildLadder(rungs, "long", 100, 95)
+	if len(long) != 2 {
+		t.Fatalf("want 2 rungs (zero close_pct dropped), got %d", len(long))
+	}
+	assertFloat(t, "1R long", 105, long[0].Price)
+	assertFloat(t, "2R long", 110, long[1].Price)
+
+	short := buildLadder(rungs, "short", 100, 0)
+	assertFloat(t, "1R short, 4% fallback", 96, short[0].Price)
+	assertFloat(t, "2R short, 4% fallback", 92, short[1].Price)
+
+	if buildLadder(nil, "long", 100, 95) != nil {
+		t.Error("no rungs configured should yield no ladder")
+	}
+}
+
+func TestLadderCloseFraction(t *testing.T) {
+	ladder := []LadderRung{{ClosePct: 0.5}, {ClosePct: 0.3}}
+	assertFloat(t, "first rung", 0.5, ladderCloseFraction(ladder, 0))
+	ladder[0].Filled = true
+	assertFloat(t, "second rung of remaining half", 0.6, ladderCloseFraction(ladder, 1))
+
+	full := []LadderRung{{ClosePct: 0.5, Filled: true}, {ClosePct: 0.5}}
+	assertFloat(t, "last rung takes the rest", 1, ladderCloseFraction(full, 1))
+}
+
+func TestNextLadderRung(t *testing.T) {
+	ladder := []LadderRung{{Price: 105, Filled: true}, {Price: 110}, {Price: 115}}
+	if got := nextLadderRung("long", ladder, 112); got != 1 {
+		t.Errorf("want rung 1, got %d", got)
+	}
+	if got := nextLadderRung("long", ladder, 108); got != -1 {
+		t.Errorf("want no rung, got %d", got)
+	}
+	if got := nextLadderRung("short", []LadderRung{{Price: 95}}, 94); got != 0 {
+		t.Errorf("short: want rung 0, got %d", got)
+	}
+}
+
+func TestTakeLadderProfit_ClosesRungsAsSeparateTrades(t *testing.T) {
+	store := &ladderStore{position: domain.Position{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot,
+		Side: domain.PositionSideLong, Quantity: 10, AvgEntryPrice: 100,
+	}}
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	ps := &PositionState{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: "spot", Side: "long",
+		EntryPrice: 100, StopLoss: 95, Quantity: 10,
+		Ladder: buildLadder([]TakeProfitRung{{R: 1, ClosePct: 0.5}, {R: 2, ClosePct: 0.3}}, "long", 100, 95),
+	}
+	e.posState[posKey("acc", "BTC-USD")] = ps
+	ctx := context.Background()
+
+	if e.takeLadderProfit(ctx, ps, 104) {
+		t.Fatal("no rung should fire below 1R")
+	}
+	if !e.takeLadderProfit(ctx, ps, 105) {
+		t.Fatal("1R rung should fire")
+	}
+	if !e.takeLadderProfit(ctx, ps, 111) {
+		t.Fatal("2R rung should fire")
+	}
+	if e.takeLadderProfit(ctx, ps, 130) {
+		t.Fatal("remainder is left to the trailing stop")
+	}
+
+	if len(store.trades) != 2 {
+		t.Fatalf("want 2 close trades, got %d", len(store.trades))
+	}
+	assertFloat(t, "rung 1 qty", 5, store.trades[0].Quantity)
+	assertFloat(t, "rung 2 qty", 3, store.trades[1].Quantity)
+	if !strings.HasPrefix(*store.trades[0].ExitReason, "Take-profit ladder: rung 1/2 (1R)") {
+		t.Errorf("exit reason: got %q", *store.trades[0].ExitReason)
+	}
+
+	last := store.updated[len(store.updated)-1]
+	assertFloat(t, "persisted qty", 2, last.Quantity)
+	if !last.Ladder[0].Filled || !last.Ladder[1].Filled {
+		t.Errorf("persisted ladder progress: %+v", last.Ladder)
+	}
+	if ps.Closing {
+		t.Error("closing guard should be released after a partial rung")
+	}
+}
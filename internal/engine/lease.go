@@ -0,0 +1,313 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/google/uuid"
+)
+
+// LeaseStore holds named, expiring leases used for leader election between
+// engine instances of the same tenant.
+type LeaseStore interface {
+	// Acquire takes the lease for holder, or renews it if holder already owns
+	// it, for ttl from now. Returns false when another holder's lease has not
+	// expired yet.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// Release gives up the lease if holder owns it, so a follower can take
+	// over without waiting for it to expire.
+	Release(ctx context.Context, name, holder string) error
+}
+
+// --- MemoryLeaseStore ---
+
+type memoryLease struct {
+	holder  string
+	expires time.Time
+}
+
+// MemoryLeaseStore is an in-process LeaseStore. It only coordinates engines
+// that share it, so it is meant for tests and single-process setups.
+type MemoryLeaseStore struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+	now    func() time.Time
+}
+
+// NewMemoryLeaseStore creates an empty MemoryLeaseStore.
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{leases: make(map[string]memoryLease), now: time.Now}
+}
+
+func (s *MemoryLeaseStore) Acquire(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if l, ok := s.leases[name]; ok && l.holder != holder && now.Before(l.expires) {
+		return false, nil
+	}
+	s.leases[name] = memoryLease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryLeaseStore) Release(_ context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.leases[name]; ok && l.holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
+
+// --- FirestoreLeaseStore ---
+
+// FirestoreLeaseStore keeps leases as Firestore documents at
+// engine-leases/{name}, read and written in a transaction so two instances
+// cannot both take an expired lease.
+type FirestoreLeaseStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreLeaseStore creates a FirestoreLeaseStore.
+func NewFirestoreLeaseStore(client *firestore.Client) *FirestoreLeaseStore {
+	return &FirestoreLeaseStore{client: client}
+}
+
+func (s *FirestoreLeaseStore) ref(name string) *firestore.DocumentRef {
+	return s.client.Collection("engine-leases").Doc(name)
+}
+
+func (s *FirestoreLeaseStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	ref := s.ref(name)
+	var acquired bool
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		acquired = false
+		now := time.Now().UTC()
+		doc, err := tx.Get(ref)
+		if err != nil && !isFirestoreNotFound(err) {
+			return err
+		}
+		if err == nil {
+			data := doc.Data()
+			current, _ := data["holder"].(string)
+			expires, _ := data["expires_at"].(time.Time)
+			if current != holder && now.Before(expires) {
+				return nil
+			}
+		}
+		acquired = true
+		return tx.Set(ref, map[string]interface{}{
+			"holder":     holder,
+			"expires_at": now.Add(ttl),
+			"renewed_at": now,
+		})
+	})
+	if err != nil {
+		return false, fmt.Errorf("acquire lease %s: %w", name, err)
+	}
+	return acquired, nil
+}
+
+func (s *FirestoreLeaseStore) Release(ctx context.Context, name, holder string) error {
+	ref := s.ref(name)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			if isFirestoreNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if current, _ := doc.Data()["holder"].(string); current != holder {
+			return nil
+		}
+		return tx.Delete(ref)
+	})
+	if err != nil {
+		return fmt.Errorf("release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// --- leader election ---
+
+// SetLeaseStore enables leader election: only the instance holding the
+// tenant's lease runs the signal, risk and reconcile loops. Must be called
+// before Start.
+func (e *Engine) SetLeaseStore(store LeaseStore) {
+	e.leases = store
+}
+
+// IsLeader reports whether this instance is currently trading. Always true
+// when leader election is disabled and the engine is running.
+func (e *Engine) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// leaseName is the lease the engine campaigns for. A shadow engine has its
+// own, so it never displaces the trading leader.
+func (e *Engine) leaseName() string {
+	if e.cfg.TradingMode == "shadow" {
+		return "shadow-" + e.tenantUUID.String()
+	}
+	return e.tenantUUID.String()
+}
+
+// leaseHolderID identifies this instance in the lease document.
+func leaseHolderID() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "traderd"
+	}
+	return host + "-" + uuid.NewString()[:8]
+}
+
+// runTrading runs the loops that act on the market. It blocks until ctx is
+// cancelled.
+func (e *Engine) runTrading(ctx context.Context) {
+	e.leading.Store(true)
+	defer e.leading.Store(false)
+
+	// Signals, pending-entry checks and risk exits for one position are
+	// serialized through its queue.
+	e.queues.Store(newSymbolQueues(ctx, e.cfg.SignalQueueDepth, e.logger))
+	defer e.queues.Store(nil)
+
+	// Resting entries are handed to the next leader on step-down.
+	defer e.releasePendingEntries()
+
+	// Start risk loop goroutine.
+	go e.startRiskLoop(ctx)
+
+	// Trip circuit breakers on drawdown, weekly loss and losing streaks.
+	if e.breakersEnabled() {
+		go e.startBreakerMonitor(ctx)
+	}
+
+	// Restore operator pauses before taking signals, then pick up pauses set
+	// through other replicas.
+	if err := e.refreshPauses(ctx); err != nil {
+		e.logger.Error().Err(err).Msg("failed to load operator pauses — retrying in the background")
+	}
+	go e.startPauseWatcher(ctx)
+
+	// Keep Kelly sizing statistics current.
+	if e.cfg.KellyRefreshInterval > 0 {
+		go e.startKellyRefresher(ctx)
+	}
+
+	// Start the exchange ↔ ledger reconciler (live mode only).
+	if e.cfg.TradingMode == "live" && e.cfg.ReconcileInterval > 0 {
+		go e.startReconciler(ctx)
+	}
+
+	// Connect to NGS and run signal loop (blocks until ctx cancelled).
+	e.runSignalLoop(ctx)
+}
+
+// runElection campaigns for the tenant's lease, renewing it every third of
+// LEADER_LEASE_TTL. On winning, position state is reloaded — the previous
+// leader may have traded — and run (runTrading) starts. The instance steps down
+// when another holder owns the lease or when renewals have failed for long
+// enough that the lease may already have expired. Blocks until ctx is
+// cancelled, then releases the lease.
+func (e *Engine) runElection(ctx context.Context, run func(context.Context)) {
+	name := e.leaseName()
+	holder := leaseHolderID()
+	ttl := e.cfg.LeaderLeaseTTL
+	renew := ttl / 3
+	logger := e.logger.With().Str("lease", name).Str("holder", holder).Logger()
+
+	var (
+		stop      context.CancelFunc
+		done      chan struct{}
+		lastRenew time.Time
+	)
+	stepDown := func(reason string) {
+		stop()
+		<-done
+		stop, done = nil, nil
+		logger.Warn().Str("reason", reason).Msg("stepped down as engine leader — trading stopped")
+	}
+
+	ticker := time.NewTicker(renew)
+	defer ticker.Stop()
+
+	logger.Info().Dur("ttl", ttl).Msg("leader election enabled — waiting for lease")
+	for {
+		ok, err := e.leases.Acquire(ctx, name, holder, ttl)
+		now := time.Now()
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				break
+			}
+			logger.Warn().Err(err).Msg("failed to renew engine lease")
+			if stop != nil && now.Sub(lastRenew) >= ttl-renew {
+				stepDown("lease renewal failing")
+			}
+		case ok:
+			lastRenew = now
+			if stop != nil {
+				break
+			}
+			if err := e.reloadState(ctx); err != nil {
+				logger.Error().Err(err).Msg("won engine lease but failed to reload state — releasing")
+				if err := e.leases.Release(ctx, name, holder); err != nil {
+					logger.Warn().Err(err).Msg("failed to release engine lease")
+				}
+				break
+			}
+			var leaderCtx context.Context
+			leaderCtx, stop = context.WithCancel(ctx)
+			done = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				run(leaderCtx)
+			}(done)
+			logger.Info().Msg("acquired engine lease — this instance is now trading")
+		default:
+			if stop != nil {
+				stepDown("lease held by another instance")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if stop != nil {
+				stop()
+				<-done
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := e.leases.Release(releaseCtx, name, holder); err != nil {
+					logger.Warn().Err(err).Msg("failed to release engine lease")
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reloadState replaces the in-memory conflict guard, position state and
+// resting entries with a fresh load from the ledger and the engine store.
+func (e *Engine) reloadState(ctx context.Context) error {
+	e.conflictMu.Lock()
+	e.conflict = make(map[string]string)
+	e.conflictMu.Unlock()
+	e.posStateMu.Lock()
+	e.posState = make(map[string]*PositionState)
+	e.posStateMu.Unlock()
+	e.breakerMu.Lock()
+	e.breakers = make(map[breakerKey]*BreakerState)
+	e.breakerMu.Unlock()
+	e.pendingMu.Lock()
+	e.pending = make(map[string]*pendingEntry)
+	e.pendingMu.Unlock()
+	return e.loadStartupState(ctx)
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+)
+
+func TestMemoryLeaseStore_ExpiryAndRelease(t *testing.T) {
+	store := NewMemoryLeaseStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if ok, _ := store.Acquire(ctx, "tenant", "a", 10*time.Second); !ok {
+		t.Fatal("a should acquire a free lease")
+	}
+	if ok, _ := store.Acquire(ctx, "tenant", "b", 10*time.Second); ok {
+		t.Fatal("b must not take a live lease")
+	}
+	if ok, _ := store.Acquire(ctx, "tenant", "a", 10*time.Second); !ok {
+		t.Fatal("a should renew its own lease")
+	}
+
+	now = now.Add(11 * time.Second)
+	if ok, _ := store.Acquire(ctx, "tenant", "b", 10*time.Second); !ok {
+		t.Fatal("b should take an expired lease")
+	}
+
+	_ = store.Release(ctx, "tenant", "a")
+	if ok, _ := store.Acquire(ctx, "tenant", "a", 10*time.Second); ok {
+		t.Fatal("release by a non-holder must not free the lease")
+	}
+	_ = store.Release(ctx, "tenant", "b")
+	if ok, _ := store.Acquire(ctx, "tenant", "a", 10*time.Second); !ok {
+		t.Fatal("a should acquire after b released")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunElection_FollowerTakesOver(t *testing.T) {
+	store := NewMemoryLeaseStore()
+	tenant := uuid.New()
+	newEngine := func() *Engine {
+		e := makeEngine(&config.Config{LeaderLeaseTTL: 60 * time.Millisecond})
+		e.leases = store
+		e.tenantUUID = tenant
+		return e
+	}
+	var trading [2]atomic.Bool
+	run := func(i int) func(context.Context) {
+		return func(ctx context.Context) {
+			trading[i].Store(true)
+			<-ctx.Done()
+			trading[i].Store(false)
+		}
+	}
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() { newEngine().runElection(ctxA, run(0)); close(doneA) }()
+	waitFor(t, "first instance to lead", trading[0].Load)
+
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+	go newEngine().runElection(ctxB, run(1))
+	time.Sleep(150 * time.Millisecond)
+	if trading[1].Load() {
+		t.Fatal("follower must not trade while the leader holds the lease")
+	}
+
+	stopA()
+	<-doneA
+	if trading[0].Load() {
+		t.Fatal("old leader still trading after shutdown")
+	}
+	waitFor(t, "follower to take over", trading[1].Load)
+}
@@ -0,0 +1,363 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+	"github.com/Signal-ngn/trader/internal/platform"
+)
+
+const (
+	outboxPollInterval = 5 * time.Second
+	outboxFirstRetry   = 30 * time.Second // leaves the inline submit time to finish
+	outboxMaxBackoff   = 5 * time.Minute
+)
+
+// outboxEntry is one trade awaiting acknowledgement by the platform API.
+type outboxEntry struct {
+	TenantID    uuid.UUID    `json:"tenant_id"`
+	Trade       domain.Trade `json:"trade"`
+	QueuedAt    time.Time    `json:"queued_at"`
+	Attempts    int          `json:"attempts"`
+	NextAttempt time.Time    `json:"next_attempt"`
+	LastError   string       `json:"last_error,omitempty"`
+}
+
+// Outbox is a durable, file-backed queue of ledger trades. Each trade is one
+// JSON file named after its trade ID, written via a temp file and rename so a
+// crash never leaves a partial entry. The platform API deduplicates on trade
+// ID, so an entry may safely be submitted more than once. Entries the platform
+// rejects outright are moved to the dead/ subdirectory and not retried.
+type Outbox struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// OpenOutbox opens (creating if needed) the outbox directory.
+func OpenOutbox(dir string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create outbox dir: %w", err)
+	}
+	return &Outbox{dir: dir}, nil
+}
+
+// path returns the file for a trade ID.
+func (o *Outbox) path(tradeID string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, tradeID)
+	return filepath.Join(o.dir, name+".json")
+}
+
+// Put writes or replaces the entry for its trade and syncs it to disk.
+func (o *Outbox) Put(entry *outboxEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal outbox entry: %w", err)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	f, err := os.CreateTemp(o.dir, ".pending-*")
+	if err != nil {
+		return fmt.Errorf("write outbox entry: %w", err)
+	}
+	tmp := f.Name()
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, o.path(entry.Trade.TradeID))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write outbox entry: %w", err)
+	}
+	return nil
+}
+
+// deadDir is the subdirectory holding dead-lettered entries.
+func (o *Outbox) deadDir() string {
+	return filepath.Join(o.dir, "dead")
+}
+
+// DeadLetter moves the entry for tradeID out of the queue into dead/, where
+// it is kept for manual recovery.
+func (o *Outbox) DeadLetter(tradeID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := os.MkdirAll(o.deadDir(), 0o700); err != nil {
+		return fmt.Errorf("dead-letter outbox entry: %w", err)
+	}
+	src := o.path(tradeID)
+	if err := os.Rename(src, filepath.Join(o.deadDir(), filepath.Base(src))); err != nil {
+		return fmt.Errorf("dead-letter outbox entry: %w", err)
+	}
+	return nil
+}
+
+// DeadLetters returns the number of dead-lettered entries.
+func (o *Outbox) DeadLetters() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	files, _ := filepath.Glob(filepath.Join(o.deadDir(), "*.json"))
+	return len(files)
+}
+
+// Remove deletes the entry for tradeID. A missing entry is not an error.
+func (o *Outbox) Remove(tradeID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := os.Remove(o.path(tradeID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove outbox entry: %w", err)
+	}
+	return nil
+}
+
+// List returns all queued entries, oldest first. Unreadable files are skipped
+// and reported in the error alongside the entries that could be read.
+func (o *Outbox) List() ([]*outboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	files, err := filepath.Glob(filepath.Join(o.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []*outboxEntry
+	var bad []string
+	for _, name := range files {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			bad = append(bad, filepath.Base(name))
+			continue
+		}
+		var entry outboxEntry
+		if err := json.Unmarshal(b, &entry); err != nil {
+			bad = append(bad, filepath.Base(name))
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].QueuedAt.Before(entries[j].QueuedAt) })
+	if len(bad) > 0 {
+		return entries, fmt.Errorf("unreadable outbox entries: %s", strings.Join(bad, ", "))
+	}
+	return entries, nil
+}
+
+// Depth returns the number of queued entries.
+func (o *Outbox) Depth() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	files, _ := filepath.Glob(filepath.Join(o.dir, "*.json"))
+	return len(files)
+}
+
+// outboxBackoff returns the retry delay after the given number of failed
+// attempts: 30s doubling up to 5 minutes.
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxFirstRetry
+	for i := 1; i < attempts && d < outboxMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > outboxMaxBackoff {
+		d = outboxMaxBackoff
+	}
+	return d
+}
+
+// permanentTradeError reports whether the platform rejected a trade in a way
+// a retry cannot fix: a 4xx other than a timeout or rate limit. Auth failures
+// are retried too, since they clear once the API key is fixed.
+func permanentTradeError(err error) bool {
+	var apiErr *platform.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode < 400 || apiErr.StatusCode >= 500 {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return true
+}
+
+// deadLetter moves a rejected trade out of the outbox and logs it for manual
+// recovery.
+func (e *Engine) deadLetter(trade *domain.Trade, err error) {
+	logger := e.logger.With().
+		Str("trade_id", trade.TradeID).
+		Str("account", trade.AccountID).
+		Str("symbol", trade.Symbol).
+		Logger()
+	if derr := e.outbox.DeadLetter(trade.TradeID); derr != nil {
+		logger.Error().Err(derr).Msg("outbox: failed to dead-letter rejected trade")
+	}
+	logger.Error().Err(err).Msg("outbox: platform rejected trade — moved to dead letters, not retried")
+}
+
+// OutboxDepth returns the number of trades waiting to be accepted by the
+// platform API; 0 when no outbox is configured.
+func (e *Engine) OutboxDepth() int {
+	if e.outbox == nil {
+		return 0
+	}
+	return e.outbox.Depth()
+}
+
+// OutboxDeadLetters returns the number of trades the platform rejected; 0
+// when no outbox is configured.
+func (e *Engine) OutboxDeadLetters() int {
+	if e.outbox == nil {
+		return 0
+	}
+	return e.outbox.DeadLetters()
+}
+
+// outboxSymbols returns the account+symbol keys, by Binance symbol, with
+// trades still queued in the outbox.
+func (e *Engine) outboxSymbols() map[string]bool {
+	out := make(map[string]bool)
+	if e.outbox == nil {
+		return out
+	}
+	entries, err := e.outbox.List()
+	if err != nil {
+		e.logger.Warn().Err(err).Msg("outbox: failed to read some entries")
+	}
+	for _, entry := range entries {
+		out[posKey(entry.Trade.AccountID, binanceSymbol(entry.Trade.Symbol))] = true
+	}
+	return out
+}
+
+// submitTrade writes a trade to the ledger. With an outbox configured the
+// trade is made durable first; if the platform write then fails the trade
+// stays queued for the outbox worker and is reported as recorded, unless the
+// platform rejected it outright. Returns the (inserted, err) contract of
+// EngineStore.InsertTradeAndUpdatePosition.
+func (e *Engine) submitTrade(ctx context.Context, trade *domain.Trade) (bool, error) {
+	tenantID := e.tenantID()
+	if e.outbox == nil {
+		return e.repo.InsertTradeAndUpdatePosition(ctx, tenantID, trade)
+	}
+
+	now := time.Now().UTC()
+	entry := &outboxEntry{
+		TenantID:    tenantID,
+		Trade:       *trade,
+		QueuedAt:    now,
+		NextAttempt: now.Add(outboxFirstRetry),
+	}
+	if err := e.outbox.Put(entry); err != nil {
+		e.logger.Error().Err(err).Str("trade_id", trade.TradeID).Msg("failed to write trade to outbox — submitting without it")
+		return e.repo.InsertTradeAndUpdatePosition(ctx, tenantID, trade)
+	}
+
+	inserted, err := e.repo.InsertTradeAndUpdatePosition(ctx, tenantID, trade)
+	if err != nil && permanentTradeError(err) {
+		e.deadLetter(trade, err)
+		return false, err
+	}
+	if err != nil {
+		e.logger.Warn().Err(err).
+			Str("trade_id", trade.TradeID).
+			Str("account", trade.AccountID).
+			Str("symbol", trade.Symbol).
+			Msg("ledger write failed — trade queued in outbox for retry")
+		return true, nil
+	}
+	if err := e.outbox.Remove(trade.TradeID); err != nil {
+		e.logger.Warn().Err(err).Str("trade_id", trade.TradeID).Msg("failed to remove acknowledged trade from outbox")
+	}
+	return inserted, nil
+}
+
+// startOutboxWorker retries queued trades until the platform accepts them.
+// Entries left over from a previous run are picked up on the first pass.
+func (e *Engine) startOutboxWorker(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.drainOutbox(ctx, time.Now().UTC())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drainOutbox submits every entry due at now. Acknowledged entries (including
+// duplicates) are removed, rejected ones dead-lettered and other failures
+// rescheduled with backoff.
+func (e *Engine) drainOutbox(ctx context.Context, now time.Time) {
+	entries, err := e.outbox.List()
+	if err != nil {
+		e.logger.Error().Err(err).Msg("outbox: failed to read some entries")
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if now.Before(entry.NextAttempt) {
+			continue
+		}
+		logger := e.logger.With().
+			Str("trade_id", entry.Trade.TradeID).
+			Str("account", entry.Trade.AccountID).
+			Str("symbol", entry.Trade.Symbol).
+			Logger()
+
+		trade := entry.Trade
+		inserted, err := e.repo.InsertTradeAndUpdatePosition(ctx, entry.TenantID, &trade)
+		if err != nil && permanentTradeError(err) {
+			e.deadLetter(&trade, err)
+			continue
+		}
+		if err != nil {
+			entry.Attempts++
+			entry.LastError = err.Error()
+			entry.NextAttempt = now.Add(outboxBackoff(entry.Attempts))
+			if perr := e.outbox.Put(entry); perr != nil {
+				logger.Error().Err(perr).Msg("outbox: failed to reschedule entry")
+			}
+			logger.Warn().Err(err).
+				Int("attempts", entry.Attempts).
+				Time("next_attempt", entry.NextAttempt).
+				Dur("queued_for", now.Sub(entry.QueuedAt)).
+				Msg("outbox: ledger write still failing")
+			continue
+		}
+		if err := e.outbox.Remove(trade.TradeID); err != nil {
+			logger.Error().Err(err).Msg("outbox: failed to remove acknowledged entry")
+			continue
+		}
+		logger.Info().
+			Bool("duplicate", !inserted).
+			Int("attempts", entry.Attempts+1).
+			Dur("queued_for", now.Sub(entry.QueuedAt)).
+			Msg("outbox: trade recorded in ledger")
+	}
+}
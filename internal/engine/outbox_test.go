@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+	"github.com/Signal-ngn/trader/internal/platform"
+)
+
+// flakyStore is an EngineStore whose trade writes fail while err is set.
+type flakyStore struct {
+	EngineStore
+	err    error
+	dup    bool
+	trades []*domain.Trade
+}
+
+func (s *flakyStore) InsertTradeAndUpdatePosition(_ context.Context, _ uuid.UUID, trade *domain.Trade) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	s.trades = append(s.trades, trade)
+	return !s.dup, nil
+}
+
+func outboxEngine(t *testing.T, store EngineStore) *Engine {
+	t.Helper()
+	ob, err := OpenOutbox(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := makeEngine(&config.Config{TradingMode: "live"})
+	e.repo = store
+	e.outbox = ob
+	return e
+}
+
+func TestOutbox_PutListRemove(t *testing.T) {
+	ob, err := OpenOutbox(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for i, id := range []string{"engine-b:2", "engine-a/1"} {
+		if err := ob.Put(&outboxEntry{Trade: domain.Trade{TradeID: id}, QueuedAt: now.Add(-time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("put %s: %v", id, err)
+		}
+	}
+	entries, err := ob.List()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("list: got %d entries, err=%v", len(entries), err)
+	}
+	assertEq(t, "oldest first", "engine-a/1", entries[0].Trade.TradeID)
+
+	if err := ob.Remove("engine-a/1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ob.Remove("missing"); err != nil {
+		t.Errorf("removing a missing entry should not fail: %v", err)
+	}
+	if d := ob.Depth(); d != 1 {
+		t.Errorf("depth: want 1, got %d", d)
+	}
+}
+
+func TestSubmitTrade_QueuesOnFailureAndWorkerRetries(t *testing.T) {
+	store := &flakyStore{err: errors.New("platform unavailable")}
+	e := outboxEngine(t, store)
+	ctx := context.Background()
+
+	inserted, err := e.submitTrade(ctx, &domain.Trade{TradeID: "engine-acc-BTC-USD-1", AccountID: "acc", Symbol: "BTC-USD"})
+	if err != nil || !inserted {
+		t.Fatalf("queued trade should count as recorded: inserted=%v err=%v", inserted, err)
+	}
+	if d := e.OutboxDepth(); d != 1 {
+		t.Fatalf("depth: want 1, got %d", d)
+	}
+
+	// Not yet due — the worker leaves it alone.
+	e.drainOutbox(ctx, time.Now().UTC())
+	if d := e.OutboxDepth(); d != 1 {
+		t.Fatalf("entry retried before its backoff elapsed")
+	}
+
+	// Due but still failing — rescheduled with backoff.
+	due := time.Now().UTC().Add(outboxFirstRetry)
+	e.drainOutbox(ctx, due)
+	entries, _ := e.outbox.List()
+	if len(entries) != 1 || entries[0].Attempts != 1 || entries[0].LastError == "" {
+		t.Fatalf("want one rescheduled entry, got %+v", entries)
+	}
+	if !entries[0].NextAttempt.Equal(due.Add(outboxBackoff(1))) {
+		t.Errorf("next attempt: got %s", entries[0].NextAttempt)
+	}
+
+	// Platform recovers; a duplicate (409) also counts as acknowledged.
+	store.err = nil
+	store.dup = true
+	e.drainOutbox(ctx, due.Add(time.Hour))
+	if d := e.OutboxDepth(); d != 0 {
+		t.Errorf("depth after ack: want 0, got %d", d)
+	}
+	assertEq(t, "retried trade", "engine-acc-BTC-USD-1", store.trades[0].TradeID)
+}
+
+func TestSubmitTrade_RemovesEntryOnSuccess(t *testing.T) {
+	store := &flakyStore{}
+	e := outboxEngine(t, store)
+
+	if _, err := e.submitTrade(context.Background(), &domain.Trade{TradeID: "t1"}); err != nil {
+		t.Fatal(err)
+	}
+	if d := e.OutboxDepth(); d != 0 {
+		t.Errorf("depth: want 0, got %d", d)
+	}
+}
+
+func TestSubmitTrade_DeadLettersRejectedTrades(t *testing.T) {
+	store := &flakyStore{err: &platform.APIError{StatusCode: 422, Body: "invalid quantity"}}
+	e := outboxEngine(t, store)
+	ctx := context.Background()
+
+	if _, err := e.submitTrade(ctx, &domain.Trade{TradeID: "t1"}); err == nil {
+		t.Fatal("a rejected trade should not count as recorded")
+	}
+	if d := e.OutboxDepth(); d != 0 || e.OutboxDeadLetters() != 1 {
+		t.Fatalf("want the trade dead-lettered, got depth %d and %d dead letters", d, e.OutboxDeadLetters())
+	}
+
+	// A queued trade the platform later rejects is dead-lettered too.
+	store.err = errors.New("platform unavailable")
+	if _, err := e.submitTrade(ctx, &domain.Trade{TradeID: "t2"}); err != nil {
+		t.Fatal(err)
+	}
+	store.err = &platform.APIError{StatusCode: 400, Body: "bad request"}
+	e.drainOutbox(ctx, time.Now().UTC().Add(time.Hour))
+	if d := e.OutboxDepth(); d != 0 || e.OutboxDeadLetters() != 2 {
+		t.Errorf("want both trades dead-lettered, got depth %d and %d dead letters", d, e.OutboxDeadLetters())
+	}
+}
+
+func TestPermanentTradeError(t *testing.T) {
+	for status, want := range map[int]bool{400: true, 422: true, 401: false, 429: false, 500: false, 503: false} {
+		if got := permanentTradeError(&platform.APIError{StatusCode: status}); got != want {
+			t.Errorf("%d: want %v, got %v", status, want, got)
+		}
+	}
+	if permanentTradeError(errors.New("connection refused")) {
+		t.Error("network errors are retried")
+	}
+}
+
+func TestOutboxBackoff_Capped(t *testing.T) {
+	if got := outboxBackoff(1); got != outboxFirstRetry {
+		t.Errorf("first retry: got %s", got)
+	}
+	if got := outboxBackoff(2); got != 2*outboxFirstRetry {
+		t.Errorf("second retry: got %s", got)
+	}
+	if got := outboxBackoff(50); got != outboxMaxBackoff {
+		t.Errorf("cap: got %s", got)
+	}
+}
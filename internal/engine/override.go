@@ -0,0 +1,279 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Signal-ngn/risk"
+)
+
+var (
+	// ErrPositionNotFound is returned when the engine holds no open position
+	// for the account and symbol.
+	ErrPositionNotFound = errors.New("no engine-managed position for this account and symbol")
+
+	// ErrPositionClosing is returned when a close is already in flight for
+	// the position.
+	ErrPositionClosing = errors.New("a close is already in flight for this position")
+
+	// ErrInvalidOverride is returned for a level the position cannot take.
+	ErrInvalidOverride = errors.New("invalid position override")
+)
+
+// positionLevel names an exit level an operator can override.
+type positionLevel string
+
+const (
+	levelStopLoss     positionLevel = "stop loss"
+	levelTakeProfit   positionLevel = "take profit"
+	levelTrailingStop positionLevel = "trailing stop"
+)
+
+// SetStopLoss moves the stop loss of an engine-managed position.
+func (e *Engine) SetStopLoss(ctx context.Context, accountID, symbol string, price float64) (PositionStatus, error) {
+	return e.overrideLevel(ctx, accountID, symbol, levelStopLoss, price)
+}
+
+// SetTakeProfit moves the take-profit of an engine-managed position. Not
+// available for ML strategies, which exit via the trailing stop, or for
+// positions on a take-profit ladder.
+func (e *Engine) SetTakeProfit(ctx context.Context, accountID, symbol string, price float64) (PositionStatus, error) {
+	return e.overrideLevel(ctx, accountID, symbol, levelTakeProfit, price)
+}
+
+// SetTrailingStop moves the trailing stop of an engine-managed ML-strategy
+// position. The risk loop still only tightens it from there.
+func (e *Engine) SetTrailingStop(ctx context.Context, accountID, symbol string, price float64) (PositionStatus, error) {
+	return e.overrideLevel(ctx, accountID, symbol, levelTrailingStop, price)
+}
+
+// ClosePosition closes an engine-managed position at market, recording
+// reason as the exit reason.
+func (e *Engine) ClosePosition(ctx context.Context, accountID, symbol, reason string) error {
+	if !e.IsLeader() {
+		return ErrNotLeader
+	}
+	if err := e.checkAccount(accountID); err != nil {
+		return err
+	}
+	if reason == "" {
+		reason = "Manual close: operator request"
+	}
+
+	err := ctx.Err()
+	e.serialize(ctx, posKey(accountID, symbol), func() {
+		err = e.closeManually(ctx, accountID, symbol, reason)
+	})
+	return err
+}
+
+// closeManually runs a manual close on the position's queue.
+func (e *Engine) closeManually(ctx context.Context, accountID, symbol, reason string) error {
+	key := posKey(accountID, symbol)
+	e.posStateMu.RLock()
+	_, exists := e.posState[key]
+	e.posStateMu.RUnlock()
+	if !exists {
+		return ErrPositionNotFound
+	}
+
+	// Live closes fill at the exchange price; paper closes need a mark.
+	price, err := e.markPrice(ctx, symbol)
+	if err != nil && e.cfg.TradingMode != "live" {
+		return fmt.Errorf("price %s: %w", symbol, err)
+	}
+
+	e.posStateMu.Lock()
+	ps, exists := e.posState[key]
+	if !exists {
+		e.posStateMu.Unlock()
+		return ErrPositionNotFound
+	}
+	if ps.Closing {
+		e.posStateMu.Unlock()
+		return ErrPositionClosing
+	}
+	ps.Closing = true
+	e.posStateMu.Unlock()
+
+	e.logger.Warn().Str("account", accountID).Str("symbol", symbol).Str("exit_reason", reason).
+		Msg("closing position by operator request")
+	if e.executeCloseTrade(ctx, ps, price, reason) {
+		return nil
+	}
+
+	// Hand the position back to the risk loop.
+	e.posStateMu.Lock()
+	ps.Closing = false
+	e.posStateMu.Unlock()
+	return errors.New("close failed — see the engine log")
+}
+
+// overrideLevel validates and applies an operator override on the position's
+// queue, so it cannot interleave with a risk-loop tick or a signal.
+func (e *Engine) overrideLevel(ctx context.Context, accountID, symbol string, lvl positionLevel, price float64) (PositionStatus, error) {
+	if !e.IsLeader() {
+		return PositionStatus{}, ErrNotLeader
+	}
+	if err := e.checkAccount(accountID); err != nil {
+		return PositionStatus{}, err
+	}
+	if !(price > 0) || math.IsInf(price, 0) {
+		return PositionStatus{}, fmt.Errorf("%w: %s must be a positive price", ErrInvalidOverride, lvl)
+	}
+
+	var out PositionStatus
+	err := ctx.Err()
+	e.serialize(ctx, posKey(accountID, symbol), func() {
+		out, err = e.applyLevel(ctx, accountID, symbol, lvl, price)
+	})
+	return out, err
+}
+
+// applyLevel sets lvl on the position, moves the matching exchange-side order
+// when the level it protects changed, persists the state and, for stops,
+// publishes a stop_moved event.
+func (e *Engine) applyLevel(ctx context.Context, accountID, symbol string, lvl positionLevel, price float64) (PositionStatus, error) {
+	key := posKey(accountID, symbol)
+	e.posStateMu.RLock()
+	_, exists := e.posState[key]
+	e.posStateMu.RUnlock()
+	if !exists {
+		return PositionStatus{}, ErrPositionNotFound
+	}
+
+	// A stop on the wrong side of the market would fire on the next tick.
+	mark, err := e.markPrice(ctx, symbol)
+	if err != nil {
+		e.logger.Warn().Err(err).Str("symbol", symbol).Msg("override: no mark price — skipping side check")
+	}
+
+	e.posStateMu.Lock()
+	ps, exists := e.posState[key]
+	if !exists {
+		e.posStateMu.Unlock()
+		return PositionStatus{}, ErrPositionNotFound
+	}
+	if ps.Closing {
+		e.posStateMu.Unlock()
+		return PositionStatus{}, ErrPositionClosing
+	}
+	if err := checkLevel(ps, lvl, price, mark); err != nil {
+		e.posStateMu.Unlock()
+		return PositionStatus{}, err
+	}
+	oldStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, ps.TrailingStop)
+	var old float64
+	switch lvl {
+	case levelStopLoss:
+		old = ps.StopLoss
+		// A stop at or past break-even makes the break-even move moot; keep
+		// the entry stop so the trailing distance is unchanged.
+		if ps.BreakEvenR > 0 && !ps.BreakEven && !stopLooser(ps.Side, price, breakEvenPrice(ps)) {
+			ps.EntryStopLoss = ps.StopLoss
+			ps.BreakEven = true
+		}
+		ps.StopLoss = price
+	case levelTakeProfit:
+		old = ps.TakeProfit
+		ps.TakeProfit = price
+	case levelTrailingStop:
+		old = ps.TrailingStop
+		ps.TrailingStop = price
+	}
+	newStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, ps.TrailingStop)
+	e.posStateMu.Unlock()
+
+	switch {
+	case lvl == levelTakeProfit:
+		id := e.replaceProtectiveTakeProfit(ctx, ps, price)
+		e.posStateMu.Lock()
+		ps.TakeProfitOrderID = id
+		e.posStateMu.Unlock()
+	case newStop != oldStop:
+		id := e.replaceProtectiveStop(ctx, ps, newStop)
+		e.posStateMu.Lock()
+		ps.StopOrderID = id
+		e.posStateMu.Unlock()
+	}
+
+	e.posStateMu.RLock()
+	rec := ps.record()
+	out := statusOf(ps)
+	e.posStateMu.RUnlock()
+	if err := e.repo.UpdatePositionState(ctx, e.tenantID(), rec); err != nil {
+		e.logger.Warn().Err(err).Str("account", accountID).Str("symbol", symbol).
+			Msg("failed to persist position override — applied in memory only")
+	}
+
+	e.logger.Info().
+		Str("account", accountID).
+		Str("symbol", symbol).
+		Str("level", string(lvl)).
+		Float64("old", old).
+		Float64("new", price).
+		Msg("position level overridden by operator")
+
+	if lvl == levelStopLoss && e.publisher != nil {
+		e.publisher.Publish(accountID, StopMovedEvent{
+			Event:      "stop_moved",
+			AccountID:  accountID,
+			Symbol:     symbol,
+			Side:       out.Side,
+			EntryPrice: out.EntryPrice,
+			OldStop:    old,
+			NewStop:    price,
+			Price:      mark,
+			Reason:     "operator override",
+			Timestamp:  time.Now().UTC(),
+		})
+	}
+
+	if mark > 0 {
+		out.MarkPrice = mark
+		out.UnrealizedPnL = unrealizedPnLAt(out.Side, out.EntryPrice, out.Quantity, mark)
+	}
+	return out, nil
+}
+
+// checkLevel rejects an override the position cannot use, or — when mark is
+// known — one already crossed by the market.
+func checkLevel(ps *PositionState, lvl positionLevel, price, mark float64) error {
+	switch lvl {
+	case levelTakeProfit:
+		if risk.IsMLStrategy(ps.Strategy) {
+			return fmt.Errorf("%w: ML strategy %s exits via the trailing stop, not a take-profit", ErrInvalidOverride, ps.Strategy)
+		}
+		if len(ps.Ladder) > 0 {
+			return fmt.Errorf("%w: position exits on a take-profit ladder", ErrInvalidOverride)
+		}
+	case levelTrailingStop:
+		if !risk.IsMLStrategy(ps.Strategy) {
+			return fmt.Errorf("%w: trailing stops only apply to ML strategies", ErrInvalidOverride)
+		}
+	}
+	if mark <= 0 {
+		return nil
+	}
+
+	crossed := stopLooser(ps.Side, mark, price) // a stop must stay behind the mark
+	if lvl == levelTakeProfit {
+		crossed = stopLooser(ps.Side, price, mark)
+	}
+	if crossed || price == mark {
+		return fmt.Errorf("%w: %s %s at $%.4f is already crossed at mark $%.4f", ErrInvalidOverride, ps.Side, lvl, price, mark)
+	}
+	return nil
+}
+
+// stopLooser reports whether a stop at a sits further from the market than
+// one at b for side: lower for a long, higher for a short.
+func stopLooser(side string, a, b float64) bool {
+	if side == "short" {
+		return a > b
+	}
+	return a < b
+}
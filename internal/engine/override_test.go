@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+func TestSetStopLoss_ValidatesAndPersists(t *testing.T) {
+	store := &stateStore{}
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = store
+	e.accounts = []string{"acc"}
+	ps := &PositionState{AccountID: "acc", Symbol: "BTC-USD", Side: "long", EntryPrice: 100, StopLoss: 95, BreakEvenR: 1}
+	e.posState[posKey("acc", "BTC-USD")] = ps
+	e.lastPrice["BTC-USD"] = 110
+	ctx := context.Background()
+
+	if _, err := e.SetStopLoss(ctx, "acc", "BTC-USD", 98); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("want ErrNotLeader, got %v", err)
+	}
+	e.leading.Store(true)
+	if _, err := e.SetStopLoss(ctx, "acc", "ETH-USD", 98); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("want ErrPositionNotFound, got %v", err)
+	}
+	if _, err := e.SetStopLoss(ctx, "acc", "BTC-USD", 112); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("long stop above the mark: want ErrInvalidOverride, got %v", err)
+	}
+
+	got, err := e.SetStopLoss(ctx, "acc", "BTC-USD", 98)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "returned stop", 98, got.StopLoss)
+	assertFloat(t, "mark", 110, got.MarkPrice)
+	if len(store.updated) != 1 || store.updated[0].StopLoss != 98 {
+		t.Fatalf("override not persisted: %+v", store.updated)
+	}
+	if ps.BreakEven {
+		t.Error("a stop below entry leaves the break-even move pending")
+	}
+
+	if _, err := e.SetStopLoss(ctx, "acc", "BTC-USD", 104); err != nil {
+		t.Fatal(err)
+	}
+	if !ps.BreakEven || ps.EntryStopLoss != 98 {
+		t.Errorf("a stop past break-even should retire the move and keep the entry stop, got %+v", ps)
+	}
+}
+
+func TestSetTakeProfitAndTrailing_Rules(t *testing.T) {
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = &stateStore{}
+	e.accounts = []string{"acc"}
+	e.leading.Store(true)
+	e.posState[posKey("acc", "ETH-USD")] = &PositionState{AccountID: "acc", Symbol: "ETH-USD", Side: "short", EntryPrice: 2000, Strategy: "trend"}
+	e.posState[posKey("acc", "SOL-USD")] = &PositionState{AccountID: "acc", Symbol: "SOL-USD", Side: "long", EntryPrice: 100, Strategy: "ml_xgboost"}
+	e.lastPrice["ETH-USD"] = 1900
+	e.lastPrice["SOL-USD"] = 120
+	ctx := context.Background()
+
+	if _, err := e.SetTakeProfit(ctx, "acc", "ETH-USD", 1950); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("short take-profit above the mark: want ErrInvalidOverride, got %v", err)
+	}
+	if got, err := e.SetTakeProfit(ctx, "acc", "ETH-USD", 1800); err != nil || got.TakeProfit != 1800 {
+		t.Errorf("short take-profit below the mark: got %+v %v", got, err)
+	}
+	if _, err := e.SetTrailingStop(ctx, "acc", "ETH-USD", 1950); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("non-ML strategy has no trailing stop: got %v", err)
+	}
+
+	if _, err := e.SetTakeProfit(ctx, "acc", "SOL-USD", 130); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("ML strategy has no take-profit: got %v", err)
+	}
+	if got, err := e.SetTrailingStop(ctx, "acc", "SOL-USD", 115); err != nil || got.TrailingStop != 115 {
+		t.Errorf("ML trailing stop: got %+v %v", got, err)
+	}
+}
+
+func TestClosePosition_RecordsOperatorReason(t *testing.T) {
+	store := &ladderStore{position: domain.Position{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot,
+		Side: domain.PositionSideLong, Quantity: 1, AvgEntryPrice: 50000,
+	}}
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	e.accounts = []string{"acc"}
+	e.leading.Store(true)
+	e.posState[posKey("acc", "BTC-USD")] = &PositionState{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: "spot", Side: "long", EntryPrice: 50000, Quantity: 1,
+	}
+	e.lastPrice["BTC-USD"] = 51000
+	ctx := context.Background()
+
+	if err := e.ClosePosition(ctx, "other", "BTC-USD", ""); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("want ErrUnknownAccount, got %v", err)
+	}
+	if err := e.ClosePosition(ctx, "acc", "BTC-USD", "Manual close: news event"); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.trades) != 1 || *store.trades[0].ExitReason != "Manual close: news event" {
+		t.Fatalf("want one close with the operator's reason, got %+v", store.trades)
+	}
+	assertFloat(t, "close price", 51000, store.trades[0].Price)
+	if _, open := e.posState[posKey("acc", "BTC-USD")]; open {
+		t.Error("position state should be gone after the close")
+	}
+	if err := e.ClosePosition(ctx, "acc", "BTC-USD", ""); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("closing twice: want ErrPositionNotFound, got %v", err)
+	}
+}
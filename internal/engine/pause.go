@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// pauseRefreshInterval is how often the leader re-reads operator pauses, so a
+// pause set through another replica takes effect within it.
+const pauseRefreshInterval = 15 * time.Second
+
+var (
+	// ErrUnknownAccount is returned for an account the engine does not manage.
+	ErrUnknownAccount = errors.New("account is not managed by this engine")
+
+	// ErrNotLeader is returned for operations on open positions or circuit
+	// breakers by an instance that does not hold the trading lease and so
+	// does not manage them.
+	ErrNotLeader = errors.New("this instance is not the trading leader")
+)
+
+// PauseState is an operator pause on new positions — the remote counterpart
+// of the kill switch file. An empty AccountID covers every account of the
+// tenant and an empty Strategy every strategy, so the zero scope pauses the
+// whole tenant. Closes and risk exits continue while paused.
+type PauseState struct {
+	AccountID string    `json:"account_id,omitempty"`
+	Strategy  string    `json:"strategy,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	PausedAt  time.Time `json:"paused_at"`
+}
+
+// pauseKey scopes a pause; "" matches any account or strategy.
+type pauseKey struct {
+	accountID string
+	strategy  string
+}
+
+// openPausedBy returns the pause covering new positions for strategy on the
+// account, the widest first, or nil.
+func (e *Engine) openPausedBy(accountID, strategy string) *PauseState {
+	e.pauseMu.RLock()
+	defer e.pauseMu.RUnlock()
+	for _, key := range []pauseKey{{"", ""}, {accountID, ""}, {"", strategy}, {accountID, strategy}} {
+		if p, ok := e.pauses[key]; ok {
+			cp := *p
+			return &cp
+		}
+	}
+	return nil
+}
+
+// refreshPauses replaces the in-memory pauses with the store's.
+func (e *Engine) refreshPauses(ctx context.Context) error {
+	pauses, err := e.repo.LoadPauses(ctx, e.tenantID())
+	if err != nil {
+		return err
+	}
+	m := make(map[pauseKey]*PauseState, len(pauses))
+	for i := range pauses {
+		p := pauses[i]
+		m[pauseKey{p.AccountID, p.Strategy}] = &p
+	}
+	e.pauseMu.Lock()
+	e.pauses = m
+	e.pauseMu.Unlock()
+	return nil
+}
+
+// startPauseWatcher re-reads operator pauses every pauseRefreshInterval.
+// Blocks until ctx is cancelled.
+func (e *Engine) startPauseWatcher(ctx context.Context) {
+	ticker := time.NewTicker(pauseRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.refreshPauses(ctx); err != nil && ctx.Err() == nil {
+				e.logger.Warn().Err(err).Msg("failed to refresh operator pauses — keeping previous")
+			}
+		}
+	}
+}
+
+// checkAccount returns ErrUnknownAccount unless accountID is empty or managed
+// by the engine.
+func (e *Engine) checkAccount(accountID string) error {
+	if accountID != "" && !slices.Contains(e.accounts, accountID) {
+		return fmt.Errorf("%w: %s", ErrUnknownAccount, accountID)
+	}
+	return nil
+}
+
+// Pauses returns the tenant's operator pauses as persisted in the store.
+func (e *Engine) Pauses(ctx context.Context) ([]PauseState, error) {
+	if err := e.refreshPauses(ctx); err != nil {
+		return nil, err
+	}
+	e.pauseMu.RLock()
+	out := make([]PauseState, 0, len(e.pauses))
+	for _, p := range e.pauses {
+		out = append(out, *p)
+	}
+	e.pauseMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AccountID != out[j].AccountID {
+			return out[i].AccountID < out[j].AccountID
+		}
+		return out[i].Strategy < out[j].Strategy
+	})
+	return out, nil
+}
+
+// Pause stops new positions for the tenant, an account, a strategy, or a
+// strategy on an account until Resume is called for the same scope.
+func (e *Engine) Pause(ctx context.Context, accountID, strategy, reason string) (PauseState, error) {
+	if err := e.checkAccount(accountID); err != nil {
+		return PauseState{}, err
+	}
+	p := PauseState{AccountID: accountID, Strategy: strategy, Reason: reason, PausedAt: time.Now().UTC()}
+	if err := e.repo.SavePause(ctx, e.tenantID(), &p); err != nil {
+		return PauseState{}, err
+	}
+	e.pauseMu.Lock()
+	e.pauses[pauseKey{accountID, strategy}] = &p
+	e.pauseMu.Unlock()
+	e.logger.Warn().Str("account", accountID).Str("strategy", strategy).Str("reason", reason).
+		Msg("new positions paused by operator")
+	return p, nil
+}
+
+// Resume lifts the pause on exactly the given scope. Returns false when that
+// scope is not paused; pauses on wider or narrower scopes are unaffected.
+func (e *Engine) Resume(ctx context.Context, accountID, strategy string) (bool, error) {
+	if err := e.refreshPauses(ctx); err != nil {
+		return false, err
+	}
+	key := pauseKey{accountID, strategy}
+	e.pauseMu.RLock()
+	_, paused := e.pauses[key]
+	e.pauseMu.RUnlock()
+	if !paused {
+		return false, nil
+	}
+	if err := e.repo.DeletePause(ctx, e.tenantID(), accountID, strategy); err != nil {
+		return false, err
+	}
+	e.pauseMu.Lock()
+	delete(e.pauses, key)
+	e.pauseMu.Unlock()
+	e.logger.Info().Str("account", accountID).Str("strategy", strategy).Msg("new positions resumed by operator")
+	return true, nil
+}
+
+// Flatten closes every engine-managed position on the account, or on every
+// managed account when accountID is empty, and returns how many closes were
+// recorded. It does not pause new positions.
+func (e *Engine) Flatten(ctx context.Context, accountID string) (int, error) {
+	if !e.IsLeader() {
+		return 0, ErrNotLeader
+	}
+	if err := e.checkAccount(accountID); err != nil {
+		return 0, err
+	}
+	accounts := e.accounts
+	if accountID != "" {
+		accounts = []string{accountID}
+	}
+	closed := 0
+	for _, acct := range accounts {
+		closed += e.flattenAccount(ctx, acct, "Flatten: operator request")
+	}
+	return closed, nil
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+)
+
+// pauseStore keeps operator pauses like the Firestore scopes collection.
+type pauseStore struct {
+	EngineStore
+	pauses map[pauseKey]PauseState
+}
+
+func (s *pauseStore) LoadPauses(_ context.Context, _ uuid.UUID) ([]PauseState, error) {
+	var out []PauseState
+	for _, p := range s.pauses {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (s *pauseStore) SavePause(_ context.Context, _ uuid.UUID, p *PauseState) error {
+	s.pauses[pauseKey{p.AccountID, p.Strategy}] = *p
+	return nil
+}
+
+func (s *pauseStore) DeletePause(_ context.Context, _ uuid.UUID, accountID, strategy string) error {
+	delete(s.pauses, pauseKey{accountID, strategy})
+	return nil
+}
+
+func TestPause_ScopesAndResume(t *testing.T) {
+	store := &pauseStore{pauses: make(map[pauseKey]PauseState)}
+	e := makeEngine(&config.Config{})
+	e.repo = store
+	e.accounts = []string{"acc", "other"}
+	ctx := context.Background()
+
+	if _, err := e.Pause(ctx, "acc", "trend", "review"); err != nil {
+		t.Fatal(err)
+	}
+	if p := e.openPausedBy("acc", "trend"); p == nil || p.Reason != "review" {
+		t.Fatalf("trend on acc should be paused, got %+v", p)
+	}
+	if e.openPausedBy("acc", "scalp") != nil || e.openPausedBy("other", "trend") != nil {
+		t.Error("pause must not leak to other strategies or accounts")
+	}
+
+	if _, err := e.Pause(ctx, "", "", "incident"); err != nil {
+		t.Fatal(err)
+	}
+	if p := e.openPausedBy("other", "scalp"); p == nil || p.Reason != "incident" {
+		t.Fatalf("tenant pause should cover every account, got %+v", p)
+	}
+
+	if ok, err := e.Resume(ctx, "", ""); !ok || err != nil {
+		t.Fatalf("resume tenant: %v %v", ok, err)
+	}
+	if e.openPausedBy("acc", "trend") == nil {
+		t.Error("resuming the tenant must leave the narrower pause in place")
+	}
+	if ok, _ := e.Resume(ctx, "acc", ""); ok {
+		t.Error("acc itself was never paused")
+	}
+
+	if _, err := e.Pause(ctx, "nope", "", ""); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("want ErrUnknownAccount, got %v", err)
+	}
+}
+
+func TestRefreshPauses_PicksUpOtherReplicas(t *testing.T) {
+	store := &pauseStore{pauses: map[pauseKey]PauseState{{"acc", ""}: {AccountID: "acc", Reason: "set elsewhere"}}}
+	e := makeEngine(&config.Config{})
+	e.repo = store
+
+	if e.openPausedBy("acc", "trend") != nil {
+		t.Fatal("not loaded yet")
+	}
+	if err := e.refreshPauses(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if e.openPausedBy("acc", "trend") == nil {
+		t.Error("pause written by another replica should apply after a refresh")
+	}
+}
+
+func TestFlatten_RequiresLeadership(t *testing.T) {
+	e := makeEngine(&config.Config{})
+	e.accounts = []string{"acc"}
+	if _, err := e.Flatten(context.Background(), "acc"); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("want ErrNotLeader, got %v", err)
+	}
+
+	e.leading.Store(true)
+	if _, err := e.Flatten(context.Background(), "nope"); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("want ErrUnknownAccount, got %v", err)
+	}
+	if n, err := e.Flatten(context.Background(), ""); n != 0 || err != nil {
+		t.Errorf("no positions: want 0 closes, got %d %v", n, err)
+	}
+}
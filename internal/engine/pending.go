@@ -0,0 +1,318 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// pendingEntry is a resting limit, post-only or stop entry order. The prepared
+// trade is held until the exchange reports a fill, at which point it is
+// recorded exactly like a market entry. Live entries are also persisted in the
+// engine store, so a restarted engine or a new leader picks them up.
+type pendingEntry struct {
+	exchange     Exchange // adapter the order rests on
+	venue        string
+	orderID      string
+	trade        *domain.Trade
+	signalPrice  float64
+	positionSide domain.PositionSide
+	leverage     int
+	tc           *TradingConfig // nil when reloaded from the store
+	placedAt     time.Time
+	expiresAt    time.Time
+}
+
+// state returns the persisted form of pe.
+func (pe *pendingEntry) state() *PendingEntryState {
+	return &PendingEntryState{
+		AccountID:    pe.trade.AccountID,
+		Symbol:       pe.trade.Symbol,
+		Exchange:     pe.venue,
+		OrderID:      pe.orderID,
+		Trade:        *pe.trade,
+		SignalPrice:  pe.signalPrice,
+		PositionSide: string(pe.positionSide),
+		Leverage:     pe.leverage,
+		PlacedAt:     pe.placedAt,
+		ExpiresAt:    pe.expiresAt,
+	}
+}
+
+// persistsPendingEntries reports whether resting entries are kept in the
+// engine store. Paper and shadow entries rest on the simulated exchange,
+// which does not outlive the process.
+func (e *Engine) persistsPendingEntries() bool {
+	return e.cfg.TradingMode == "live"
+}
+
+// trackPendingEntry registers a resting entry order for fill polling.
+func (e *Engine) trackPendingEntry(ctx context.Context, ex Exchange, venue, orderID string, trade *domain.Trade, signalPrice float64, positionSide domain.PositionSide, leverage int, tc *TradingConfig) {
+	now := time.Now()
+	pe := &pendingEntry{
+		exchange:     ex,
+		venue:        venue,
+		orderID:      orderID,
+		trade:        trade,
+		signalPrice:  signalPrice,
+		positionSide: positionSide,
+		leverage:     leverage,
+		tc:           tc,
+		placedAt:     now,
+		expiresAt:    now.Add(e.cfg.LimitOrderTimeout),
+	}
+	e.pendingMu.Lock()
+	e.pending[posKey(trade.AccountID, trade.Symbol)] = pe
+	e.pendingMu.Unlock()
+
+	if e.persistsPendingEntries() {
+		if err := e.repo.SavePendingEntry(ctx, pe.state()); err != nil {
+			e.logger.Error().Err(err).
+				Str("account", trade.AccountID).
+				Str("product", trade.Symbol).
+				Str("order_id", orderID).
+				Msg("failed to persist resting entry order — it is forgotten on restart")
+		}
+	}
+}
+
+// loadPendingEntries restores the account's persisted resting entries and
+// their direction conflict guard. Returns the number restored.
+func (e *Engine) loadPendingEntries(ctx context.Context, accountID string) (int, error) {
+	if !e.persistsPendingEntries() {
+		return 0, nil
+	}
+	states, err := e.repo.LoadPendingEntries(ctx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	loaded := 0
+	for _, st := range states {
+		trade := st.Trade
+		ex, err := e.positionExchange(accountID, st.Exchange, string(trade.MarketType), st.Symbol)
+		if err != nil {
+			e.logger.Error().Err(err).
+				Str("account", accountID).
+				Str("product", st.Symbol).
+				Str("order_id", st.OrderID).
+				Msg("no exchange for persisted resting entry order — not polled")
+			continue
+		}
+		key := posKey(accountID, st.Symbol)
+		e.pendingMu.Lock()
+		e.pending[key] = &pendingEntry{
+			exchange:     ex,
+			venue:        st.Exchange,
+			orderID:      st.OrderID,
+			trade:        &trade,
+			signalPrice:  st.SignalPrice,
+			positionSide: domain.PositionSide(st.PositionSide),
+			leverage:     st.Leverage,
+			placedAt:     st.PlacedAt,
+			expiresAt:    st.ExpiresAt,
+		}
+		e.pendingMu.Unlock()
+		e.conflictMu.Lock()
+		e.conflict[key] = st.PositionSide
+		e.conflictMu.Unlock()
+		loaded++
+	}
+	return loaded, nil
+}
+
+// releasePendingEntries forgets the resting entries when this instance stops
+// trading. Live entries stay in the engine store for the next leader to poll;
+// simulated ones are cancelled, as nothing else would ever resolve them.
+func (e *Engine) releasePendingEntries() {
+	e.pendingMu.Lock()
+	pending := e.pending
+	e.pending = make(map[string]*pendingEntry)
+	e.pendingMu.Unlock()
+	if e.persistsPendingEntries() {
+		return
+	}
+	for _, pe := range pending {
+		if err := pe.exchange.CancelOrder(context.Background(), pe.trade.Symbol, pe.orderID); err != nil {
+			e.logger.Warn().Err(err).Str("order_id", pe.orderID).Msg("failed to cancel resting entry order on step-down")
+		}
+	}
+}
+
+// hasPendingEntry reports whether an entry order is resting for account+symbol.
+func (e *Engine) hasPendingEntry(accountID, symbol string) bool {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+	_, ok := e.pending[posKey(accountID, symbol)]
+	return ok
+}
+
+// takePendingEntry removes pe from the pending map and the engine store.
+// Returns false when another goroutine already resolved it.
+func (e *Engine) takePendingEntry(ctx context.Context, key string, pe *pendingEntry) bool {
+	e.pendingMu.Lock()
+	if e.pending[key] != pe {
+		e.pendingMu.Unlock()
+		return false
+	}
+	delete(e.pending, key)
+	e.pendingMu.Unlock()
+
+	if e.persistsPendingEntries() {
+		if err := e.repo.DeletePendingEntry(ctx, pe.trade.AccountID, pe.trade.Symbol); err != nil {
+			e.logger.Warn().Err(err).
+				Str("account", pe.trade.AccountID).
+				Str("product", pe.trade.Symbol).
+				Str("order_id", pe.orderID).
+				Msg("failed to delete persisted resting entry order")
+		}
+	}
+	return true
+}
+
+// checkPendingEntries polls the exchange for resting entry orders. When symbol
+// is non-empty only entries for that symbol are checked.
+func (e *Engine) checkPendingEntries(ctx context.Context, symbol string) {
+	now := time.Now()
+	for key, pe := range e.pendingEntriesFor(symbol) {
+		key, pe := key, pe
+		e.serialize(ctx, key, func() { e.checkPendingEntry(ctx, key, pe, now) })
+	}
+}
+
+// pendingEntriesFor returns the resting entries for symbol, or all of them
+// when symbol is empty, by account+product key.
+func (e *Engine) pendingEntriesFor(symbol string) map[string]*pendingEntry {
+	entries := make(map[string]*pendingEntry)
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+	for k, pe := range e.pending {
+		if symbol == "" || pe.trade.Symbol == symbol {
+			entries[k] = pe
+		}
+	}
+	return entries
+}
+
+// checkPendingEntry resolves a single resting entry: records it when filled,
+// drops it when the exchange cancelled it, and cancels it once the configured
+// timeout has elapsed. A partial fill at cancellation is recorded as the
+// position.
+func (e *Engine) checkPendingEntry(ctx context.Context, key string, pe *pendingEntry, now time.Time) {
+	logger := e.logger.With().
+		Str("account", pe.trade.AccountID).
+		Str("product", pe.trade.Symbol).
+		Str("order_id", pe.orderID).
+		Logger()
+
+	res, err := pe.exchange.GetOrder(ctx, pe.trade.Symbol, pe.orderID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to query resting entry order")
+		return
+	}
+
+	switch {
+	case res.Status == domain.OrderStatusFilled:
+		e.completePendingEntry(ctx, key, pe, res)
+		return
+	case res.Status == domain.OrderStatusCancelled:
+		if res.Quantity > 0 {
+			e.completePendingEntry(ctx, key, pe, res)
+		} else {
+			e.dropPendingEntry(ctx, key, pe, "cancelled by exchange")
+		}
+		return
+	case now.Before(pe.expiresAt):
+		return
+	}
+
+	// Timed out — cancel and record whatever filled in the meantime.
+	if err := pe.exchange.CancelOrder(ctx, pe.trade.Symbol, pe.orderID); err != nil {
+		logger.Warn().Err(err).Msg("failed to cancel expired entry order")
+		return
+	}
+	final, err := pe.exchange.GetOrder(ctx, pe.trade.Symbol, pe.orderID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to query cancelled entry order")
+		final = res
+	}
+	if final.Quantity > 0 {
+		e.completePendingEntry(ctx, key, pe, final)
+		return
+	}
+	e.dropPendingEntry(ctx, key, pe, "expired")
+}
+
+// completePendingEntry records a filled (or partially filled) resting entry
+// in the ledger and starts risk-managing the position.
+func (e *Engine) completePendingEntry(ctx context.Context, key string, pe *pendingEntry, res *OrderResult) {
+	if !e.takePendingEntry(ctx, key, pe) {
+		return
+	}
+	logger := e.logger.With().
+		Str("account", pe.trade.AccountID).
+		Str("product", pe.trade.Symbol).
+		Str("order_id", pe.orderID).
+		Logger()
+
+	// GetOrder does not know the position's leverage, so re-derive margin
+	// from the filled notional.
+	fill := *res
+	if pe.leverage > 1 {
+		fill.Margin = fill.FillPrice * fill.Quantity / float64(pe.leverage)
+	}
+
+	now := time.Now()
+	trade := pe.trade
+	applyFill(trade, &fill)
+	trade.Timestamp = now
+	trade.IngestedAt = now
+
+	logger.Info().
+		Float64("fill_price", res.FillPrice).
+		Float64("qty", res.Quantity).
+		Str("status", string(res.Status)).
+		Dur("waited", now.Sub(pe.placedAt)).
+		Msg("resting entry order filled")
+
+	if err := e.recordOpenTrade(ctx, trade); err != nil {
+		logger.Error().Err(err).Msg("failed to record filled entry order")
+		return
+	}
+
+	entryPrice := res.FillPrice
+	if entryPrice <= 0 {
+		entryPrice = pe.signalPrice
+	}
+	// An entry reloaded from the store takes the current trading config.
+	tc := pe.tc
+	if tc == nil {
+		if tc, _ = e.tradingConfig(trade.AccountID, trade.Symbol); tc == nil {
+			tc = &TradingConfig{}
+		}
+	}
+	e.persistOpenedPosition(ctx, pe.venue, trade, entryPrice, pe.positionSide, pe.leverage, tc)
+}
+
+// dropPendingEntry forgets an entry that never filled and releases the
+// direction conflict guard so the next signal can open.
+func (e *Engine) dropPendingEntry(ctx context.Context, key string, pe *pendingEntry, reason string) {
+	if !e.takePendingEntry(ctx, key, pe) {
+		return
+	}
+	e.posStateMu.RLock()
+	_, open := e.posState[key]
+	e.posStateMu.RUnlock()
+	if !open {
+		e.conflictMu.Lock()
+		delete(e.conflict, key)
+		e.conflictMu.Unlock()
+	}
+	e.logger.Info().
+		Str("account", pe.trade.AccountID).
+		Str("product", pe.trade.Symbol).
+		Str("order_id", pe.orderID).
+		Str("reason", reason).
+		Dur("waited", time.Since(pe.placedAt)).
+		Msg("entry order not filled — dropped")
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+func TestCheckPendingEntry_ExpiredOrderCancelledAndDropped(t *testing.T) {
+	cfg := &config.Config{LimitOrderTimeout: time.Minute}
+	e := makeEngine(cfg)
+	ex := NewNoopExchange(cfg)
+	ctx := context.Background()
+
+	res, err := ex.OpenPosition(ctx, OpenPositionRequest{
+		Symbol:     "BTC-USD",
+		Side:       domain.PositionSideLong,
+		SizeUSD:    1000,
+		Price:      50000,
+		OrderType:  OrderTypeLimit,
+		LimitPrice: 49000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	trade := &domain.Trade{AccountID: "acc", Symbol: "BTC-USD"}
+	e.trackPendingEntry(ctx, ex, "coinbase", res.OrderID, trade, 49000, domain.PositionSideLong, 1, &TradingConfig{Granularity: "ONE_HOUR"})
+	e.conflict[posKey("acc", "BTC-USD")] = string(domain.PositionSideLong)
+
+	// Not yet expired — stays pending.
+	e.checkPendingEntries(ctx, "BTC-USD")
+	if !e.hasPendingEntry("acc", "BTC-USD") {
+		t.Fatal("entry dropped before timeout")
+	}
+
+	// Past the timeout — cancelled on the exchange and forgotten.
+	pe := e.pending[posKey("acc", "BTC-USD")]
+	e.checkPendingEntry(ctx, posKey("acc", "BTC-USD"), pe, pe.expiresAt.Add(time.Second))
+	if e.hasPendingEntry("acc", "BTC-USD") {
+		t.Error("expired entry still pending")
+	}
+	if _, ok := e.conflict[posKey("acc", "BTC-USD")]; ok {
+		t.Error("conflict guard not released after expiry")
+	}
+	got, _ := ex.GetOrder(ctx, "BTC-USD", res.OrderID)
+	if got.Status != domain.OrderStatusCancelled {
+		t.Errorf("exchange order: want cancelled, got %s", got.Status)
+	}
+}
+
+func TestHandleOpenSignal_PaperPostOnlyRestsAtOffsetThenFills(t *testing.T) {
+	cfg := &config.Config{
+		TradingMode: "paper", PortfolioSize: 10000, PositionSizePct: 10,
+		EntryOrderType: "post_only", EntryOffsetBps: 10, LimitOrderTimeout: time.Minute,
+	}
+	store, err := NewShadowEngineStore(&exposureStore{}, filepath.Join(t.TempDir(), "ledger.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	e := makeEngine(cfg)
+	e.exchange = NewNoopExchange(cfg)
+	e.exchanges = NewPaperExchangeRegistry(e.exchange)
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	e.accounts = []string{"acc"}
+	ctx := context.Background()
+
+	e.handleOpenSignal(ctx, SignalPayload{Action: "BUY", Price: 50000, Confidence: 0.9}, "BTC-USD", "trend", "acc", &TradingConfig{Exchange: "binance"})
+	if !e.hasPendingEntry("acc", "BTC-USD") {
+		t.Fatal("a post-only entry 10bps under the signal price should rest")
+	}
+	if trades := store.Trades("acc", time.Time{}, time.Time{}); len(trades) != 0 {
+		t.Fatalf("nothing is recorded before the fill, got %+v", trades)
+	}
+
+	// A later price at or through the limit fills it at the limit.
+	e.exchange.(*NoopExchange).ObservePrice("BTC-USD", 49960)
+	e.checkPendingEntries(ctx, "BTC-USD")
+	if !e.hasPendingEntry("acc", "BTC-USD") {
+		t.Fatal("49960 has not reached the 49950 limit")
+	}
+	e.exchange.(*NoopExchange).ObservePrice("BTC-USD", 49900)
+	e.checkPendingEntries(ctx, "BTC-USD")
+	if e.hasPendingEntry("acc", "BTC-USD") {
+		t.Fatal("entry still pending after the limit was crossed")
+	}
+	trades := store.Trades("acc", time.Time{}, time.Time{})
+	if len(trades) != 1 {
+		t.Fatalf("want one entry trade, got %+v", trades)
+	}
+	assertFloat(t, "fill price", 49950, trades[0].Price)
+	if _, open := e.posState[posKey("acc", "BTC-USD")]; !open {
+		t.Error("the filled entry should open a managed position")
+	}
+}
+
+// pendingStore keeps persisted resting entries in memory.
+type pendingStore struct {
+	stateStore
+	entries map[string]PendingEntryState
+}
+
+func (s *pendingStore) LoadPendingEntries(_ context.Context, accountID string) ([]PendingEntryState, error) {
+	var out []PendingEntryState
+	for _, p := range s.entries {
+		if p.AccountID == accountID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *pendingStore) SavePendingEntry(_ context.Context, p *PendingEntryState) error {
+	s.entries[posKey(p.AccountID, p.Symbol)] = *p
+	return nil
+}
+
+func (s *pendingStore) DeletePendingEntry(_ context.Context, accountID, symbol string) error {
+	delete(s.entries, posKey(accountID, symbol))
+	return nil
+}
+
+func TestPendingEntry_LiveEntrySurvivesRestartAndExpires(t *testing.T) {
+	cfg := &config.Config{TradingMode: "live", LimitOrderTimeout: time.Minute}
+	ex := NewNoopExchange(cfg)
+	registry := NewExchangeRegistry()
+	registry.Register("coinbase", ex)
+	store := &pendingStore{entries: make(map[string]PendingEntryState)}
+	ctx := context.Background()
+
+	res, err := ex.OpenPosition(ctx, OpenPositionRequest{
+		Symbol: "BTC-USD", Side: domain.PositionSideLong, SizeUSD: 1000, Price: 50000,
+		OrderType: OrderTypeLimit, LimitPrice: 49000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := makeEngine(cfg)
+	before.exchanges, before.repo = registry, store
+	trade := &domain.Trade{AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot, Quantity: 0.02}
+	before.trackPendingEntry(ctx, ex, "coinbase", res.OrderID, trade, 49000, domain.PositionSideLong, 1, &TradingConfig{})
+	if len(store.entries) != 1 {
+		t.Fatalf("a live resting entry should be persisted, got %+v", store.entries)
+	}
+
+	// A restarted engine (or a new leader) resumes polling the order.
+	after := makeEngine(cfg)
+	after.exchanges, after.repo = registry, store
+	if n, err := after.loadPendingEntries(ctx, "acc"); err != nil || n != 1 {
+		t.Fatalf("want one entry reloaded, got %d %v", n, err)
+	}
+	if after.conflict[posKey("acc", "BTC-USD")] != string(domain.PositionSideLong) {
+		t.Error("a reloaded entry should hold the direction conflict guard")
+	}
+	key := posKey("acc", "BTC-USD")
+	pe := after.pending[key]
+	if pe.orderID != res.OrderID || pe.trade.Quantity != 0.02 {
+		t.Fatalf("reloaded entry: got %+v", pe)
+	}
+
+	// LIMIT_ORDER_TIMEOUT still applies: the order is cancelled and forgotten.
+	after.checkPendingEntry(ctx, key, pe, pe.expiresAt.Add(time.Second))
+	if got, _ := ex.GetOrder(ctx, "BTC-USD", res.OrderID); got.Status != domain.OrderStatusCancelled {
+		t.Errorf("exchange order: want cancelled, got %s", got.Status)
+	}
+	if after.hasPendingEntry("acc", "BTC-USD") || len(store.entries) != 0 {
+		t.Errorf("expired entry not removed: pending=%v store=%+v", after.hasPendingEntry("acc", "BTC-USD"), store.entries)
+	}
+}
+
+func TestReleasePendingEntries_CancelsSimulatedEntries(t *testing.T) {
+	cfg := &config.Config{TradingMode: "paper", LimitOrderTimeout: time.Minute}
+	e := makeEngine(cfg)
+	ex := NewNoopExchange(cfg)
+	ctx := context.Background()
+	res, err := ex.OpenPosition(ctx, OpenPositionRequest{
+		Symbol: "BTC-USD", Side: domain.PositionSideLong, SizeUSD: 1000, Price: 50000,
+		OrderType: OrderTypeLimit, LimitPrice: 49000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.trackPendingEntry(ctx, ex, "coinbase", res.OrderID, &domain.Trade{AccountID: "acc", Symbol: "BTC-USD"}, 49000, domain.PositionSideLong, 1, &TradingConfig{})
+
+	e.releasePendingEntries()
+	if e.hasPendingEntry("acc", "BTC-USD") {
+		t.Error("entry still tracked after step-down")
+	}
+	if got, _ := ex.GetOrder(ctx, "BTC-USD", res.OrderID); got.Status != domain.OrderStatusCancelled {
+		t.Errorf("a simulated entry nobody will poll should be cancelled, got %s", got.Status)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +17,10 @@ import (
 )
 
 // processSignal routes a signal to the position engine for a specific account.
-func (e *Engine) processSignal(ctx context.Context, signal SignalPayload, product, strategy, accountID string) {
+// It returns an error when the signal should have been acted on but was not —
+// a failed order, store read or close — and nil when it was carried out or
+// deliberately skipped.
+func (e *Engine) processSignal(ctx context.Context, signal SignalPayload, product, strategy, accountID string) error {
 	logger := e.logger.With().
 		Str("account", accountID).
 		Str("product", product).
@@ -27,19 +31,26 @@ func (e *Engine) processSignal(ctx context.Context, signal SignalPayload, produc
 	// Kill switch check.
 	if (signal.Action == "BUY" || signal.Action == "SHORT") && e.killSwitchActive() {
 		logger.Warn().Str("file", e.cfg.KillSwitchFile).Msg("kill switch active — skipping open trade")
-		return
+		return nil
 	}
 
-	// Fetch trading config for this account+product to get market type and leverage.
-	tradingConfigs, err := fetchTradingConfigs(ctx, e.cfg)
-	if err != nil {
-		logger.Error().Err(err).Msg("failed to fetch trading configs, skipping signal")
-		return
+	// Operator pause for the tenant, account or strategy.
+	if signal.Action == "BUY" || signal.Action == "SHORT" {
+		if p := e.openPausedBy(accountID, strategy); p != nil {
+			logger.Warn().
+				Str("paused_account", p.AccountID).
+				Str("paused_strategy", p.Strategy).
+				Str("reason", p.Reason).
+				Msg("new positions paused by operator — skipping open trade")
+			return nil
+		}
 	}
-	tradingConfig, ok := tradingConfigs[tradingConfigKey{accountID: accountID, productID: product}]
+
+	// Look up the cached trading config for this account+product to get market type and leverage.
+	tradingConfig, ok := e.tradingConfig(accountID, product)
 	if !ok {
 		logger.Warn().Msg("no trading config for account+product, skipping signal")
-		return
+		return nil
 	}
 
 	// Validate that the signal strategy is configured for this account+product.
@@ -59,7 +70,7 @@ func (e *Engine) processSignal(ctx context.Context, signal SignalPayload, produc
 		}
 		if !strategyAllowed {
 			logger.Debug().Str("strategy", strategy).Msg("strategy not in trading config for account+product, skipping signal")
-			return
+			return nil
 		}
 	}
 
@@ -77,7 +88,7 @@ func (e *Engine) processSignal(ctx context.Context, signal SignalPayload, produc
 					Float64("confidence", signal.Confidence).
 					Float64("threshold", thresh).
 					Msg("signal below account confidence threshold, skipping")
-				return
+				return nil
 			}
 		case "SELL", "COVER":
 			// ML strategies self-govern exit confidence server-side: the ingestion
@@ -92,7 +103,7 @@ func (e *Engine) processSignal(ctx context.Context, signal SignalPayload, produc
 						Float64("confidence", signal.Confidence).
 						Float64("exit_threshold", thresh).
 						Msg("exit signal below account exit_confidence threshold, skipping")
-					return
+					return nil
 				}
 			}
 		}
@@ -100,11 +111,12 @@ func (e *Engine) processSignal(ctx context.Context, signal SignalPayload, produc
 
 	switch signal.Action {
 	case "BUY", "SHORT":
-		e.handleOpenSignal(ctx, signal, product, strategy, accountID, tradingConfig)
+		return e.handleOpenSignal(ctx, signal, product, strategy, accountID, tradingConfig)
 	case "SELL", "COVER":
-		e.handleCloseSignal(ctx, signal, product, strategy, accountID, tradingConfig)
+		return e.handleCloseSignal(ctx, signal, product, strategy, accountID, tradingConfig)
 	default:
 		logger.Warn().Str("action", signal.Action).Msg("unknown signal action, skipping")
+		return nil
 	}
 }
 
@@ -122,7 +134,7 @@ func signalBaseName(strategy string) string {
 }
 
 // handleOpenSignal handles BUY and SHORT signals for a specific account.
-func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, product, strategy, accountID string, tc *TradingConfig) {
+func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, product, strategy, accountID string, tc *TradingConfig) error {
 	logger := e.logger.With().
 		Str("account", accountID).
 		Str("product", product).
@@ -135,10 +147,46 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 	// Determine market type and side.
 	side, positionSide, marketType := mapSignalToSide(signal.Action, tc)
 
+	// Resolve the venue adapter for this account and trading config. The
+	// signal's exchange is used when the config does not name one. Unknown
+	// venues fail closed.
+	venueName := tc.Exchange
+	if venueName == "" {
+		venueName = signal.Exchange
+	}
+	ex, venue, err := e.exchangeFor(accountID, venueName, marketType)
+	if err != nil {
+		logger.Error().Err(err).Str("venue", venue).Msg("no exchange adapter for venue — refusing to trade")
+		return err
+	}
+
 	// Daily loss limit check.
 	if e.cfg.DailyLossLimit > 0 && e.isDailyLossLimitReached(ctx, accountID) {
 		logger.Warn().Float64("limit", e.cfg.DailyLossLimit).Msg("daily loss limit reached — skipping open trade")
-		return
+		return nil
+	}
+
+	// An account flattened on FLATTEN_LOSS_LIMIT opens nothing more today.
+	if e.cfg.FlattenLossLimit > 0 && e.flattenedToday(accountID) {
+		logger.Warn().Float64("limit", e.cfg.FlattenLossLimit).Msg("account flattened on loss limit today — skipping open trade")
+		return nil
+	}
+
+	// Circuit breakers — drawdown, weekly loss and losing-streak trips pause
+	// opens for the account or the strategy.
+	if st := e.openBlockedBy(accountID, strategy); st != nil {
+		logger.Warn().
+			Str("breaker", st.Breaker).
+			Str("breaker_strategy", st.Strategy).
+			Time("until", st.Until).
+			Msg("circuit breaker tripped — skipping open trade")
+		return nil
+	}
+
+	// Resting entry order guard — one entry per account+product at a time.
+	if e.hasPendingEntry(accountID, product) {
+		logger.Debug().Msg("entry order already resting for account+product — skipping trade")
+		return nil
 	}
 
 	// Direction conflict guard.
@@ -148,31 +196,105 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 			e.conflictMu.Unlock()
 			logger.Warn().Str("open_side", openSide).Str("want", string(positionSide)).
 				Msg("direction conflict — skipping trade")
-			return
+			return nil
 		}
 	}
 	e.conflictMu.Unlock()
 
+	// Same-direction position already open: add a layer when the signal asks
+	// to scale in, otherwise skip.
+	e.posStateMu.RLock()
+	existing, open := e.posState[posKey(accountID, product)]
+	var scaleIns int
+	var closing, layered bool
+	if open {
+		scaleIns = len(existing.Entries) - 1
+		closing = existing.Closing
+		layered = existing.Quantity > 0
+	}
+	e.posStateMu.RUnlock()
+	scaleIn := open
+	if open {
+		switch {
+		case !signal.ScaleIn:
+			logger.Debug().Msg("position already open — skipping entry (signal does not request scale-in)")
+			return nil
+		case closing:
+			logger.Debug().Msg("position is closing — skipping scale-in")
+			return nil
+		case !layered:
+			logger.Warn().Msg("position predates layered entries — scale-in unavailable")
+			return nil
+		case scaleIns >= e.cfg.MaxScaleIns:
+			logger.Warn().Int("scale_ins", scaleIns).Int("max", e.cfg.MaxScaleIns).
+				Msg("max scale-ins reached — skipping trade")
+			return nil
+		}
+	}
+
 	// Per-config confidence threshold check.
 	if tc.MinConfidence > 0 && signal.Confidence < tc.MinConfidence {
 		logger.Debug().
 			Float64("confidence", signal.Confidence).
 			Float64("min_confidence", tc.MinConfidence).
 			Msg("signal confidence below configured threshold, dropping")
-		return
+		return nil
 	}
 
-	// Max positions check (per account).
-	if e.cfg.MaxPositions > 0 {
+	// Max positions check (per account). A scale-in adds to an existing position.
+	if e.cfg.MaxPositions > 0 && !scaleIn {
 		states, err := e.repo.CountOpenPositionStates(ctx, accountID)
 		if err != nil {
 			logger.Error().Err(err).Msg("failed to count open positions")
-			return
+			return err
 		}
 		if states >= e.cfg.MaxPositions {
 			logger.Warn().Int("max", e.cfg.MaxPositions).Int("open", states).
 				Msg("max positions reached — skipping trade")
-			return
+			return nil
+		}
+	}
+
+	// Correlated cluster check (per account). A scale-in adds to an existing position.
+	if !scaleIn {
+		breach, cluster, err := e.checkCorrelation(ctx, accountID, product, positionSide)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to load open positions for correlation check — skipping trade")
+			return err
+		}
+		if breach != nil {
+			members := make([]string, len(cluster))
+			for i, m := range cluster {
+				members[i] = m.String()
+			}
+			logger.Warn().
+				Int("max", e.cfg.MaxCorrelatedPositions).
+				Strs("cluster", members).
+				Msg("max correlated positions reached — rejecting signal")
+			e.publishRejected(accountID, product, strategy, signal, breach)
+			return nil
+		}
+	}
+
+	// Kelly sizing: once the strategy has enough closed trades on this
+	// product, its Kelly bet is used as an explicit position percentage.
+	sizing := signal
+	if tc.SizingMode == sizingKelly && signal.PositionPct <= 0 {
+		pct, stats, ok := e.kellyPct(strategy, product, tc)
+		switch {
+		case !ok:
+			logger.Debug().Int("trades", stats.Trades).Int("min_trades", e.cfg.KellyMinTrades).
+				Msg("too few closed trades for Kelly sizing — using POSITION_SIZE_PCT")
+		case pct <= 0:
+			logger.Warn().
+				Int("trades", stats.Trades).
+				Float64("win_rate", stats.winRate()).
+				Float64("avg_win", stats.AvgWin).
+				Float64("avg_loss", stats.AvgLoss).
+				Msg("strategy has no edge under Kelly sizing — skipping trade")
+			return nil
+		default:
+			sizing.PositionPct = pct / 100
 		}
 	}
 
@@ -181,14 +303,31 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 	balance, balErr := e.repo.GetAccountBalance(ctx, tenantID, accountID, "USD")
 	if balErr != nil {
 		logger.Error().Err(balErr).Msg("failed to fetch account balance")
-		return
+		return balErr
 	}
 
 	// Calculate position size capped to available balance.
-	size, qty, margin, err := e.calculatePositionSize(signal, tc, marketType, balance)
+	size, qty, margin, err := e.calculatePositionSize(sizing, tc, marketType, balance)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to calculate position size")
-		return
+		return err
+	}
+
+	// Portfolio-wide exposure limits across all managed accounts.
+	breach, err := e.checkExposure(ctx, product, positionSide, size, margin)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to load portfolio exposure — skipping trade")
+		return err
+	}
+	if breach != nil {
+		logger.Warn().
+			Str("limit", breach.Limit).
+			Float64("value", breach.Value).
+			Float64("max", breach.Max).
+			Float64("size_usd", size).
+			Msg("portfolio exposure limit reached — rejecting signal")
+		e.publishRejected(accountID, product, strategy, signal, breach)
+		return nil
 	}
 
 	// Determine the required capital for this position.
@@ -203,13 +342,13 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 			Float64("required", required).
 			Float64("min_position_size", e.cfg.MinPositionSize).
 			Msg("available balance below minimum position size — skipping trade")
-		return
+		return nil
 	}
 
 	// Safety-net balance check (guards against races and zero-balance edge cases).
 	if err := e.checkBalance(ctx, tenantID, accountID, required); err != nil {
 		logger.Warn().Err(err).Msg("insufficient balance — skipping trade")
-		return
+		return nil
 	}
 
 	// Build trade.
@@ -251,7 +390,7 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 
 	trade := &domain.Trade{
 		TenantID:    tenantID,
-		TradeID:     fmt.Sprintf("engine-%s-%s-%d", accountID, product, now.UnixNano()),
+		TradeID:     engineTradeID("", accountID, product, signal.ID, now),
 		AccountID:   accountID,
 		Symbol:      product,
 		Side:        side,
@@ -282,6 +421,10 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 	if tp != nil {
 		tpVal = *tp
 	}
+	msg := "opening position"
+	if scaleIn {
+		msg = "scaling into position"
+	}
 	logger.Info().
 		Str("market_type", string(marketType)).
 		Str("position_side", string(positionSide)).
@@ -291,18 +434,19 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 		Float64("take_profit", tpVal).
 		Int("leverage", leverage).
 		Str("mode", e.cfg.TradingMode).
-		Msg("opening position")
+		Msg(msg)
 
 	// Execute the trade.
-	if err := e.executeOpenTrade(ctx, signal, trade, positionSide); err != nil {
+	result, err := e.executeOpenTrade(ctx, ex, signal, trade, positionSide)
+	if err != nil {
 		logger.Error().Err(err).Msg("failed to execute open trade")
-		return
+		return err
 	}
 
 	// Update cooldown.
 	key := cooldownKey{accountID: accountID, symbol: product, action: signal.Action}
 	e.cooldownMu.Lock()
-	e.cooldown[key] = time.Now().Add(5 * time.Minute)
+	e.cooldown[key] = time.Now().Add(e.thresholdsFor(tc).openCooldown)
 	e.cooldownMu.Unlock()
 
 	// Update conflict guard.
@@ -310,54 +454,142 @@ func (e *Engine) handleOpenSignal(ctx context.Context, signal SignalPayload, pro
 	e.conflict[posKey(accountID, product)] = string(positionSide)
 	e.conflictMu.Unlock()
 
+	// A resting entry order is recorded once the exchange reports the fill.
+	if result != nil && result.Pending() {
+		e.trackPendingEntry(ctx, ex, venue, result.OrderID, trade, signal.Price, positionSide, leverage, tc)
+		logger.Info().
+			Str("order_id", result.OrderID).
+			Str("order_type", e.cfg.EntryOrderType).
+			Dur("timeout", e.cfg.LimitOrderTimeout).
+			Msg("entry order resting — awaiting fill")
+		return nil
+	}
+
+	e.persistOpenedPosition(ctx, venue, trade, signal.Price, positionSide, leverage, tc)
+	return nil
+}
+
+// persistOpenedPosition computes the hard stop for a filled entry and records
+// the position's risk state in the store and the in-memory cache. A fill into
+// an already-open position on the same side is layered in as a scale-in.
+func (e *Engine) persistOpenedPosition(ctx context.Context, venue string, trade *domain.Trade, entryPrice float64, positionSide domain.PositionSide, leverage int, tc *TradingConfig) {
+	logger := e.logger.With().
+		Str("account", trade.AccountID).
+		Str("product", trade.Symbol).
+		Logger()
+	tenantID := e.tenantID()
+	marketType := trade.MarketType
+
+	e.posStateMu.RLock()
+	existing, open := e.posState[posKey(trade.AccountID, trade.Symbol)]
+	e.posStateMu.RUnlock()
+	if open && existing.Side == string(positionSide) {
+		e.addPositionEntry(ctx, existing, trade, entryPrice)
+		return
+	}
+
 	// Compute hard stop price at entry (circuit-breaker, immutable for lifetime of position).
-	hardStop := risk.ComputeHardStop(signal.Price, string(positionSide), leverage, string(marketType))
+	hardStop := risk.ComputeHardStop(entryPrice, string(positionSide), leverage, string(marketType))
+
+	var strategy string
+	if trade.Strategy != nil {
+		strategy = *trade.Strategy
+	}
 
 	// Persist position state.
 	dbState := &EnginePositionState{
-		AccountID:   accountID,
-		Symbol:      product,
+		AccountID:   trade.AccountID,
+		Symbol:      trade.Symbol,
 		MarketType:  string(marketType),
+		Exchange:    venue,
 		Side:        string(positionSide),
-		EntryPrice:  signal.Price,
+		EntryPrice:  entryPrice,
 		HardStop:    hardStop,
 		Leverage:    leverage,
 		Strategy:    strategy,
 		Granularity: tc.Granularity,
-		OpenedAt:    now,
+		OpenedAt:    trade.Timestamp,
+		Quantity:    trade.Quantity,
+		Entries:     []PositionEntry{{Price: entryPrice, Quantity: trade.Quantity, Fee: trade.Fee, At: trade.Timestamp}},
 	}
-	if sl != nil {
-		dbState.StopLoss = *sl
+	if trade.StopLoss != nil {
+		dbState.StopLoss = *trade.StopLoss
 	}
-	if tp != nil {
-		dbState.TakeProfit = *tp
+	if trade.TakeProfit != nil {
+		dbState.TakeProfit = *trade.TakeProfit
 	}
+	dbState.Ladder = buildLadder(tc.TakeProfitLadder, dbState.Side, entryPrice, dbState.StopLoss)
+	dbState.BreakEvenR = tc.BreakEvenR
+
+	// Live positions also get exchange-side exits so they stay protected if
+	// the engine goes down.
+	e.placeProtectiveOrders(ctx, dbState)
 
 	if err := e.repo.InsertPositionState(ctx, tenantID, dbState); err != nil {
 		logger.Error().Err(err).Msg("failed to persist position state")
 	} else {
-		ps := &PositionState{
-			AccountID:   dbState.AccountID,
-			Symbol:      dbState.Symbol,
-			MarketType:  dbState.MarketType,
-			Side:        dbState.Side,
-			EntryPrice:  dbState.EntryPrice,
-			StopLoss:    dbState.StopLoss,
-			TakeProfit:  dbState.TakeProfit,
-			HardStop:    dbState.HardStop,
-			Leverage:    dbState.Leverage,
-			Strategy:    dbState.Strategy,
-			Granularity: dbState.Granularity,
-			OpenedAt:    dbState.OpenedAt,
-		}
 		e.posStateMu.Lock()
-		e.posState[posKey(accountID, product)] = ps
+		e.posState[posKey(trade.AccountID, trade.Symbol)] = positionStateFrom(dbState)
+		e.posStateMu.Unlock()
+	}
+}
+
+// addPositionEntry layers a scale-in fill into an open position. The entry
+// price becomes the quantity-weighted average of all layers and the hard stop
+// is recomputed from it, so the risk loop evaluates the blended position.
+func (e *Engine) addPositionEntry(ctx context.Context, ps *PositionState, trade *domain.Trade, fillPrice float64) {
+	logger := e.logger.With().
+		Str("account", ps.AccountID).
+		Str("product", ps.Symbol).
+		Logger()
+
+	e.posStateMu.Lock()
+	oldStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, ps.TrailingStop)
+	ps.Entries = append(ps.Entries, PositionEntry{Price: fillPrice, Quantity: trade.Quantity, Fee: trade.Fee, At: trade.Timestamp})
+	ps.Quantity += trade.Quantity
+	ps.EntryPrice = weightedEntryPrice(ps.Entries)
+	ps.HardStop = risk.ComputeHardStop(ps.EntryPrice, ps.Side, ps.Leverage, ps.MarketType)
+	newStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, ps.TrailingStop)
+	e.posStateMu.Unlock()
+
+	if newStop != oldStop {
+		id := e.replaceProtectiveStop(ctx, ps, newStop)
+		e.posStateMu.Lock()
+		ps.StopOrderID = id
 		e.posStateMu.Unlock()
 	}
+
+	e.posStateMu.RLock()
+	rec := ps.record()
+	e.posStateMu.RUnlock()
+	if err := e.repo.UpdatePositionState(ctx, e.tenantID(), rec); err != nil {
+		logger.Warn().Err(err).Msg("failed to persist scale-in")
+	}
+	logger.Info().
+		Int("layers", len(rec.Entries)).
+		Float64("fill_price", fillPrice).
+		Float64("added_qty", trade.Quantity).
+		Float64("qty", rec.Quantity).
+		Float64("entry_price", rec.EntryPrice).
+		Float64("hard_stop", rec.HardStop).
+		Msg("scaled into position")
+}
+
+// weightedEntryPrice returns the quantity-weighted average price of entries.
+func weightedEntryPrice(entries []PositionEntry) float64 {
+	var qty, notional float64
+	for _, en := range entries {
+		qty += en.Quantity
+		notional += en.Price * en.Quantity
+	}
+	if qty <= 0 {
+		return 0
+	}
+	return notional / qty
 }
 
 // handleCloseSignal handles SELL and COVER signals for a specific account.
-func (e *Engine) handleCloseSignal(ctx context.Context, signal SignalPayload, product, strategy, accountID string, tc *TradingConfig) {
+func (e *Engine) handleCloseSignal(ctx context.Context, signal SignalPayload, product, strategy, accountID string, tc *TradingConfig) error {
 	logger := e.logger.With().
 		Str("account", accountID).
 		Str("product", product).
@@ -373,14 +605,22 @@ func (e *Engine) handleCloseSignal(ctx context.Context, signal SignalPayload, pr
 
 	if !exists {
 		logger.Debug().Msg("no open position state for account+product, ignoring close signal")
-		return
+		return nil
 	}
 
+	// close_pct in (0, 1) closes that fraction; anything else closes it all.
+	fraction := 1.0
+	msg := "closing position on signal"
+	if signal.ClosePct > 0 && signal.ClosePct < 1 {
+		fraction = signal.ClosePct
+		msg = "partially closing position on signal"
+	}
 	logger.Info().
 		Str("position_side", ps.Side).
 		Float64("entry_price", ps.EntryPrice).
+		Float64("close_pct", fraction).
 		Str("mode", e.cfg.TradingMode).
-		Msg("closing position on signal")
+		Msg(msg)
 
 	// Use the strategy-supplied reason if present; otherwise fall back to the
 	// canonical Layer 3 conviction-drop label.
@@ -388,7 +628,10 @@ func (e *Engine) handleCloseSignal(ctx context.Context, signal SignalPayload, pr
 	if signal.Reason != "" {
 		exitReason = signal.Reason
 	}
-	e.executeCloseTrade(ctx, ps, signal.Price, exitReason)
+	if !e.executePartialClose(ctx, ps, signal.Price, exitReason, fraction, signal.ID) {
+		return fmt.Errorf("close of %s for %s not recorded", product, accountID)
+	}
+	return nil
 }
 
 // mapSignalToSide maps a signal action to trade side, position side, and market type.
@@ -449,12 +692,56 @@ func (e *Engine) scaledPct(basePct, balance float64) float64 {
 	return pct
 }
 
+// Risk-based position sizing, selected per trading config with
+// sizing_mode "risk"; any other mode sizes from POSITION_SIZE_PCT.
+const (
+	sizingRisk = "risk"
+
+	defaultRiskPct         = 1.0 // % of equity risked per trade in risk mode
+	defaultATRStopMultiple = 2.0 // stop distance in ATRs when the signal has no stop
+)
+
+// riskSizedPct returns the position percentage of equity at which a move from
+// the signal price to the stop loses the trading config's risk_pct of equity.
+//
+// The stop distance is, in order:
+//   - the signal's stop_loss, when it is on the losing side and > 0.1% from entry
+//   - atr_stop_multiple × the signal's "atr" indicator
+//   - defaultSLPct of entry, the stop the risk loop falls back to
+func riskSizedPct(signal SignalPayload, tc *TradingConfig) float64 {
+	if signal.Price <= 0 {
+		return 0
+	}
+	riskPct := tc.RiskPct
+	if riskPct <= 0 {
+		riskPct = defaultRiskPct
+	}
+	multiple := tc.ATRStopMultiple
+	if multiple <= 0 {
+		multiple = defaultATRStopMultiple
+	}
+
+	sl := signal.StopLoss
+	onLosingSide := (signal.Action == "SHORT" && sl > signal.Price) || (signal.Action != "SHORT" && sl > 0 && sl < signal.Price)
+	var distance float64
+	switch atr := signal.Indicators["atr"]; {
+	case onLosingSide && math.Abs(signal.Price-sl) > signal.Price*0.001:
+		distance = math.Abs(signal.Price - sl)
+	case atr > 0:
+		distance = atr * multiple
+	default:
+		distance = signal.Price * defaultSLPct
+	}
+	return riskPct * signal.Price / distance
+}
+
 // calculatePositionSize returns (size, quantity, margin, error).
 //
 // Sizing logic (in order):
 //  1. Base: live account balance when available; PortfolioSize as fallback.
 //  2. Percentage: progressively scaled from PositionSizePct (at PortfolioSize)
-//     up to PositionSizeMaxPct (at PortfolioSize/10) for small accounts.
+//     up to PositionSizeMaxPct (at PortfolioSize/10) for small accounts, or
+//     in risk mode the percentage that risks risk_pct of equity to the stop.
 //     Signal-provided PositionPct overrides the config PCT directly (no scaling).
 //  3. Clamp: applied against [MinPositionSize, MaxPositionSize] when set.
 //  4. Hard cap: position never exceeds available balance.
@@ -467,10 +754,14 @@ func (e *Engine) calculatePositionSize(signal SignalPayload, tc *TradingConfig,
 	}
 
 	var pct float64
-	if signal.PositionPct > 0 {
+	switch {
+	case signal.PositionPct > 0:
 		// Explicit signal override — honour as-is, no progressive scaling.
 		pct = signal.PositionPct * 100 // signal uses 0–1 fraction
-	} else {
+	case tc.SizingMode == sizingRisk:
+		// Volatility-targeted: wider stops give smaller positions.
+		pct = riskSizedPct(signal, tc)
+	default:
 		// Apply progressive scaling: higher % for small accounts, lower for large.
 		pct = e.scaledPct(e.cfg.PositionSizePct, base)
 	}
@@ -538,40 +829,81 @@ func (e *Engine) checkBalance(ctx context.Context, tenantID uuid.UUID, accountID
 	return nil
 }
 
-// executeOpenTrade executes an open trade in paper or live mode.
-func (e *Engine) executeOpenTrade(ctx context.Context, signal SignalPayload, trade *domain.Trade, positionSide domain.PositionSide) error {
-	tenantID := e.tenantID()
-
-	if e.cfg.TradingMode == "live" {
+// executeOpenTrade submits the entry order and records the fill in the ledger.
+//
+// Paper-mode market entries are filled at the signal price without touching
+// the exchange. Limit, post-only and stop entries always go through the
+// exchange (NoopExchange simulates them in paper mode); when the order is left
+// resting the returned result is Pending() and nothing is written to the
+// ledger until the fill is observed by checkPendingEntries.
+func (e *Engine) executeOpenTrade(ctx context.Context, ex Exchange, signal SignalPayload, trade *domain.Trade, positionSide domain.PositionSide) (*OrderResult, error) {
+	orderType := parseOrderType(e.cfg.EntryOrderType)
+
+	var result *OrderResult
+	if e.cfg.TradingMode == "live" || orderType != OrderTypeMarket {
+		limit, stop := entryPrices(positionSide, signal.Price, e.cfg.EntryOffsetBps)
 		req := OpenPositionRequest{
-			Symbol:    trade.Symbol,
-			Side:      positionSide,
-			SizeUSD:   signal.Price * trade.Quantity,
-			Leverage:  0,
-			Price:     signal.Price,
+			Symbol:      trade.Symbol,
+			Side:        positionSide,
+			SizeUSD:     signal.Price * trade.Quantity,
+			Leverage:    0,
+			Price:       signal.Price,
+			OrderType:   orderType,
+			LimitPrice:  limit,
+			StopPrice:   stop,
+			TimeInForce: TimeInForce(e.cfg.EntryTimeInForce),
 		}
 		if trade.Leverage != nil {
 			req.Leverage = *trade.Leverage
 		}
-		result, err := e.exchange.OpenPosition(ctx, req)
+		var err error
+		result, err = ex.OpenPosition(ctx, req)
 		if err != nil {
-			return fmt.Errorf("exchange open position: %w", err)
+			return nil, fmt.Errorf("exchange open position: %w", err)
 		}
-		trade.Price = result.FillPrice
-		trade.Quantity = result.Quantity
-		trade.Fee = result.Fee
-		if trade.Margin != nil && result.Margin > 0 {
-			m := result.Margin
-			trade.Margin = &m
+		if result.Pending() {
+			return result, nil
 		}
+		if result.Status == domain.OrderStatusCancelled && result.Quantity <= 0 {
+			return nil, fmt.Errorf("entry order was not filled (%s)", orderType)
+		}
+		applyFill(trade, result)
+	}
+
+	return result, e.recordOpenTrade(ctx, trade)
+}
+
+// entryPrices returns the limit and stop prices of an entry at price, offset
+// by offsetBps basis points: a limit rests below the market for a long and
+// above it for a short, a stop triggers on the breakout side.
+func entryPrices(side domain.PositionSide, price, offsetBps float64) (limit, stop float64) {
+	off := price * offsetBps / 10000
+	if side == domain.PositionSideShort {
+		off = -off
 	}
+	return price - off, price + off
+}
 
+// applyFill copies exchange fill details onto an entry trade.
+func applyFill(trade *domain.Trade, result *OrderResult) {
+	trade.Price = result.FillPrice
+	trade.Quantity = result.Quantity
+	trade.Fee = result.Fee
+	if trade.Margin != nil && result.Margin > 0 {
+		m := result.Margin
+		trade.Margin = &m
+	}
+}
+
+// recordOpenTrade writes a filled entry trade to the ledger and fans it out
+// to stream subscribers.
+func (e *Engine) recordOpenTrade(ctx context.Context, trade *domain.Trade) error {
 	// Compute cost basis for buys.
 	if trade.Side == domain.SideBuy {
 		trade.CostBasis = trade.Quantity*trade.Price + trade.Fee
 	}
 
-	inserted, err := e.repo.InsertTradeAndUpdatePosition(ctx, tenantID, trade)
+	inserted, err := e.submitTrade(ctx, trade)
 	if err != nil {
 		if e.cfg.TradingMode == "live" {
 			log.Error().
@@ -611,60 +943,119 @@ func (e *Engine) executeOpenTrade(ctx context.Context, signal SignalPayload, tra
 	return nil
 }
 
-// executeCloseTrade executes a close trade.
-func (e *Engine) executeCloseTrade(ctx context.Context, ps *PositionState, currentPrice float64, exitReason string) {
+// executeCloseTrade closes the whole position. Reports whether the close
+// trade was recorded.
+func (e *Engine) executeCloseTrade(ctx context.Context, ps *PositionState, currentPrice float64, exitReason string) bool {
+	return e.executePartialClose(ctx, ps, currentPrice, exitReason, 1, "")
+}
+
+// executePartialClose closes fraction (0, 1] of the position. A partial close
+// keeps the position state, scaling every entry layer down pro rata so the
+// weighted entry price is unchanged; exchange-side exits stay in place since
+// they close whatever remains. signalID, when the close answers a signal,
+// makes the trade ID deterministic. Reports whether the close trade was
+// recorded.
+func (e *Engine) executePartialClose(ctx context.Context, ps *PositionState, currentPrice float64, exitReason string, fraction float64, signalID string) bool {
 	logger := e.logger.With().
 		Str("account", ps.AccountID).
 		Str("symbol", ps.Symbol).
 		Str("exit_reason", exitReason).
 		Logger()
-	tenantID := e.tenantID()
-
-	// Determine close side (opposite of open side).
-	var side domain.Side
-	if ps.Side == "long" {
-		side = domain.SideSell
-	} else {
-		side = domain.SideBuy
-	}
-
 	marketType := domain.MarketType(ps.MarketType)
-	now := time.Now().UTC()
 
 	// Load current open position to get quantity.
-	openPositions, err := e.repo.ListOpenPositionsForAccount(ctx, ps.AccountID)
+	qty, err := e.openQuantity(ctx, ps)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to load open positions for close")
-		return
-	}
-
-	var qty float64
-	for _, p := range openPositions {
-		if p.Symbol == ps.Symbol && string(p.MarketType) == ps.MarketType {
-			qty = p.Quantity
-			break
-		}
+		return false
 	}
 	if qty <= 0 {
 		logger.Warn().Msg("no open position quantity found, skipping close")
-		return
+		return false
+	}
+	partial := fraction > 0 && fraction < 1
+	if partial {
+		qty *= fraction
 	}
 
+	var fee float64
 	if e.cfg.TradingMode == "live" {
+		ex, err := e.positionExchange(ps.AccountID, ps.Exchange, ps.MarketType, ps.Symbol)
+		if err != nil {
+			logger.Error().Err(err).Str("venue", ps.Exchange).Msg("no exchange adapter for venue — cannot close position")
+			return false
+		}
 		req := ClosePositionRequest{
 			Symbol:     ps.Symbol,
 			Side:       domain.PositionSide(ps.Side),
 			MarketType: marketType,
+			Quantity:   qty,
+		}
+		if partial {
+			req.Fraction = fraction
 		}
-		result, err := e.exchange.ClosePosition(ctx, req)
+		result, err := ex.ClosePosition(ctx, req)
 		if err != nil {
 			logger.Error().Err(err).Msg("exchange close position failed")
-			return
+			return false
+		}
+		// A close cancelled after a partial fill sold only part of the
+		// position; the rest stays open and managed.
+		if !partial && result.Status == domain.OrderStatusCancelled && result.Quantity < qty {
+			logger.Warn().Float64("filled", result.Quantity).Float64("qty", qty).
+				Msg("close order only partially filled — keeping the remainder open")
+			fraction, partial = result.Quantity/qty, true
 		}
 		currentPrice = result.FillPrice
 		qty = result.Quantity
+		fee = result.Fee
+
+		// The position is flat — remove the exchange-side exits.
+		if !partial {
+			e.cancelProtectiveOrders(ctx, ps)
+		}
+	}
+
+	return e.recordCloseTrade(ctx, ps, qty, currentPrice, fee, exitReason, fraction, partial, signalID)
+}
+
+// openQuantity returns the ledger quantity of the position ps tracks, or 0
+// when the ledger has no such open position.
+func (e *Engine) openQuantity(ctx context.Context, ps *PositionState) (float64, error) {
+	openPositions, err := e.repo.ListOpenPositionsForAccount(ctx, ps.AccountID)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range openPositions {
+		if p.Symbol == ps.Symbol && string(p.MarketType) == ps.MarketType {
+			return p.Quantity, nil
+		}
+	}
+	return 0, nil
+}
+
+// recordCloseTrade records the close of qty at price in the ledger, then
+// scales the position state down by fraction for a partial close or forgets
+// it for a full one. Reports whether the trade was recorded.
+func (e *Engine) recordCloseTrade(ctx context.Context, ps *PositionState, qty, currentPrice, fee float64, exitReason string, fraction float64, partial bool, signalID string) bool {
+	logger := e.logger.With().
+		Str("account", ps.AccountID).
+		Str("symbol", ps.Symbol).
+		Str("exit_reason", exitReason).
+		Logger()
+	tenantID := e.tenantID()
+
+	// Determine close side (opposite of open side).
+	var side domain.Side
+	if ps.Side == "long" {
+		side = domain.SideSell
+	} else {
+		side = domain.SideBuy
 	}
 
+	marketType := domain.MarketType(ps.MarketType)
+	now := time.Now().UTC()
+
 	var leveragePtr *int
 	if ps.Leverage > 0 {
 		v := ps.Leverage
@@ -679,13 +1070,13 @@ func (e *Engine) executeCloseTrade(ctx context.Context, ps *PositionState, curre
 
 	trade := &domain.Trade{
 		TenantID:    tenantID,
-		TradeID:     fmt.Sprintf("engine-close-%s-%s-%d", ps.AccountID, ps.Symbol, now.UnixNano()),
+		TradeID:     engineTradeID("close", ps.AccountID, ps.Symbol, signalID, now),
 		AccountID:   ps.AccountID,
 		Symbol:      ps.Symbol,
 		Side:        side,
 		Quantity:    qty,
 		Price:       currentPrice,
-		Fee:         0,
+		Fee:         fee,
 		FeeCurrency: "USD",
 		MarketType:  marketType,
 		Timestamp:   now,
@@ -702,10 +1093,9 @@ func (e *Engine) executeCloseTrade(ctx context.Context, ps *PositionState, curre
 	}
 	costBasisForTrade(trade, avgEntry)
 
-	_, err = e.repo.InsertTradeAndUpdatePosition(ctx, tenantID, trade)
-	if err != nil {
+	if _, err := e.submitTrade(ctx, trade); err != nil {
 		logger.Error().Err(err).Msg("failed to record close trade")
-		return
+		return false
 	}
 
 	pnl := (currentPrice - ps.EntryPrice) * qty
@@ -725,12 +1115,21 @@ func (e *Engine) executeCloseTrade(ctx context.Context, ps *PositionState, curre
 	if ps.Strategy != "" {
 		ev = ev.Str("strategy", ps.Strategy)
 	}
-	ev.Msg("position closed")
+	if partial {
+		ev.Float64("close_pct", fraction).Msg("position partially closed")
+	} else {
+		ev.Msg("position closed")
+	}
 
 	if e.publisher != nil {
 		e.publisher.Publish(trade.AccountID, trade)
 	}
 
+	if partial {
+		e.reducePositionState(ctx, ps, fraction)
+		return true
+	}
+
 	// Clean up position state.
 	if err := e.repo.DeletePositionState(ctx, tenantID, ps.Symbol, ps.MarketType, ps.AccountID); err != nil {
 		logger.Warn().Err(err).Msg("failed to delete position state")
@@ -743,6 +1142,44 @@ func (e *Engine) executeCloseTrade(ctx context.Context, ps *PositionState, curre
 	e.conflictMu.Lock()
 	delete(e.conflict, posKey(ps.AccountID, ps.Symbol))
 	e.conflictMu.Unlock()
+	return true
+}
+
+// reducePositionState scales an open position's quantity and entry layers
+// down after a partial close of fraction.
+func (e *Engine) reducePositionState(ctx context.Context, ps *PositionState, fraction float64) {
+	keep := 1 - fraction
+	e.posStateMu.Lock()
+	ps.Quantity *= keep
+	for i := range ps.Entries {
+		ps.Entries[i].Quantity *= keep
+		ps.Entries[i].Fee *= keep
+	}
+	rec := ps.record()
+	e.posStateMu.Unlock()
+
+	if err := e.repo.UpdatePositionState(ctx, e.tenantID(), rec); err != nil {
+		e.logger.Warn().Err(err).
+			Str("account", ps.AccountID).
+			Str("symbol", ps.Symbol).
+			Msg("failed to persist partial close")
+	}
+}
+
+// engineTradeID builds the ledger trade ID for an engine trade. Trades placed
+// for a signal derive it from the signal ID, so a duplicate delivery produces
+// the same ID and the ledger rejects the second trade; other trades use the
+// current time.
+func engineTradeID(kind, accountID, symbol, signalID string, now time.Time) string {
+	prefix := "engine-"
+	if kind != "" {
+		prefix += kind + "-"
+	}
+	suffix := signalID
+	if suffix == "" {
+		suffix = strconv.FormatInt(now.UnixNano(), 10)
+	}
+	return prefix + accountID + "-" + symbol + "-" + suffix
 }
 
 // killSwitchActive returns true if the kill switch file exists.
@@ -756,25 +1193,26 @@ func (e *Engine) killSwitchActive() bool {
 
 // isDailyLossLimitReached reads today's realised P&L from Firestore (via the
 // EngineStore) and returns true when total losses exceed cfg.DailyLossLimit.
+// With DAILY_LOSS_INCLUDE_UNREALIZED the account's open positions, marked to
+// market, count too.
 //
 // Using Firestore (rather than an in-memory counter) means:
 //   - The limit survives engine restarts.
 //   - Daily P&L is atomically incremented after each close, so concurrent writes
 //     are safe and no increments are lost.
 func (e *Engine) isDailyLossLimitReached(ctx context.Context, accountID string) bool {
-	pnl, err := e.repo.DailyRealizedPnL(ctx, accountID)
+	loss, err := e.dailyLoss(ctx, accountID, e.cfg.DailyLossIncludeUnrealized)
 	if err != nil {
-		e.logger.Warn().Err(err).Msg("daily loss check: DB query failed, allowing trade")
-		return false
-	}
-	// pnl is negative when there are net losses.
-	loss := -pnl
-	if loss < 0 {
-		loss = 0
+		if loss == 0 {
+			e.logger.Warn().Err(err).Msg("daily loss check: DB query failed, allowing trade")
+			return false
+		}
+		e.logger.Warn().Err(err).Msg("daily loss check: unrealised P&L unavailable, using realised loss")
 	}
 	if loss >= e.cfg.DailyLossLimit {
 		e.logger.Warn().
 			Float64("loss_today", loss).
+			Bool("includes_unrealized", e.cfg.DailyLossIncludeUnrealized).
 			Float64("limit", e.cfg.DailyLossLimit).
 			Msg("daily loss limit reached")
 		return true
@@ -786,3 +1224,12 @@ func (e *Engine) isDailyLossLimitReached(ctx context.Context, accountID string)
 func (e *Engine) tenantID() uuid.UUID {
 	return e.tenantUUID
 }
+
+// TenantID returns the tenant the engine trades for, or uuid.Nil until Start
+// has resolved it. Safe to call from any goroutine.
+func (e *Engine) TenantID() uuid.UUID {
+	if id := e.tenantReady.Load(); id != nil {
+		return *id
+	}
+	return uuid.Nil
+}
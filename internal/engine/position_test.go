@@ -16,6 +16,12 @@ func makeEngine(cfg *config.Config) *Engine {
 		cooldown:  make(map[cooldownKey]time.Time),
 		conflict:  make(map[string]string),
 		lastPrice: make(map[string]float64),
+		prices:    newPriceHistory(cfg.CorrelationInterval, cfg.CorrelationWindow),
+		pending:   make(map[string]*pendingEntry),
+		breakers:  make(map[breakerKey]*BreakerState),
+		flattened: make(map[string]string),
+		pauses:    make(map[pauseKey]*PauseState),
+		signals:   make(map[string]SignalRecord),
 	}
 }
 
@@ -306,6 +312,78 @@ func TestCalcSize_FuturesShortUsesShortLeverage(t *testing.T) {
 	assertFloat(t, "margin (short leverage=5)", 200, margin)
 }
 
+// ── risk-based sizing ─────────────────────────────────────────────────────────
+
+func TestRiskSizedPct_StopDistance(t *testing.T) {
+	tc := &TradingConfig{SizingMode: "risk", RiskPct: 1, ATRStopMultiple: 2}
+	atr := map[string]float64{"atr": 1000}
+
+	tests := []struct {
+		name   string
+		signal SignalPayload
+		want   float64
+	}{
+		// 1% risk with a 2% stop → a 50% position.
+		{"signal stop", SignalPayload{Action: "BUY", Price: 50000, StopLoss: 49000, Indicators: atr}, 50},
+		{"short stop above entry", SignalPayload{Action: "SHORT", Price: 50000, StopLoss: 51000}, 50},
+		// 2 × ATR 1000 = a 4% stop → 25%.
+		{"atr when no stop", SignalPayload{Action: "BUY", Price: 50000, Indicators: atr}, 25},
+		{"atr when stop on wrong side", SignalPayload{Action: "BUY", Price: 50000, StopLoss: 51000, Indicators: atr}, 25},
+		{"atr when stop too tight", SignalPayload{Action: "BUY", Price: 50000, StopLoss: 49990, Indicators: atr}, 25},
+		// Neither: the 4% default stop → 25%.
+		{"default stop", SignalPayload{Action: "BUY", Price: 50000}, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFloat(t, "pct", tt.want, riskSizedPct(tt.signal, tc))
+		})
+	}
+}
+
+func TestRiskSizedPct_Defaults(t *testing.T) {
+	// risk_pct 1% and 2 × ATR when the config leaves them unset.
+	signal := SignalPayload{Action: "BUY", Price: 100, Indicators: map[string]float64{"atr": 1}}
+	assertFloat(t, "pct", 50, riskSizedPct(signal, &TradingConfig{SizingMode: "risk"}))
+}
+
+func TestCalcSize_RiskModeSizesFromStop(t *testing.T) {
+	cfg := &config.Config{PortfolioSize: 10000, PositionSizePct: 10}
+	e := makeEngine(cfg)
+	tc := &TradingConfig{SizingMode: "risk", RiskPct: 0.5, LongLeverage: 1}
+	signal := SignalPayload{Action: "BUY", Price: 100, StopLoss: 95}
+	bal := 4000.0
+
+	size, qty, _, err := e.calculatePositionSize(signal, tc, domain.MarketTypeSpot, &bal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Risk $20 (0.5% of $4,000) over a $5 stop → 4 units, $400.
+	assertFloat(t, "size", 400, size)
+	assertFloat(t, "qty", 4, qty)
+}
+
+func TestCalcSize_RiskModeStillClamped(t *testing.T) {
+	cfg := &config.Config{PortfolioSize: 10000, MaxPositionSize: 3000}
+	e := makeEngine(cfg)
+	tc := &TradingConfig{SizingMode: "risk", RiskPct: 1, LongLeverage: 1}
+	// A 0.2% stop would size at 500% of equity.
+	signal := SignalPayload{Action: "BUY", Price: 1000, StopLoss: 998}
+
+	size, _, _, err := e.calculatePositionSize(signal, tc, domain.MarketTypeSpot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "size (clamped to max)", 3000, size)
+
+	cfg.MaxPositionSize = 0
+	bal := 2500.0
+	size, _, _, err = e.calculatePositionSize(signal, tc, domain.MarketTypeSpot, &bal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat(t, "size (capped to balance)", 2500, size)
+}
+
 // ── helpers ───────────────────────────────────────────────────────────────────
 
 func assertEq(t *testing.T, name, want, got string) {
@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/Signal-ngn/risk"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// protectiveExchange returns the adapter holding a position as a
+// ProtectiveOrderExchange when exchange-side exits apply: live mode on a
+// venue that supports them.
+func (e *Engine) protectiveExchange(accountID, venue, marketType, symbol string) (ProtectiveOrderExchange, bool) {
+	if e.cfg.TradingMode != "live" {
+		return nil, false
+	}
+	ex, err := e.positionExchange(accountID, venue, marketType, symbol)
+	if err != nil {
+		return nil, false
+	}
+	pex, ok := ex.(ProtectiveOrderExchange)
+	return pex, ok
+}
+
+// protectiveStopPrice returns the tightest of the hard stop, stop loss and
+// trailing stop for a position side — the level the in-process risk loop
+// would exit at first. Returns 0 when none is set.
+func protectiveStopPrice(side string, hardStop, stopLoss, trailingStop float64) float64 {
+	var stop float64
+	for _, p := range []float64{hardStop, stopLoss, trailingStop} {
+		if p <= 0 {
+			continue
+		}
+		switch {
+		case stop == 0:
+			stop = p
+		case side == "short" && p < stop:
+			stop = p
+		case side != "short" && p > stop:
+			stop = p
+		}
+	}
+	return stop
+}
+
+// protectiveTakeProfitPrice returns the take-profit trigger for a position, or
+// 0 when none applies. Mirrors risk.Evaluate: ML strategies exit via the
+// trailing stop and never use a fixed take-profit.
+func protectiveTakeProfitPrice(strategy string, takeProfit float64) float64 {
+	if risk.IsMLStrategy(strategy) {
+		return 0
+	}
+	return takeProfit
+}
+
+// placeProtectiveOrders places the exchange-side stop and take-profit orders
+// for a newly opened position and records their IDs on s. Failures are logged
+// and leave the position protected by the risk loop only.
+func (e *Engine) placeProtectiveOrders(ctx context.Context, s *EnginePositionState) {
+	pex, ok := e.protectiveExchange(s.AccountID, s.Exchange, s.MarketType, s.Symbol)
+	if !ok {
+		return
+	}
+	logger := e.logger.With().
+		Str("account", s.AccountID).
+		Str("symbol", s.Symbol).
+		Logger()
+
+	if stop := protectiveStopPrice(s.Side, s.HardStop, s.StopLoss, s.TrailingStop); stop > 0 {
+		id, err := pex.PlaceProtectiveOrder(ctx, ProtectiveOrderRequest{
+			Symbol:       s.Symbol,
+			Side:         domain.PositionSide(s.Side),
+			Kind:         ProtectiveStop,
+			TriggerPrice: stop,
+		})
+		if err != nil {
+			logger.Error().Err(err).Float64("stop", stop).Msg("failed to place exchange stop order — position protected by risk loop only")
+		} else {
+			s.StopOrderID = id
+			logger.Info().Str("order_id", id).Float64("stop", stop).Msg("exchange stop order placed")
+		}
+	}
+
+	// A take-profit ladder exits in stages from the risk loop; a
+	// close-position order would take the whole position at once.
+	if tp := protectiveTakeProfitPrice(s.Strategy, s.TakeProfit); tp > 0 && len(s.Ladder) == 0 {
+		id, err := pex.PlaceProtectiveOrder(ctx, ProtectiveOrderRequest{
+			Symbol:       s.Symbol,
+			Side:         domain.PositionSide(s.Side),
+			Kind:         ProtectiveTakeProfit,
+			TriggerPrice: tp,
+		})
+		if err != nil {
+			logger.Error().Err(err).Float64("take_profit", tp).Msg("failed to place exchange take-profit order")
+		} else {
+			s.TakeProfitOrderID = id
+			logger.Info().Str("order_id", id).Float64("take_profit", tp).Msg("exchange take-profit order placed")
+		}
+	}
+}
+
+// replaceProtectiveStop moves the exchange-side stop for ps to stopPrice and
+// returns the new stop order ID. Binance allows a single closePosition stop
+// per position side, so the old order is cancelled before the new one is
+// placed. If the cancel fails the old ID is returned unchanged; if the new
+// order fails "" is returned and the next stop move places a fresh order.
+func (e *Engine) replaceProtectiveStop(ctx context.Context, ps *PositionState, stopPrice float64) string {
+	return e.replaceProtectiveOrder(ctx, ps, ProtectiveStop, ps.StopOrderID, stopPrice)
+}
+
+// replaceProtectiveTakeProfit moves the exchange-side take-profit for ps to
+// takeProfit the same way replaceProtectiveStop moves the stop.
+func (e *Engine) replaceProtectiveTakeProfit(ctx context.Context, ps *PositionState, takeProfit float64) string {
+	return e.replaceProtectiveOrder(ctx, ps, ProtectiveTakeProfit, ps.TakeProfitOrderID, takeProfit)
+}
+
+// replaceProtectiveOrder cancels orderID and places a kind order at price.
+func (e *Engine) replaceProtectiveOrder(ctx context.Context, ps *PositionState, kind ProtectiveOrderKind, orderID string, price float64) string {
+	pex, ok := e.protectiveExchange(ps.AccountID, ps.Exchange, ps.MarketType, ps.Symbol)
+	if !ok {
+		return orderID
+	}
+	logger := e.logger.With().
+		Str("account", ps.AccountID).
+		Str("symbol", ps.Symbol).
+		Str("kind", string(kind)).
+		Float64("trigger", price).
+		Logger()
+
+	if orderID != "" {
+		if err := pex.CancelOrder(ctx, ps.Symbol, orderID); err != nil {
+			logger.Warn().Err(err).Str("order_id", orderID).Msg("failed to cancel exchange protective order — keeping previous order")
+			return orderID
+		}
+	}
+
+	id, err := pex.PlaceProtectiveOrder(ctx, ProtectiveOrderRequest{
+		Symbol:       ps.Symbol,
+		Side:         domain.PositionSide(ps.Side),
+		Kind:         kind,
+		TriggerPrice: price,
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to place replacement exchange protective order — position protected by risk loop only")
+		return ""
+	}
+	logger.Debug().Str("order_id", id).Msg("exchange protective order moved")
+	return id
+}
+
+// cancelProtectiveOrders cancels any exchange-side stop and take-profit orders
+// still resting for ps. Failures are logged; Binance expires closePosition
+// orders on its own once the position is flat.
+func (e *Engine) cancelProtectiveOrders(ctx context.Context, ps *PositionState) {
+	pex, ok := e.protectiveExchange(ps.AccountID, ps.Exchange, ps.MarketType, ps.Symbol)
+	if !ok {
+		return
+	}
+	for _, id := range []string{ps.StopOrderID, ps.TakeProfitOrderID} {
+		if id == "" {
+			continue
+		}
+		if err := pex.CancelOrder(ctx, ps.Symbol, id); err != nil {
+			e.logger.Warn().Err(err).
+				Str("account", ps.AccountID).
+				Str("symbol", ps.Symbol).
+				Str("order_id", id).
+				Msg("failed to cancel exchange protective order")
+		}
+	}
+}
+
+// checkProtectiveFills polls the exchange-side stop and take-profit orders of
+// a live position. Once one has filled the position is flat on the exchange:
+// the close is recorded at the fill price, the other order is cancelled and
+// the state dropped. Reports whether the position was handled, in which case
+// the risk rules are not evaluated this tick.
+func (e *Engine) checkProtectiveFills(ctx context.Context, ps *PositionState) bool {
+	pex, ok := e.protectiveExchange(ps.AccountID, ps.Exchange, ps.MarketType, ps.Symbol)
+	if !ok {
+		return false
+	}
+	e.posStateMu.RLock()
+	stopID, takeProfitID := ps.StopOrderID, ps.TakeProfitOrderID
+	e.posStateMu.RUnlock()
+
+	for _, o := range []struct{ id, other, reason string }{
+		{stopID, takeProfitID, "Exchange stop order filled"},
+		{takeProfitID, stopID, "Exchange take-profit order filled"},
+	} {
+		if o.id == "" {
+			continue
+		}
+		logger := e.logger.With().
+			Str("account", ps.AccountID).
+			Str("symbol", ps.Symbol).
+			Str("order_id", o.id).
+			Logger()
+		res, err := pex.GetOrder(ctx, ps.Symbol, o.id)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to query exchange protective order")
+			continue
+		}
+		if res.Status != domain.OrderStatusFilled {
+			continue
+		}
+
+		e.posStateMu.Lock()
+		if ps.Closing {
+			e.posStateMu.Unlock()
+			return true
+		}
+		ps.Closing = true
+		e.posStateMu.Unlock()
+
+		logger.Info().
+			Float64("fill_price", res.FillPrice).
+			Float64("qty", res.Quantity).
+			Msg("exchange protective order filled — recording close")
+
+		if o.other != "" {
+			if err := pex.CancelOrder(ctx, ps.Symbol, o.other); err != nil {
+				logger.Warn().Err(err).Str("other_order_id", o.other).Msg("failed to cancel remaining exchange protective order")
+			}
+		}
+
+		qty := res.Quantity
+		if qty <= 0 {
+			if qty, err = e.openQuantity(ctx, ps); err != nil {
+				logger.Error().Err(err).Msg("failed to load open positions for close")
+			}
+		}
+		price := res.FillPrice
+		if price <= 0 {
+			e.lastPriceMu.RLock()
+			price = e.lastPrice[ps.Symbol]
+			e.lastPriceMu.RUnlock()
+		}
+		// The order ID keeps the trade ID stable across retries.
+		if qty > 0 && price > 0 && e.recordCloseTrade(ctx, ps, qty, price, res.Fee, o.reason, 1, false, "exchange-"+o.id) {
+			return true
+		}
+
+		// Retry on the next tick.
+		e.posStateMu.Lock()
+		ps.Closing = false
+		e.posStateMu.Unlock()
+		return true
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// ── protectiveStopPrice ───────────────────────────────────────────────────────
+
+func TestProtectiveStopPrice_PicksTightestLevel(t *testing.T) {
+	cases := []struct {
+		name                         string
+		side                         string
+		hardStop, stopLoss, trailing float64
+		want                         float64
+	}{
+		{"long none", "long", 0, 0, 0, 0},
+		{"long hard stop only", "long", 90, 0, 0, 90},
+		{"long stop loss above hard stop", "long", 90, 95, 0, 95},
+		{"long trailing above stop loss", "long", 90, 95, 101, 101},
+		{"short hard stop only", "short", 110, 0, 0, 110},
+		{"short stop loss below hard stop", "short", 110, 105, 0, 105},
+		{"short trailing below stop loss", "short", 110, 105, 99, 99},
+	}
+	for _, tc := range cases {
+		got := protectiveStopPrice(tc.side, tc.hardStop, tc.stopLoss, tc.trailing)
+		assertFloat(t, tc.name, tc.want, got)
+	}
+}
+
+func TestProtectiveTakeProfitPrice_SkipsMLStrategies(t *testing.T) {
+	assertFloat(t, "rule-based", 110, protectiveTakeProfitPrice("macd_rsi", 110))
+	assertFloat(t, "ml", 0, protectiveTakeProfitPrice("ml_xgboost", 110))
+}
+
+// ── engine integration ────────────────────────────────────────────────────────
+
+func liveProtectiveEngine(mock *mockBinanceFuturesClient) *Engine {
+	cfg := &config.Config{TradingMode: "live"}
+	e := makeEngine(cfg)
+	e.exchanges = NewExchangeRegistry()
+	e.exchanges.Register(venueBinance, newTestExchange(mock))
+	return e
+}
+
+func TestPlaceProtectiveOrders_PlacesStopAndTakeProfit(t *testing.T) {
+	mock := &mockBinanceFuturesClient{orderResult: &binanceOrderResult{OrderID: 7, Status: "NEW"}}
+	e := liveProtectiveEngine(mock)
+
+	s := &EnginePositionState{
+		Symbol:     "BTC-USD",
+		MarketType: "futures",
+		Side:       "long",
+		EntryPrice: 100,
+		StopLoss:   95,
+		HardStop:   90,
+		TakeProfit: 110,
+		Strategy:   "macd_rsi",
+	}
+	e.placeProtectiveOrders(context.Background(), s)
+
+	assertEq(t, "StopOrderID", "7", s.StopOrderID)
+	assertEq(t, "TakeProfitOrderID", "7", s.TakeProfitOrderID)
+	p := mock.lastOrder
+	if p.Type != "TAKE_PROFIT_MARKET" || p.StopPrice != "110" || !p.ClosePosition || p.Quantity != "" {
+		t.Errorf("take-profit params: got %+v", p)
+	}
+}
+
+func TestPlaceProtectiveOrder_RoundsTriggerToTickAwayFromPosition(t *testing.T) {
+	mock := &mockBinanceFuturesClient{
+		orderResult: &binanceOrderResult{OrderID: 7, Status: "NEW"},
+		priceFilters: map[string]binancePriceFilter{
+			"BTCUSDT": {tick: 0.1, decimals: 1},
+			"ETHUSDT": {tick: 0.01, decimals: 2},
+		},
+	}
+	ex := newTestExchange(mock)
+	ctx := context.Background()
+
+	cases := []struct {
+		symbol string
+		side   domain.PositionSide
+		kind   ProtectiveOrderKind
+		price  float64
+		want   string
+	}{
+		{"BTC-USD", domain.PositionSideLong, ProtectiveStop, 61234.5678, "61234.5"},
+		{"BTC-USD", domain.PositionSideLong, ProtectiveTakeProfit, 72000.01, "72000.1"},
+		{"BTC-USD", domain.PositionSideLong, ProtectiveStop, 61500.3, "61500.3"},
+		{"ETH-USD", domain.PositionSideShort, ProtectiveStop, 2450.0333, "2450.04"},
+		{"ETH-USD", domain.PositionSideShort, ProtectiveTakeProfit, 2100.129, "2100.12"},
+	}
+	for _, tc := range cases {
+		if _, err := ex.PlaceProtectiveOrder(ctx, ProtectiveOrderRequest{Symbol: tc.symbol, Side: tc.side, Kind: tc.kind, TriggerPrice: tc.price}); err != nil {
+			t.Fatal(err)
+		}
+		assertEq(t, fmt.Sprintf("%s %s %s %g", tc.symbol, tc.side, tc.kind, tc.price), tc.want, mock.lastOrder.StopPrice)
+	}
+	if mock.filterLoads != 1 {
+		t.Errorf("exchangeInfo should be loaded once, got %d loads", mock.filterLoads)
+	}
+}
+
+func TestPlaceProtectiveOrders_PaperModeNoop(t *testing.T) {
+	mock := &mockBinanceFuturesClient{}
+	e := liveProtectiveEngine(mock)
+	e.cfg.TradingMode = "paper"
+
+	s := &EnginePositionState{Symbol: "BTC-USD", MarketType: "futures", Side: "long", HardStop: 90}
+	e.placeProtectiveOrders(context.Background(), s)
+
+	assertEq(t, "StopOrderID", "", s.StopOrderID)
+	assertEq(t, "order type", "", mock.lastOrder.Type)
+}
+
+func TestReplaceProtectiveStop_CancelsThenPlaces(t *testing.T) {
+	mock := &mockBinanceFuturesClient{orderResult: &binanceOrderResult{OrderID: 9, Status: "NEW"}}
+	e := liveProtectiveEngine(mock)
+
+	ps := &PositionState{Symbol: "ETH-USD", MarketType: "futures", Side: string(domain.PositionSideShort), StopOrderID: "5"}
+	id := e.replaceProtectiveStop(context.Background(), ps, 2450)
+
+	assertEq(t, "new order id", "9", id)
+	if mock.cancelledID != 5 {
+		t.Errorf("cancelOrder: want 5, got %d", mock.cancelledID)
+	}
+	p := mock.lastOrder
+	if p.Type != "STOP_MARKET" || p.Side != "BUY" || p.PositionSide != "SHORT" || p.StopPrice != "2450" || p.WorkingType != "MARK_PRICE" {
+		t.Errorf("stop params: got %+v", p)
+	}
+}
+
+func TestCheckProtectiveFills_RecordsFilledStop(t *testing.T) {
+	mock := &mockBinanceFuturesClient{getOrderResult: &binanceOrderResult{OrderID: 11, Status: "FILLED", AvgPrice: 2450, Quantity: 2, Fee: 1.5}}
+	e := liveProtectiveEngine(mock)
+	store := &ladderStore{position: domain.Position{
+		AccountID: "acc", Symbol: "ETH-USD", MarketType: domain.MarketTypeFutures,
+		Side: domain.PositionSideLong, Quantity: 2, AvgEntryPrice: 2500,
+	}}
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	ps := &PositionState{
+		AccountID: "acc", Symbol: "ETH-USD", MarketType: "futures", Side: "long", EntryPrice: 2500,
+		StopOrderID: "11", TakeProfitOrderID: "12",
+	}
+	e.posState[posKey("acc", "ETH-USD")] = ps
+
+	if !e.checkProtectiveFills(context.Background(), ps) {
+		t.Fatal("a filled stop should be handled")
+	}
+	if len(store.trades) != 1 {
+		t.Fatalf("want 1 close trade, got %d", len(store.trades))
+	}
+	tr := store.trades[0]
+	assertFloat(t, "close price", 2450, tr.Price)
+	assertFloat(t, "close qty", 2, tr.Quantity)
+	assertFloat(t, "fee", 1.5, tr.Fee)
+	assertEq(t, "exit reason", "Exchange stop order filled", *tr.ExitReason)
+	if mock.cancelledID != 12 {
+		t.Errorf("take-profit order should be cancelled, got cancel of %d", mock.cancelledID)
+	}
+	if _, ok := e.posState[posKey("acc", "ETH-USD")]; ok {
+		t.Error("position state should be dropped")
+	}
+}
+
+func TestCheckProtectiveFills_OpenOrdersLeavePositionToRiskRules(t *testing.T) {
+	e := liveProtectiveEngine(&mockBinanceFuturesClient{})
+	ps := &PositionState{AccountID: "acc", Symbol: "ETH-USD", MarketType: "futures", Side: "long", StopOrderID: "11"}
+	if e.checkProtectiveFills(context.Background(), ps) {
+		t.Error("a resting stop should not be handled as a fill")
+	}
+}
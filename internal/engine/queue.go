@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// queueLagWarn is the wait after which a queued task is logged as lagging.
+const queueLagWarn = 5 * time.Second
+
+// queueIdleTimeout is how long a worker waits for a task before it exits and
+// removes its queue; the next task for the key starts a new one.
+const queueIdleTimeout = time.Minute
+
+// QueueStats summarises the per-position work queues.
+type QueueStats struct {
+	Workers   int   `json:"workers"`     // (account, symbol) queues with a running worker
+	Queued    int   `json:"queued"`      // tasks waiting across all queues
+	Processed int64 `json:"processed"`   // tasks run since trading started
+	Dropped   int64 `json:"dropped"`     // signals and price checks dropped because their queue was full
+	LastLagMs int64 `json:"last_lag_ms"` // queue wait of the most recent task
+	MaxLagMs  int64 `json:"max_lag_ms"`  // longest queue wait seen
+}
+
+type queuedTask struct {
+	run      func()
+	enqueued time.Time
+}
+
+// symbolQueues gives every (account, symbol) its own bounded FIFO and worker,
+// so signals, pending-entry fills and risk exits for one position run one at
+// a time in arrival order while different positions proceed in parallel.
+// Workers start on first use and stop when ctx is cancelled or after
+// queueIdleTimeout without work, so symbols and accounts no longer traded do
+// not keep a goroutine each.
+type symbolQueues struct {
+	ctx    context.Context
+	depth  int
+	idle   time.Duration
+	logger zerolog.Logger
+
+	mu      sync.Mutex
+	workers map[string]*keyQueue
+	stats   QueueStats
+}
+
+// keyQueue is one key's FIFO. senders counts do calls between looking the
+// queue up and handing over their task; the worker does not exit under them.
+type keyQueue struct {
+	ch      chan queuedTask
+	senders int
+}
+
+func newSymbolQueues(ctx context.Context, depth int, logger zerolog.Logger) *symbolQueues {
+	if depth < 1 {
+		depth = 1
+	}
+	return &symbolQueues{
+		ctx:     ctx,
+		depth:   depth,
+		idle:    queueIdleTimeout,
+		logger:  logger,
+		workers: make(map[string]*keyQueue),
+	}
+}
+
+// queue returns the queue for key, starting its worker if needed.
+// Callers must hold q.mu.
+func (q *symbolQueues) queue(key string) *keyQueue {
+	kq, ok := q.workers[key]
+	if !ok {
+		kq = &keyQueue{ch: make(chan queuedTask, q.depth)}
+		q.workers[key] = kq
+		go q.work(key, kq)
+	}
+	return kq
+}
+
+// submit queues fn for key without blocking. Returns false, and counts the
+// drop, when the queue is full.
+func (q *symbolQueues) submit(key string, fn func()) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case q.queue(key).ch <- queuedTask{run: fn, enqueued: time.Now()}:
+		return true
+	default:
+		q.stats.Dropped++
+		return false
+	}
+}
+
+// do queues fn for key and waits until it has run. Returns early, possibly
+// without running fn, when ctx or the queues' context is cancelled.
+func (q *symbolQueues) do(ctx context.Context, key string, fn func()) {
+	done := make(chan struct{})
+	task := queuedTask{run: func() { defer close(done); fn() }, enqueued: time.Now()}
+	q.mu.Lock()
+	kq := q.queue(key)
+	kq.senders++
+	q.mu.Unlock()
+	var sent bool
+	select {
+	case kq.ch <- task:
+		sent = true
+	case <-ctx.Done():
+	case <-q.ctx.Done():
+	}
+	q.mu.Lock()
+	kq.senders--
+	q.mu.Unlock()
+	if !sent {
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+	case <-q.ctx.Done():
+	}
+}
+
+func (q *symbolQueues) work(key string, kq *keyQueue) {
+	idle := time.NewTimer(q.idle)
+	defer idle.Stop()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-idle.C:
+			// Submitters hold q.mu, so an empty queue with no do call in
+			// flight stays empty until the key is removed.
+			q.mu.Lock()
+			if len(kq.ch) == 0 && kq.senders == 0 {
+				delete(q.workers, key)
+				q.mu.Unlock()
+				return
+			}
+			q.mu.Unlock()
+			idle.Reset(q.idle)
+		case t := <-kq.ch:
+			lag := time.Since(t.enqueued)
+			q.mu.Lock()
+			q.stats.Processed++
+			q.stats.LastLagMs = lag.Milliseconds()
+			if q.stats.LastLagMs > q.stats.MaxLagMs {
+				q.stats.MaxLagMs = q.stats.LastLagMs
+			}
+			q.mu.Unlock()
+			if lag > queueLagWarn {
+				q.logger.Warn().Str("key", key).Dur("lag", lag).Int("backlog", len(kq.ch)).
+					Msg("signal queue lagging")
+			}
+			t.run()
+			idle.Reset(q.idle)
+		}
+	}
+}
+
+func (q *symbolQueues) snapshot() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := q.stats
+	s.Workers = len(q.workers)
+	for _, kq := range q.workers {
+		s.Queued += len(kq.ch)
+	}
+	return s
+}
+
+// serialize runs fn on the queue for key and waits for it. Without queues —
+// before trading starts, or in tests — fn runs inline.
+func (e *Engine) serialize(ctx context.Context, key string, fn func()) {
+	q := e.queues.Load()
+	if q == nil {
+		fn()
+		return
+	}
+	q.do(ctx, key, fn)
+}
+
+// dispatch queues fn for key without waiting for it; without queues fn runs
+// inline. A full queue drops fn, so it is only used for checks the next
+// signal or risk-loop tick repeats.
+func (e *Engine) dispatch(key string, fn func()) {
+	q := e.queues.Load()
+	if q == nil {
+		fn()
+		return
+	}
+	if !q.submit(key, fn) {
+		e.logger.Warn().Str("key", key).Int("depth", e.cfg.SignalQueueDepth).
+			Msg("position queue full — skipping price check")
+	}
+}
+
+// QueueStats reports the state of the per-position work queues. Zero when
+// this instance is not trading.
+func (e *Engine) QueueStats() QueueStats {
+	q := e.queues.Load()
+	if q == nil {
+		return QueueStats{}
+	}
+	return q.snapshot()
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+func TestSymbolQueues_RunsInOrderAndDropsWhenFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newSymbolQueues(ctx, 2, makeEngine(&config.Config{}).logger)
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []int
+	record := func(i int) func() {
+		return func() { mu.Lock(); order = append(order, i); mu.Unlock() }
+	}
+
+	started := make(chan struct{})
+	q.submit("acc:BTC-USD", func() { close(started); <-release })
+	<-started
+	if !q.submit("acc:BTC-USD", record(1)) || !q.submit("acc:BTC-USD", record(2)) {
+		t.Fatal("queue should accept up to its depth")
+	}
+	if q.submit("acc:BTC-USD", record(3)) {
+		t.Fatal("full queue should drop")
+	}
+
+	// Another position is not held up by the blocked one.
+	q.do(ctx, "acc:ETH-USD", func() {})
+
+	close(release)
+	q.do(ctx, "acc:BTC-USD", func() {})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Errorf("want [1 2], got %v", order)
+	}
+	stats := q.snapshot()
+	if stats.Dropped != 1 || stats.Workers != 2 {
+		t.Errorf("stats: %+v", stats)
+	}
+}
+
+func TestSymbolQueues_IdleWorkerExits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newSymbolQueues(ctx, 1, makeEngine(&config.Config{}).logger)
+	q.idle = time.Millisecond
+
+	q.do(ctx, "acc:BTC-USD", func() {})
+	deadline := time.Now().Add(time.Second)
+	for q.snapshot().Workers != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("idle worker should exit and remove its queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ran := false
+	q.do(ctx, "acc:BTC-USD", func() { ran = true })
+	if !ran {
+		t.Error("a new task for the key should start a new worker")
+	}
+}
+
+func TestCheckSymbolPrice_DoesNotWaitOnBusyQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := makeEngine(&config.Config{SignalQueueDepth: 4})
+	q := newSymbolQueues(ctx, 4, e.logger)
+	e.queues.Store(q)
+	e.posState[posKey("acc", "BTC-USD")] = &PositionState{AccountID: "acc", Symbol: "BTC-USD", Side: "long", EntryPrice: 50000}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	q.submit(posKey("acc", "BTC-USD"), func() { close(started); <-release })
+	<-started
+
+	done := make(chan struct{})
+	go func() { e.checkSymbolPrice(ctx, "BTC-USD"); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("price checks should be queued, not waited for")
+	}
+	if got := q.snapshot().Queued; got != 1 {
+		t.Errorf("want the risk evaluation queued behind the busy task, got %d queued", got)
+	}
+	close(release)
+}
+
+// ledgerStore is a concurrency-safe EngineStore holding one ledger position.
+// Trade writes are slowed slightly to widen any check-then-act window.
+type ledgerStore struct {
+	EngineStore
+	mu       sync.Mutex
+	position domain.Position
+	trades   []*domain.Trade
+}
+
+func (s *ledgerStore) GetAccountBalance(_ context.Context, _ uuid.UUID, _, _ string) (*float64, error) {
+	return nil, nil
+}
+
+func (s *ledgerStore) ListOpenPositionsForAccount(_ context.Context, _ string) ([]domain.Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.position.Quantity <= 1e-12 {
+		return nil, nil
+	}
+	return []domain.Position{s.position}, nil
+}
+
+func (s *ledgerStore) InsertTradeAndUpdatePosition(_ context.Context, _ uuid.UUID, trade *domain.Trade) (bool, error) {
+	time.Sleep(2 * time.Millisecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trades = append(s.trades, trade)
+	if trade.Side == domain.SideBuy {
+		s.position = domain.Position{
+			AccountID: trade.AccountID, Symbol: trade.Symbol, MarketType: trade.MarketType,
+			Side: domain.PositionSideLong, Quantity: s.position.Quantity + trade.Quantity, AvgEntryPrice: trade.Price,
+		}
+	} else {
+		s.position.Quantity -= trade.Quantity
+	}
+	return true, nil
+}
+
+func (s *ledgerStore) GetAvgEntryPrice(_ context.Context, _ uuid.UUID, _, _ string, _ domain.MarketType) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.position.AvgEntryPrice, nil
+}
+
+func (s *ledgerStore) InsertPositionState(_ context.Context, _ uuid.UUID, _ *EnginePositionState) error {
+	return nil
+}
+
+func (s *ledgerStore) UpdatePositionState(_ context.Context, _ uuid.UUID, _ *EnginePositionState) error {
+	return nil
+}
+
+func (s *ledgerStore) DeletePositionState(_ context.Context, _ uuid.UUID, _, _, _ string) error {
+	return nil
+}
+
+func (s *ledgerStore) sides() (buys, sells int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tr := range s.trades {
+		if tr.Side == domain.SideBuy {
+			buys++
+		} else {
+			sells++
+		}
+	}
+	return buys, sells
+}
+
+func TestHandleSignal_ConcurrentSignalsOpenAndCloseOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]TradingConfig{{
+			AccountID: "acc", ProductID: "BTC-USD", Exchange: "binance", Granularity: "ONE_HOUR",
+			StrategiesLong: []string{"trend"}, LongLeverage: 1, Enabled: true,
+		}})
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		TradingMode: "paper", TraderAPIURL: srv.URL, EntryOrderType: "market",
+		PortfolioSize: 1000, PositionSizePct: 10, SignalQueueDepth: 64,
+	}
+	store := &ledgerStore{}
+	e := makeEngine(cfg)
+	e.repo = store
+	e.exchange = NewNoopExchange(cfg)
+	e.exchanges = NewPaperExchangeRegistry(e.exchange)
+	e.tenantUUID = uuid.New()
+	e.accounts = []string{"acc"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := e.refreshTradingConfigs(ctx); err != nil {
+		t.Fatal(err)
+	}
+	q := newSymbolQueues(ctx, cfg.SignalQueueDepth, e.logger)
+	e.queues.Store(q)
+
+	burst := func(action string) {
+		var wg sync.WaitGroup
+		base := time.Now().Unix()
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				data, _ := json.Marshal(SignalPayload{
+					Action: action, Price: 100, Confidence: 0.9, Timestamp: base - int64(i),
+					Reason: fmt.Sprintf("%s %d", action, i),
+				})
+				e.handleSignal(ctx, &nats.Msg{Subject: "signals.binance.BTC-USD.ONE_HOUR.trend", Data: data})
+			}(i)
+		}
+		wg.Wait()
+		q.do(ctx, posKey("acc", "BTC-USD"), func() {}) // barrier: everything queued before has run
+	}
+
+	burst("BUY")
+	if buys, _ := store.sides(); buys != 1 {
+		t.Fatalf("want exactly one open, got %d", buys)
+	}
+	burst("SELL")
+	if _, sells := store.sides(); sells != 1 {
+		t.Fatalf("want exactly one close, got %d", sells)
+	}
+	if n := len(e.posState); n != 0 {
+		t.Errorf("position state should be cleared, %d left", n)
+	}
+}
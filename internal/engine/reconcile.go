@@ -0,0 +1,473 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// DriftKind classifies a difference found by the reconciler.
+type DriftKind string
+
+const (
+	DriftMissingInLedger   DriftKind = "missing_in_ledger"   // exchange holds a position the ledger does not
+	DriftMissingOnExchange DriftKind = "missing_on_exchange" // ledger position the exchange no longer holds
+	DriftQuantityMismatch  DriftKind = "quantity_mismatch"   // both hold the position with different sizes
+	DriftUntracked         DriftKind = "untracked"           // ledger position with no in-memory risk state
+	DriftStaleState        DriftKind = "stale_state"         // in-memory risk state with no ledger position
+)
+
+// PositionDrift is one difference between the exchange, the ledger and the
+// engine's in-memory position state.
+type PositionDrift struct {
+	AccountID   string              `json:"account_id,omitempty"` // "" when an adapter is shared and the drift cannot be attributed
+	Venue       string              `json:"venue,omitempty"`
+	Symbol      string              `json:"symbol"`
+	Side        domain.PositionSide `json:"side"`
+	Kind        DriftKind           `json:"kind"`
+	ExchangeQty float64             `json:"exchange_qty"`
+	LedgerQty   float64             `json:"ledger_qty"`
+	EntryPrice  float64             `json:"entry_price,omitempty"` // exchange entry price, else ledger average entry
+	Leverage    int                 `json:"leverage,omitempty"`
+
+	// Set when RECONCILE_AUTO_FIX is enabled.
+	Fixed    bool   `json:"fixed,omitempty"`
+	TradeID  string `json:"trade_id,omitempty"`
+	FixError string `json:"fix_error,omitempty"`
+}
+
+// key identifies the drift across consecutive runs.
+func (d PositionDrift) key() string {
+	return strings.Join([]string{d.AccountID, d.Symbol, string(d.Side), string(d.Kind)}, "|")
+}
+
+// ReconcileReport is the result of one reconciliation run.
+type ReconcileReport struct {
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	AutoFix    bool            `json:"auto_fix"`
+	Drift      []PositionDrift `json:"drift"`
+	Errors     []string        `json:"errors,omitempty"`
+}
+
+// LastReconcile returns the most recent reconciliation report, or nil when
+// none has run yet.
+func (e *Engine) LastReconcile() *ReconcileReport {
+	e.reconcileMu.RLock()
+	defer e.reconcileMu.RUnlock()
+	return e.lastReconcile
+}
+
+// startReconciler runs reconcile once at startup and then every
+// cfg.ReconcileInterval until ctx is cancelled.
+func (e *Engine) startReconciler(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		e.reconcile(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcileGroup is a set of accounts that trade through one position-listing
+// adapter. Accounts without their own credentials share the venue default.
+type reconcileGroup struct {
+	lister   PositionLister
+	accounts []string
+}
+
+// reconcile diffs exchange positions, ledger positions and the in-memory
+// position state for every managed account, records the report and, when
+// auto-fix is enabled, writes corrective ledger trades.
+func (e *Engine) reconcile(ctx context.Context) *ReconcileReport {
+	report := &ReconcileReport{
+		StartedAt: time.Now().UTC(),
+		AutoFix:   e.cfg.ReconcileAutoFix,
+		Drift:     []PositionDrift{},
+	}
+
+	ledger := make(map[string][]domain.Position)
+	var groups []*reconcileGroup
+	for _, accountID := range e.accounts {
+		positions, err := e.repo.ListOpenPositionsForAccount(ctx, accountID)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("list open positions for %s: %v", accountID, err))
+			continue
+		}
+		ledger[accountID] = positions
+		report.Drift = append(report.Drift, diffStates(accountID, positions, e.stateSides(accountID))...)
+
+		ex, err := e.exchanges.Resolve(accountID, venueBinance)
+		if err != nil {
+			continue
+		}
+		lister, ok := ex.(PositionLister)
+		if !ok {
+			continue
+		}
+		var g *reconcileGroup
+		for _, existing := range groups {
+			if existing.lister == lister {
+				g = existing
+				break
+			}
+		}
+		if g == nil {
+			g = &reconcileGroup{lister: lister}
+			groups = append(groups, g)
+		}
+		g.accounts = append(g.accounts, accountID)
+	}
+
+	for _, g := range groups {
+		exPositions, err := g.lister.ListPositions(ctx)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("list exchange positions for %s: %v", strings.Join(g.accounts, ","), err))
+			continue
+		}
+		var ledgerFutures []domain.Position
+		skip := make(map[string]bool) // binance symbols with an entry order in flight
+		for _, accountID := range g.accounts {
+			for _, p := range ledger[accountID] {
+				if p.MarketType == domain.MarketTypeFutures {
+					ledgerFutures = append(ledgerFutures, p)
+				}
+			}
+			for _, symbol := range e.pendingSymbols(accountID) {
+				skip[binanceSymbol(symbol)] = true
+			}
+		}
+		for _, d := range diffExchange(g.accounts, venueBinance, exPositions, ledgerFutures) {
+			if !skip[binanceSymbol(d.Symbol)] {
+				report.Drift = append(report.Drift, d)
+			}
+		}
+	}
+
+	if e.cfg.ReconcileAutoFix {
+		e.fixDrift(ctx, report, e.LastReconcile())
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	for _, d := range report.Drift {
+		e.logger.Warn().
+			Str("account", d.AccountID).
+			Str("symbol", d.Symbol).
+			Str("side", string(d.Side)).
+			Str("kind", string(d.Kind)).
+			Float64("exchange_qty", d.ExchangeQty).
+			Float64("ledger_qty", d.LedgerQty).
+			Bool("fixed", d.Fixed).
+			Str("fix_error", d.FixError).
+			Msg("reconcile: position drift")
+	}
+	for _, msg := range report.Errors {
+		e.logger.Error().Str("error", msg).Msg("reconcile: run incomplete")
+	}
+	e.logger.Info().
+		Int("drift", len(report.Drift)).
+		Int("errors", len(report.Errors)).
+		Dur("took", report.FinishedAt.Sub(report.StartedAt)).
+		Msg("reconcile complete")
+
+	e.reconcileMu.Lock()
+	e.lastReconcile = report
+	e.reconcileMu.Unlock()
+	return report
+}
+
+// stateSides returns symbol → side for the account's in-memory position states.
+func (e *Engine) stateSides(accountID string) map[string]string {
+	e.posStateMu.RLock()
+	defer e.posStateMu.RUnlock()
+	out := make(map[string]string)
+	for _, ps := range e.posState {
+		if ps.AccountID == accountID {
+			out[ps.Symbol] = ps.Side
+		}
+	}
+	return out
+}
+
+// pendingSymbols returns the symbols with a resting entry order for the account.
+func (e *Engine) pendingSymbols(accountID string) []string {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+	var out []string
+	for _, pe := range e.pending {
+		if pe.trade.AccountID == accountID {
+			out = append(out, pe.trade.Symbol)
+		}
+	}
+	return out
+}
+
+// fixDrift writes a corrective ledger trade for each exchange/ledger drift
+// that was also present in the previous run — a single sighting may be an
+// order whose ledger write is still in flight. Drift on a symbol with trades
+// still queued in the outbox waits for them, and drift that cannot be
+// attributed to one account is left for manual recovery.
+func (e *Engine) fixDrift(ctx context.Context, report, prev *ReconcileReport) {
+	seen := make(map[string]bool)
+	if prev != nil {
+		for _, d := range prev.Drift {
+			if !d.Fixed {
+				seen[d.key()] = true
+			}
+		}
+	}
+
+	queued := e.outboxSymbols()
+
+	for i := range report.Drift {
+		d := &report.Drift[i]
+		switch d.Kind {
+		case DriftMissingInLedger, DriftMissingOnExchange, DriftQuantityMismatch:
+		default:
+			continue
+		}
+		if d.AccountID == "" {
+			d.FixError = "exchange account is shared by several ledger accounts — manual recovery required"
+			continue
+		}
+		if !seen[d.key()] {
+			d.FixError = "first sighting — will fix if still present on the next run"
+			continue
+		}
+		if queued[posKey(d.AccountID, binanceSymbol(d.Symbol))] {
+			d.FixError = "trades queued in the outbox — will fix if still present once they are recorded"
+			continue
+		}
+
+		e.lastPriceMu.RLock()
+		price := e.lastPrice[d.Symbol]
+		e.lastPriceMu.RUnlock()
+
+		trade := correctiveTrade(d, e.tenantID(), price, time.Now().UTC())
+		if trade == nil {
+			continue
+		}
+		if _, err := e.submitTrade(ctx, trade); err != nil {
+			d.FixError = err.Error()
+			continue
+		}
+		d.Fixed = true
+		d.TradeID = trade.TradeID
+
+		if d.Kind == DriftMissingInLedger {
+			e.conflictMu.Lock()
+			e.conflict[posKey(d.AccountID, d.Symbol)] = string(d.Side)
+			e.conflictMu.Unlock()
+		}
+		if e.publisher != nil {
+			e.publisher.Publish(trade.AccountID, trade)
+		}
+		e.logger.Info().
+			Str("trade_id", trade.TradeID).
+			Str("account", trade.AccountID).
+			Str("symbol", trade.Symbol).
+			Str("side", string(trade.Side)).
+			Float64("qty", trade.Quantity).
+			Float64("price", trade.Price).
+			Msg("reconcile: corrective trade recorded")
+	}
+}
+
+// reconcileQtyEqual reports whether two quantities match within rounding.
+func reconcileQtyEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9+1e-6*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// diffExchange compares the positions an exchange reports with the ledger's
+// open futures positions for the accounts trading through it. Ledger
+// quantities are summed per (symbol, side) across the accounts.
+func diffExchange(accounts []string, venue string, exchange []ExchangePosition, ledger []domain.Position) []PositionDrift {
+	type agg struct {
+		symbol   string
+		side     domain.PositionSide
+		exQty    float64
+		exEntry  float64
+		leverage int
+		ledQty   float64
+		ledEntry float64
+		accounts map[string]bool
+	}
+	rows := make(map[string]*agg)
+	get := func(bSymbol string, side domain.PositionSide) *agg {
+		k := bSymbol + "|" + string(side)
+		if rows[k] == nil {
+			rows[k] = &agg{side: side, accounts: make(map[string]bool)}
+		}
+		return rows[k]
+	}
+
+	for _, p := range ledger {
+		r := get(binanceSymbol(p.Symbol), p.Side)
+		r.symbol = p.Symbol
+		r.ledQty += p.Quantity
+		r.ledEntry = p.AvgEntryPrice
+		r.accounts[p.AccountID] = true
+	}
+	for _, p := range exchange {
+		r := get(p.Symbol, p.Side)
+		if r.symbol == "" {
+			r.symbol = ledgerSymbol(p.Symbol)
+		}
+		r.exQty += p.Quantity
+		r.exEntry = p.EntryPrice
+		r.leverage = p.Leverage
+	}
+
+	var out []PositionDrift
+	for _, r := range rows {
+		if reconcileQtyEqual(r.exQty, r.ledQty) {
+			continue
+		}
+		d := PositionDrift{
+			Venue:       venue,
+			Symbol:      r.symbol,
+			Side:        r.side,
+			ExchangeQty: r.exQty,
+			LedgerQty:   r.ledQty,
+			EntryPrice:  r.exEntry,
+			Leverage:    r.leverage,
+		}
+		if d.EntryPrice == 0 {
+			d.EntryPrice = r.ledEntry
+		}
+		switch {
+		case r.ledQty == 0:
+			d.Kind = DriftMissingInLedger
+		case r.exQty == 0:
+			d.Kind = DriftMissingOnExchange
+		default:
+			d.Kind = DriftQuantityMismatch
+		}
+		switch {
+		case len(accounts) == 1:
+			d.AccountID = accounts[0]
+		case len(r.accounts) == 1 && d.Kind == DriftMissingOnExchange:
+			for a := range r.accounts {
+				d.AccountID = a
+			}
+		}
+		out = append(out, d)
+	}
+	sortDrift(out)
+	return out
+}
+
+// diffStates compares an account's open ledger positions with the symbols the
+// engine is risk-managing (symbol → side).
+func diffStates(accountID string, ledger []domain.Position, states map[string]string) []PositionDrift {
+	var out []PositionDrift
+	open := make(map[string]bool)
+	for _, p := range ledger {
+		open[p.Symbol] = true
+		if _, ok := states[p.Symbol]; !ok {
+			out = append(out, PositionDrift{
+				AccountID:  accountID,
+				Symbol:     p.Symbol,
+				Side:       p.Side,
+				Kind:       DriftUntracked,
+				LedgerQty:  p.Quantity,
+				EntryPrice: p.AvgEntryPrice,
+			})
+		}
+	}
+	for symbol, side := range states {
+		if !open[symbol] {
+			out = append(out, PositionDrift{
+				AccountID: accountID,
+				Symbol:    symbol,
+				Side:      domain.PositionSide(side),
+				Kind:      DriftStaleState,
+			})
+		}
+	}
+	sortDrift(out)
+	return out
+}
+
+func sortDrift(d []PositionDrift) {
+	sort.Slice(d, func(i, j int) bool {
+		if d[i].Symbol != d[j].Symbol {
+			return d[i].Symbol < d[j].Symbol
+		}
+		return d[i].Side < d[j].Side
+	})
+}
+
+// correctiveTrade returns the ledger trade that brings the ledger quantity in
+// line with the exchange, or nil when there is nothing to write. Increases are
+// booked at the exchange entry price; reductions at price (the last observed
+// price) or, failing that, the entry price so no P&L is invented.
+func correctiveTrade(d *PositionDrift, tenantID uuid.UUID, price float64, now time.Time) *domain.Trade {
+	delta := d.ExchangeQty - d.LedgerQty
+	if reconcileQtyEqual(delta, 0) {
+		return nil
+	}
+	increase := delta > 0
+	side := domain.SideBuy
+	if increase == (d.Side == domain.PositionSideShort) {
+		side = domain.SideSell
+	}
+	if increase || price <= 0 {
+		price = d.EntryPrice
+	}
+
+	reason := "reconcile"
+	trade := &domain.Trade{
+		TenantID:    tenantID,
+		TradeID:     engineTradeID("reconcile", d.AccountID, d.Symbol, "", now),
+		AccountID:   d.AccountID,
+		Symbol:      d.Symbol,
+		Side:        side,
+		Quantity:    math.Abs(delta),
+		Price:       price,
+		FeeCurrency: "USD",
+		MarketType:  domain.MarketTypeFutures,
+		Timestamp:   now,
+		IngestedAt:  now,
+	}
+	if d.Leverage > 0 {
+		lev := d.Leverage
+		trade.Leverage = &lev
+	}
+	if increase {
+		trade.EntryReason = &reason
+		if side == domain.SideBuy {
+			trade.CostBasis = trade.Quantity * trade.Price
+		}
+	} else {
+		trade.ExitReason = &reason
+		costBasisForTrade(trade, d.EntryPrice)
+	}
+	return trade
+}
+
+// ledgerSymbol converts a Binance symbol like "BTCUSDT" back to the ledger
+// product ID "BTC-USD". It is the inverse of binanceSymbol.
+func ledgerSymbol(symbol string) string {
+	for _, q := range []struct{ binance, ledger string }{
+		{"USDT", "USD"},
+		{"USDC", "USDC"},
+	} {
+		if base, ok := strings.CutSuffix(symbol, q.binance); ok && base != "" {
+			return base + "-" + q.ledger
+		}
+	}
+	return symbol
+}
@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// reconcileStore is an EngineStore that serves fixed open positions and
+// records inserted trades. Other methods are not used by the reconciler.
+type reconcileStore struct {
+	EngineStore
+	open   map[string][]domain.Position
+	trades []*domain.Trade
+}
+
+func (s *reconcileStore) ListOpenPositionsForAccount(_ context.Context, accountID string) ([]domain.Position, error) {
+	return s.open[accountID], nil
+}
+
+func (s *reconcileStore) InsertTradeAndUpdatePosition(_ context.Context, _ uuid.UUID, trade *domain.Trade) (bool, error) {
+	s.trades = append(s.trades, trade)
+	return true, nil
+}
+
+func TestDiffExchange_ClassifiesDrift(t *testing.T) {
+	exchange := []ExchangePosition{
+		{Symbol: "BTCUSDT", Side: domain.PositionSideLong, Quantity: 0.02, EntryPrice: 50000, Leverage: 5},
+		{Symbol: "ETHUSDT", Side: domain.PositionSideShort, Quantity: 1.5, EntryPrice: 3000},
+		{Symbol: "SOLUSDT", Side: domain.PositionSideLong, Quantity: 10, EntryPrice: 150},
+	}
+	ledger := []domain.Position{
+		{AccountID: "acc", Symbol: "ETH-USD", Side: domain.PositionSideShort, Quantity: 1.0, AvgEntryPrice: 3000, MarketType: domain.MarketTypeFutures},
+		{AccountID: "acc", Symbol: "SOL-USD", Side: domain.PositionSideLong, Quantity: 10.0000000001, AvgEntryPrice: 150, MarketType: domain.MarketTypeFutures},
+		{AccountID: "acc", Symbol: "XRP-USD", Side: domain.PositionSideLong, Quantity: 100, AvgEntryPrice: 0.5, MarketType: domain.MarketTypeFutures},
+	}
+
+	drift := diffExchange([]string{"acc"}, venueBinance, exchange, ledger)
+	if len(drift) != 3 {
+		t.Fatalf("want 3 drift entries, got %d: %+v", len(drift), drift)
+	}
+	want := []struct {
+		symbol string
+		kind   DriftKind
+	}{
+		{"BTC-USD", DriftMissingInLedger},
+		{"ETH-USD", DriftQuantityMismatch},
+		{"XRP-USD", DriftMissingOnExchange},
+	}
+	for i, w := range want {
+		assertEq(t, "symbol", w.symbol, drift[i].Symbol)
+		assertEq(t, w.symbol+" kind", string(w.kind), string(drift[i].Kind))
+		assertEq(t, w.symbol+" account", "acc", drift[i].AccountID)
+	}
+	assertFloat(t, "BTC entry", 50000, drift[0].EntryPrice)
+	assertFloat(t, "XRP entry falls back to ledger", 0.5, drift[2].EntryPrice)
+}
+
+func TestDiffExchange_SharedAdapterNotAttributed(t *testing.T) {
+	exchange := []ExchangePosition{{Symbol: "BTCUSDT", Side: domain.PositionSideLong, Quantity: 0.02}}
+	drift := diffExchange([]string{"a", "b"}, venueBinance, exchange, nil)
+	if len(drift) != 1 || drift[0].AccountID != "" {
+		t.Errorf("want one unattributed drift, got %+v", drift)
+	}
+}
+
+func TestDiffStates_UntrackedAndStale(t *testing.T) {
+	ledger := []domain.Position{{Symbol: "BTC-USD", Side: domain.PositionSideLong, Quantity: 1}}
+	drift := diffStates("acc", ledger, map[string]string{"ETH-USD": "short"})
+	if len(drift) != 2 {
+		t.Fatalf("want 2 drift entries, got %+v", drift)
+	}
+	assertEq(t, "BTC kind", string(DriftUntracked), string(drift[0].Kind))
+	assertEq(t, "ETH kind", string(DriftStaleState), string(drift[1].Kind))
+	assertEq(t, "ETH side", "short", string(drift[1].Side))
+}
+
+func TestCorrectiveTrade_Sides(t *testing.T) {
+	cases := []struct {
+		name          string
+		side          domain.PositionSide
+		exQty, ledQty float64
+		wantSide      domain.Side
+		wantQty       float64
+		wantPrice     float64
+	}{
+		{"long missing in ledger", domain.PositionSideLong, 2, 0, domain.SideBuy, 2, 100},
+		{"long missing on exchange", domain.PositionSideLong, 0, 2, domain.SideSell, 2, 110},
+		{"short larger on exchange", domain.PositionSideShort, 3, 1, domain.SideSell, 2, 100},
+		{"short smaller on exchange", domain.PositionSideShort, 1, 3, domain.SideBuy, 2, 110},
+	}
+	for _, tc := range cases {
+		d := &PositionDrift{AccountID: "acc", Symbol: "BTC-USD", Side: tc.side, ExchangeQty: tc.exQty, LedgerQty: tc.ledQty, EntryPrice: 100}
+		trade := correctiveTrade(d, uuid.Nil, 110, time.Now())
+		assertEq(t, tc.name+" side", string(tc.wantSide), string(trade.Side))
+		assertFloat(t, tc.name+" qty", tc.wantQty, trade.Quantity)
+		assertFloat(t, tc.name+" price", tc.wantPrice, trade.Price)
+	}
+
+	long := &PositionDrift{Symbol: "BTC-USD", Side: domain.PositionSideLong, LedgerQty: 2, EntryPrice: 100}
+	assertFloat(t, "realised pnl at last price", 20, correctiveTrade(long, uuid.Nil, 110, time.Now()).RealizedPnL)
+	assertFloat(t, "no price → entry price", 0, correctiveTrade(long, uuid.Nil, 0, time.Now()).RealizedPnL)
+}
+
+func TestBinanceListPositions_MapsSides(t *testing.T) {
+	mock := &mockBinanceFuturesClient{positions: []binancePosition{
+		{Symbol: "BTCUSDT", PositionSide: "LONG", Amount: 0.5, EntryPrice: 50000, Leverage: 3},
+		{Symbol: "ETHUSDT", PositionSide: "SHORT", Amount: -2, EntryPrice: 3000},
+		{Symbol: "SOLUSDT", PositionSide: "BOTH", Amount: -4},
+		{Symbol: "XRPUSDT", PositionSide: "LONG", Amount: 0},
+	}}
+	got, err := newTestExchange(mock).ListPositions(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("want 3 non-zero positions, got %d", len(got))
+	}
+	assertEq(t, "BTC side", "long", string(got[0].Side))
+	assertEq(t, "ETH side", "short", string(got[1].Side))
+	assertFloat(t, "ETH qty", 2, got[1].Quantity)
+	assertEq(t, "one-way short side", "short", string(got[2].Side))
+}
+
+func TestReconcile_AutoFixOnSecondSighting(t *testing.T) {
+	mock := &mockBinanceFuturesClient{positions: []binancePosition{
+		{Symbol: "BTCUSDT", PositionSide: "LONG", Amount: 0.02, EntryPrice: 50000},
+	}}
+	store := &reconcileStore{}
+	e := makeEngine(&config.Config{TradingMode: "live", ReconcileAutoFix: true})
+	e.repo = store
+	e.accounts = []string{"acc"}
+	e.exchanges = NewExchangeRegistry()
+	e.exchanges.Register(venueBinance, newTestExchange(mock))
+	ctx := context.Background()
+
+	first := e.reconcile(ctx)
+	if len(first.Drift) != 1 || first.Drift[0].Fixed || len(store.trades) != 0 {
+		t.Fatalf("first run must report without fixing: %+v", first.Drift)
+	}
+
+	second := e.reconcile(ctx)
+	if len(second.Drift) != 1 || !second.Drift[0].Fixed || len(store.trades) != 1 {
+		t.Fatalf("second run must fix: %+v", second.Drift)
+	}
+	trade := store.trades[0]
+	assertEq(t, "symbol", "BTC-USD", trade.Symbol)
+	assertEq(t, "side", "buy", string(trade.Side))
+	assertFloat(t, "qty", 0.02, trade.Quantity)
+	assertEq(t, "conflict guard", "long", e.conflict[posKey("acc", "BTC-USD")])
+	if e.LastReconcile() != second {
+		t.Error("LastReconcile should return the latest report")
+	}
+}
+
+func TestReconcile_AutoFixWaitsForOutbox(t *testing.T) {
+	mock := &mockBinanceFuturesClient{positions: []binancePosition{
+		{Symbol: "BTCUSDT", PositionSide: "LONG", Amount: 0.02, EntryPrice: 50000},
+	}}
+	store := &reconcileStore{}
+	e := makeEngine(&config.Config{TradingMode: "live", ReconcileAutoFix: true})
+	e.repo = store
+	e.accounts = []string{"acc"}
+	e.exchanges = NewExchangeRegistry()
+	e.exchanges.Register(venueBinance, newTestExchange(mock))
+	ob, err := OpenOutbox(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.outbox = ob
+	// The open that explains the drift is still waiting to reach the ledger.
+	if err := ob.Put(&outboxEntry{Trade: domain.Trade{TradeID: "open-1", AccountID: "acc", Symbol: "BTC-USD"}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	e.reconcile(ctx)
+	second := e.reconcile(ctx)
+	if len(second.Drift) != 1 || second.Drift[0].Fixed || len(store.trades) != 0 {
+		t.Fatalf("drift with a queued trade must not be fixed: %+v", second.Drift)
+	}
+}
+
+func TestLedgerSymbol(t *testing.T) {
+	for in, want := range map[string]string{
+		"BTCUSDT": "BTC-USD",
+		"ETHUSDC": "ETH-USDC",
+		"BTCEUR":  "BTCEUR",
+	} {
+		assertEq(t, in, want, ledgerSymbol(in))
+	}
+}
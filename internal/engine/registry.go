@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Signal-ngn/trader/internal/config"
+)
+
+// ExchangeRegistry resolves the exchange adapter for an (account, venue)
+// pair. Each venue has a default adapter built from the global credentials;
+// accounts may override a venue with an adapter carrying their own
+// credentials. A paper registry resolves every venue to one simulator.
+//
+// Resolution fails closed: a venue with no registered adapter is an error,
+// never a silent fallback to another venue.
+type ExchangeRegistry struct {
+	mu       sync.RWMutex
+	paper    Exchange                       // non-nil: every venue resolves here
+	venues   map[string]Exchange            // venue → default adapter
+	accounts map[string]map[string]Exchange // AccountEnvSuffix(account) → venue → adapter
+}
+
+// RegisteredExchange is one adapter in the registry. AccountID is "" for a
+// venue default.
+type RegisteredExchange struct {
+	AccountID string
+	Venue     string
+	Exchange  Exchange
+}
+
+// NewExchangeRegistry creates an empty live registry.
+func NewExchangeRegistry() *ExchangeRegistry {
+	return &ExchangeRegistry{
+		venues:   make(map[string]Exchange),
+		accounts: make(map[string]map[string]Exchange),
+	}
+}
+
+// NewPaperExchangeRegistry creates a registry that resolves every venue and
+// account to ex.
+func NewPaperExchangeRegistry(ex Exchange) *ExchangeRegistry {
+	r := NewExchangeRegistry()
+	r.paper = ex
+	return r
+}
+
+// Register sets the default adapter for a venue.
+func (r *ExchangeRegistry) Register(venue string, ex Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.venues[strings.ToLower(venue)] = ex
+}
+
+// RegisterAccount sets an account-specific adapter for a venue, taking
+// precedence over the venue default.
+func (r *ExchangeRegistry) RegisterAccount(accountID, venue string, ex Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := config.AccountEnvSuffix(accountID)
+	if r.accounts[key] == nil {
+		r.accounts[key] = make(map[string]Exchange)
+	}
+	r.accounts[key][strings.ToLower(venue)] = ex
+}
+
+// Resolve returns the adapter for accountID on venue.
+func (r *ExchangeRegistry) Resolve(accountID, venue string) (Exchange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.paper != nil {
+		return r.paper, nil
+	}
+	venue = strings.ToLower(venue)
+	if ex, ok := r.accounts[config.AccountEnvSuffix(accountID)][venue]; ok {
+		return ex, nil
+	}
+	if ex, ok := r.venues[venue]; ok {
+		return ex, nil
+	}
+	if venue == "" {
+		return nil, fmt.Errorf("no exchange venue for account %s", accountID)
+	}
+	return nil, fmt.Errorf("unknown exchange venue %q for account %s", venue, accountID)
+}
+
+// All returns every registered live adapter, venue defaults first, in a
+// stable order. A paper registry returns nothing.
+func (r *ExchangeRegistry) All() []RegisteredExchange {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []RegisteredExchange
+	for venue, ex := range r.venues {
+		out = append(out, RegisteredExchange{Venue: venue, Exchange: ex})
+	}
+	for account, venues := range r.accounts {
+		for venue, ex := range venues {
+			out = append(out, RegisteredExchange{AccountID: account, Venue: venue, Exchange: ex})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AccountID != out[j].AccountID {
+			return out[i].AccountID < out[j].AccountID
+		}
+		return out[i].Venue < out[j].Venue
+	})
+	return out
+}
+
+// buildExchangeRegistry creates the live registry from configuration: a
+// default adapter per venue with global credentials, plus one adapter per
+// account credential override.
+func buildExchangeRegistry(cfg *config.Config) *ExchangeRegistry {
+	r := NewExchangeRegistry()
+	if cfg.BinanceAPIKey != "" && cfg.BinanceAPISecret != "" {
+		r.Register(venueBinance, NewBinanceFuturesExchange(cfg))
+	}
+	if cfg.CoinbaseAPIKey != "" && cfg.CoinbaseAPISecret != "" {
+		r.Register(venueCoinbase, NewCoinbaseSpotExchange(cfg))
+	}
+	for account, venues := range cfg.AccountExchangeCredentials {
+		for venue, creds := range venues {
+			if creds.APIKey == "" || creds.APISecret == "" {
+				continue
+			}
+			acfg := *cfg
+			switch venue {
+			case venueBinance:
+				acfg.BinanceAPIKey, acfg.BinanceAPISecret = creds.APIKey, creds.APISecret
+				r.RegisterAccount(account, venue, NewBinanceFuturesExchange(&acfg))
+			case venueCoinbase:
+				acfg.CoinbaseAPIKey, acfg.CoinbaseAPISecret = creds.APIKey, creds.APISecret
+				r.RegisterAccount(account, venue, NewCoinbaseSpotExchange(&acfg))
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+func TestExchangeRegistry_AccountOverrideAndFailClosed(t *testing.T) {
+	def := newTestExchange(&mockBinanceFuturesClient{})
+	acct := newTestExchange(&mockBinanceFuturesClient{})
+	r := NewExchangeRegistry()
+	r.Register("binance", def)
+	r.RegisterAccount("acme-live", "binance", acct)
+
+	ex, err := r.Resolve("acme-live", "Binance")
+	if err != nil || ex != Exchange(acct) {
+		t.Errorf("account override: got err=%v", err)
+	}
+	ex, err = r.Resolve("other", "binance")
+	if err != nil || ex != Exchange(def) {
+		t.Errorf("venue default: got err=%v", err)
+	}
+	if _, err := r.Resolve("acme-live", "kraken"); err == nil {
+		t.Error("expected unknown venue to fail closed")
+	}
+	if _, err := r.Resolve("acme-live", ""); err == nil {
+		t.Error("expected empty venue to fail closed")
+	}
+	if n := len(r.All()); n != 2 {
+		t.Errorf("All: want 2 adapters, got %d", n)
+	}
+}
+
+func TestExchangeRegistry_PaperResolvesEveryVenue(t *testing.T) {
+	noop := NewNoopExchange(&config.Config{})
+	r := NewPaperExchangeRegistry(noop)
+
+	ex, err := r.Resolve("any", "kraken")
+	if err != nil || ex != Exchange(noop) {
+		t.Errorf("paper registry: got err=%v", err)
+	}
+	if len(r.All()) != 0 {
+		t.Error("paper registry should report no live adapters")
+	}
+}
+
+func TestBuildExchangeRegistry_PerAccountCredentials(t *testing.T) {
+	cfg := &config.Config{
+		TradingMode:      "live",
+		BinanceAPIKey:    "k",
+		BinanceAPISecret: "s",
+		AccountExchangeCredentials: map[string]map[string]config.VenueCredentials{
+			"ACME_LIVE": {"binance": {APIKey: "acme-k", APISecret: "acme-s"}},
+			"HALF":      {"coinbase": {APIKey: "only-key"}}, // incomplete — ignored
+		},
+	}
+	r := buildExchangeRegistry(cfg)
+
+	ex, err := r.Resolve("acme-live", "binance")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, ok := ex.(*BinanceFuturesExchange)
+	if !ok || b.cfg.BinanceAPIKey != "acme-k" {
+		t.Errorf("want account Binance adapter with acme credentials, got %T", ex)
+	}
+	if _, err := r.Resolve("half", "coinbase"); err == nil {
+		t.Error("incomplete credentials should not register an adapter")
+	}
+}
+
+func TestExchangeFor_RoutesByVenueAndMarketType(t *testing.T) {
+	cb, _ := newCoinbaseTest(t)
+	bn := newTestExchange(&mockBinanceFuturesClient{})
+	e := makeEngine(&config.Config{TradingMode: "live"})
+	e.exchanges = NewExchangeRegistry()
+	e.exchanges.Register(venueBinance, bn)
+	e.exchanges.Register(venueCoinbase, cb)
+
+	ex, venue, err := e.exchangeFor("acc", "coinbase", domain.MarketTypeSpot)
+	if err != nil || ex != Exchange(cb) || venue != venueCoinbase {
+		t.Errorf("coinbase spot: got venue=%s err=%v", venue, err)
+	}
+	ex, venue, err = e.exchangeFor("acc", "coinbase", domain.MarketTypeFutures)
+	if err != nil || ex != Exchange(bn) || venue != venueBinance {
+		t.Errorf("futures: got venue=%s err=%v", venue, err)
+	}
+	if _, _, err := e.exchangeFor("acc", "kraken", domain.MarketTypeSpot); err == nil {
+		t.Error("expected error for venue without adapter")
+	}
+}
@@ -16,8 +16,8 @@ const (
 // exchangeForProduct returns the exchange name for a product by scanning the
 // in-memory allowlist. Returns "" if the product is not found.
 func (e *Engine) exchangeForProduct(product string) string {
-	e.allowlistMu.RLock()
-	defer e.allowlistMu.RUnlock()
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
 	for key := range e.allowlist {
 		if key.product == product {
 			return key.exchange
@@ -37,8 +37,14 @@ func (e *Engine) startRiskLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			e.checkPendingEntries(ctx, "")
 			if err := e.evaluatePositions(ctx); err != nil {
 				e.logger.Error().Err(err).Msg("risk loop evaluation failed")
+			} else {
+				e.lastRiskLoop.Store(time.Now().UnixNano())
+			}
+			if e.cfg.FlattenLossLimit > 0 {
+				e.checkFlattenLimits(ctx)
 			}
 		}
 	}
@@ -66,10 +72,12 @@ func (e *Engine) evaluatePositions(ctx context.Context) error {
 
 	// Prune orphaned engine_position_state rows (position closed externally).
 	tenantID := e.tenantID()
+	var pruned []*PositionState
 	e.posStateMu.Lock()
 	for key := range e.posState {
 		if !openKeys[key] {
 			ps := e.posState[key]
+			pruned = append(pruned, ps)
 			e.logger.Info().Str("account", ps.AccountID).Str("symbol", ps.Symbol).Msg("pruning orphaned position state")
 			if err := e.repo.DeletePositionState(ctx, tenantID, ps.Symbol, ps.MarketType, ps.AccountID); err != nil {
 				e.logger.Warn().Err(err).Str("key", key).Msg("failed to delete orphaned position state")
@@ -81,6 +89,9 @@ func (e *Engine) evaluatePositions(ctx context.Context) error {
 		}
 	}
 	e.posStateMu.Unlock()
+	for _, ps := range pruned {
+		e.cancelProtectiveOrders(ctx, ps)
+	}
 
 	// Evaluate each position with engine state.
 	e.posStateMu.RLock()
@@ -90,17 +101,31 @@ func (e *Engine) evaluatePositions(ctx context.Context) error {
 	}
 	e.posStateMu.RUnlock()
 
+	// Positions whose exchange-side exit filled are closed out first.
 	for _, ps := range states {
-		e.evaluatePosition(ctx, ps)
+		ps := ps
+		e.serialize(ctx, posKey(ps.AccountID, ps.Symbol), func() {
+			if e.checkProtectiveFills(ctx, ps) {
+				return
+			}
+			e.evaluatePosition(ctx, ps)
+		})
 	}
 
 	return nil
 }
 
-// evaluateOpenPositionsForSymbol evaluates exit conditions for all open positions
-// whose Symbol matches product. Called on every incoming price signal to provide
-// tick-level risk evaluation latency.
-func (e *Engine) evaluateOpenPositionsForSymbol(ctx context.Context, product string) {
+// checkSymbolPrice queues, on each position's queue, a check of the resting
+// entries and a risk evaluation of the open positions on product. Called on
+// every incoming price signal to provide tick-level latency; it does not wait,
+// so the NATS callback is not held up by exchange calls.
+func (e *Engine) checkSymbolPrice(ctx context.Context, product string) {
+	now := time.Now()
+	for key, pe := range e.pendingEntriesFor(product) {
+		key, pe := key, pe
+		e.dispatch(key, func() { e.checkPendingEntry(ctx, key, pe, now) })
+	}
+
 	e.posStateMu.RLock()
 	var matching []*PositionState
 	for _, ps := range e.posState {
@@ -111,7 +136,8 @@ func (e *Engine) evaluateOpenPositionsForSymbol(ctx context.Context, product str
 	e.posStateMu.RUnlock()
 
 	for _, ps := range matching {
-		e.evaluatePosition(ctx, ps)
+		ps := ps
+		e.dispatch(posKey(ps.AccountID, ps.Symbol), func() { e.evaluatePosition(ctx, ps) })
 	}
 }
 
@@ -149,6 +175,7 @@ func (e *Engine) evaluatePosition(ctx context.Context, ps *PositionState) {
 		e.lastPriceMu.Lock()
 		e.lastPrice[ps.Symbol] = currentPrice
 		e.lastPriceMu.Unlock()
+		e.observePrice(ps.Symbol, currentPrice)
 	}
 
 	if currentPrice <= 0 {
@@ -175,12 +202,24 @@ func (e *Engine) evaluatePosition(ctx context.Context, ps *PositionState) {
 		PeakPrice:    ps.PeakPrice,
 		TrailingStop: ps.TrailingStop,
 	}
+	// A take-profit ladder replaces the single take-profit exit.
+	if len(ps.Ladder) > 0 {
+		riskPos.TakeProfit = 0
+	}
+	// After a break-even move the library keeps the entry stop, which sets
+	// its trailing distance; the break-even stop is checked separately.
+	if ps.BreakEven {
+		riskPos.StopLoss = ps.EntryStopLoss
+	}
 
 	oldPeak := riskPos.PeakPrice
 	oldTrail := riskPos.TrailingStop
 
 	// Tick mode: use currentPrice for high, low, and close.
-	decision, shouldExit := risk.Evaluate(riskPos, currentPrice, currentPrice, currentPrice, time.Now())
+	decision, shouldExit := breakEvenStopHit(ps, currentPrice)
+	if !shouldExit {
+		decision, shouldExit = risk.Evaluate(riskPos, currentPrice, currentPrice, currentPrice, time.Now())
+	}
 
 	if shouldExit {
 		// Guard against concurrent closes: set Closing flag under write lock.
@@ -201,27 +240,37 @@ func (e *Engine) evaluatePosition(ctx context.Context, ps *PositionState) {
 			Float64("entry_price", ps.EntryPrice).
 			Msg("risk evaluation triggered exit")
 
-		e.executeCloseTrade(ctx, ps, currentPrice, decision.ExitReason)
+		if !e.executeCloseTrade(ctx, ps, currentPrice, decision.ExitReason) {
+			// Hand the position back to the next tick.
+			e.posStateMu.Lock()
+			psInMap.Closing = false
+			e.posStateMu.Unlock()
+		}
 		return
 	}
 
 	// If trailing stop advanced, persist the updated state.
 	if riskPos.PeakPrice != oldPeak || riskPos.TrailingStop != oldTrail {
+		// Move the exchange-side stop when the effective stop level changed.
+		stopOrderID := ps.StopOrderID
+		oldStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, oldTrail)
+		newStop := protectiveStopPrice(ps.Side, ps.HardStop, ps.StopLoss, riskPos.TrailingStop)
+		if newStop != oldStop {
+			stopOrderID = e.replaceProtectiveStop(ctx, ps, newStop)
+		}
+
 		e.posStateMu.Lock()
 		if psInMap, exists := e.posState[posKey(ps.AccountID, ps.Symbol)]; exists {
 			psInMap.PeakPrice = riskPos.PeakPrice
 			psInMap.TrailingStop = riskPos.TrailingStop
+			psInMap.StopOrderID = stopOrderID
 		}
+		dbState := ps.record()
 		e.posStateMu.Unlock()
+		dbState.PeakPrice = riskPos.PeakPrice
+		dbState.TrailingStop = riskPos.TrailingStop
+		dbState.StopOrderID = stopOrderID
 
-		dbState := &EnginePositionState{
-			ID:           ps.ID,
-			AccountID:    ps.AccountID,
-			Symbol:       ps.Symbol,
-			MarketType:   ps.MarketType,
-			PeakPrice:    riskPos.PeakPrice,
-			TrailingStop: riskPos.TrailingStop,
-		}
 		if err := e.repo.UpdatePositionState(ctx, tenantID, dbState); err != nil {
 			logger.Warn().Err(err).Msg("failed to persist trailing stop update")
 		} else {
@@ -231,4 +280,14 @@ func (e *Engine) evaluatePosition(ctx context.Context, ps *PositionState) {
 				Msg("trailing stop state advanced")
 		}
 	}
+
+	// Break-even: lock in the entry once price has run far enough.
+	if breakEvenDue(ps, currentPrice) {
+		e.moveStopToBreakEven(ctx, ps, currentPrice)
+	}
+
+	// Staged exits: close the next take-profit rung once price reaches it.
+	if len(ps.Ladder) > 0 {
+		e.takeLadderProfit(ctx, ps, currentPrice)
+	}
 }
@@ -1,8 +1,13 @@
 package engine
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
 )
 
 // These tests cover engine-level risk behaviour after the refactor:
@@ -42,3 +47,20 @@ func TestPositionState_ClosingFlag_Default(t *testing.T) {
 }
 
 
+
+func TestEvaluatePosition_FailedCloseReleasesClosing(t *testing.T) {
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = &ladderStore{} // no ledger quantity, so the close fails
+	e.tenantUUID = uuid.New()
+	ps := &PositionState{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: "spot", Side: "long",
+		EntryPrice: 100, HardStop: 95, OpenedAt: time.Now(),
+	}
+	e.posState[posKey("acc", "BTC-USD")] = ps
+	e.lastPrice["BTC-USD"] = 90
+
+	e.evaluatePosition(context.Background(), ps)
+	if ps.Closing {
+		t.Error("a failed close should hand the position back to the next tick")
+	}
+}
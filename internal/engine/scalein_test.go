@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// stateStore is an EngineStore that records position state writes.
+type stateStore struct {
+	EngineStore
+	inserted []*EnginePositionState
+	updated  []*EnginePositionState
+}
+
+func (s *stateStore) InsertPositionState(_ context.Context, _ uuid.UUID, state *EnginePositionState) error {
+	s.inserted = append(s.inserted, state)
+	return nil
+}
+
+func (s *stateStore) UpdatePositionState(_ context.Context, _ uuid.UUID, state *EnginePositionState) error {
+	s.updated = append(s.updated, state)
+	return nil
+}
+
+func TestWeightedEntryPrice(t *testing.T) {
+	entries := []PositionEntry{{Price: 100, Quantity: 1}, {Price: 130, Quantity: 2}}
+	assertFloat(t, "weighted", 120, weightedEntryPrice(entries))
+	assertFloat(t, "empty", 0, weightedEntryPrice(nil))
+}
+
+func TestPersistOpenedPosition_LayersSameSideFill(t *testing.T) {
+	store := &stateStore{}
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = store
+	ctx := context.Background()
+	now := time.Now().UTC()
+	tc := &TradingConfig{Granularity: "ONE_HOUR"}
+
+	first := &domain.Trade{AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot, Quantity: 1, Timestamp: now}
+	e.persistOpenedPosition(ctx, venueBinance, first, 100, domain.PositionSideLong, 1, tc)
+	if len(store.inserted) != 1 {
+		t.Fatalf("first fill should insert state, got %d inserts", len(store.inserted))
+	}
+	firstHardStop := store.inserted[0].HardStop
+
+	second := &domain.Trade{AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot, Quantity: 3, Timestamp: now.Add(time.Minute)}
+	e.persistOpenedPosition(ctx, venueBinance, second, 80, domain.PositionSideLong, 1, tc)
+	if len(store.inserted) != 1 || len(store.updated) != 1 {
+		t.Fatalf("scale-in should update, not insert: inserts=%d updates=%d", len(store.inserted), len(store.updated))
+	}
+
+	rec := store.updated[0]
+	assertFloat(t, "qty", 4, rec.Quantity)
+	assertFloat(t, "blended entry", 85, rec.EntryPrice)
+	if len(rec.Entries) != 2 {
+		t.Fatalf("want 2 layers, got %d", len(rec.Entries))
+	}
+	if rec.HardStop >= firstHardStop {
+		t.Errorf("hard stop should follow the lower blended entry: was %.4f, now %.4f", firstHardStop, rec.HardStop)
+	}
+	assertFloat(t, "in-memory entry", 85, e.posState[posKey("acc", "BTC-USD")].EntryPrice)
+}
+
+func TestReducePositionState_KeepsEntryPrice(t *testing.T) {
+	store := &stateStore{}
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = store
+	ps := &PositionState{
+		AccountID:  "acc",
+		Symbol:     "ETH-USD",
+		EntryPrice: 120,
+		Quantity:   3,
+		Entries:    []PositionEntry{{Price: 100, Quantity: 1}, {Price: 130, Quantity: 2}},
+	}
+
+	e.reducePositionState(context.Background(), ps, 0.25)
+	assertFloat(t, "qty", 2.25, ps.Quantity)
+	assertFloat(t, "first layer", 0.75, ps.Entries[0].Quantity)
+	assertFloat(t, "second layer", 1.5, ps.Entries[1].Quantity)
+	assertFloat(t, "weighted entry unchanged", 120, weightedEntryPrice(ps.Entries))
+	if len(store.updated) != 1 {
+		t.Errorf("want state persisted once, got %d", len(store.updated))
+	}
+}
+
+func TestBinanceClosePosition_Fraction(t *testing.T) {
+	mock := &mockBinanceFuturesClient{
+		positionQty: 0.4,
+		orderResult: &binanceOrderResult{AvgPrice: 50000, Quantity: 0.1},
+	}
+	_, err := newTestExchange(mock).ClosePosition(context.Background(), ClosePositionRequest{
+		Symbol: "BTC-USD", Side: domain.PositionSideLong, MarketType: domain.MarketTypeFutures, Quantity: 0.4, Fraction: 0.25,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEq(t, "qty", "0.100000", mock.lastOrder.Quantity)
+}
@@ -0,0 +1,525 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// shadowDustQty is the open quantity below which a shadow position counts as
+// closed, absorbing float error from partial closes.
+const shadowDustQty = 1e-12
+
+// ErrNotShadow is returned for shadow ledger queries on an engine that is not
+// running in shadow mode.
+var ErrNotShadow = errors.New("engine is not running in shadow mode")
+
+// shadowPosKey identifies a shadow position.
+type shadowPosKey struct {
+	accountID  string
+	symbol     string
+	marketType string
+}
+
+// ShadowEngineStore is the EngineStore of shadow mode. Accounts and balances
+// are read from the real store, so shadow positions are sized like the real
+// account's; everything the engine writes stays local. Trades are appended to
+// a JSONL ledger, one domain.Trade per line, with positions and realised P&L
+// computed as the platform would. Position state is kept in a JSON file next
+// to the ledger so open shadow positions survive a restart. Circuit breakers,
+// operator pauses and signal claims are held in memory: a shadow engine never
+// claims a signal the real engine has to process.
+type ShadowEngineStore struct {
+	base      EngineStore
+	statePath string
+
+	mu        sync.Mutex
+	ledger    *os.File
+	trades    map[string][]domain.Trade // by account, in ledger order
+	tradeIDs  map[string]struct{}
+	positions map[shadowPosKey]*domain.Position
+	states    map[shadowPosKey]EnginePositionState
+	breakers  map[breakerKey]BreakerState
+	pauses    map[pauseKey]PauseState
+	claims    map[string]time.Time // account/signal ID → claim expiry
+}
+
+var _ EngineStore = (*ShadowEngineStore)(nil)
+
+// NewShadowEngineStore opens (creating if needed) the shadow ledger at path
+// and replays it, together with the position state saved next to it. base
+// serves account and balance reads only.
+func NewShadowEngineStore(base EngineStore, path string) (*ShadowEngineStore, error) {
+	s := &ShadowEngineStore{
+		base:      base,
+		statePath: path + ".state",
+		trades:    make(map[string][]domain.Trade),
+		tradeIDs:  make(map[string]struct{}),
+		positions: make(map[shadowPosKey]*domain.Position),
+		states:    make(map[shadowPosKey]EnginePositionState),
+		breakers:  make(map[breakerKey]BreakerState),
+		pauses:    make(map[pauseKey]PauseState),
+		claims:    make(map[string]time.Time),
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create shadow ledger dir: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open shadow ledger: %w", err)
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var t domain.Trade
+		if err := json.Unmarshal(sc.Bytes(), &t); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("shadow ledger line %d: %w", line, err)
+		}
+		s.apply(&t)
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("read shadow ledger: %w", err)
+	}
+	s.ledger = f
+
+	b, err := os.ReadFile(s.statePath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		f.Close()
+		return nil, fmt.Errorf("read shadow position state: %w", err)
+	default:
+		var states []EnginePositionState
+		if err := json.Unmarshal(b, &states); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("decode shadow position state: %w", err)
+		}
+		for _, st := range states {
+			s.states[shadowPosKey{st.AccountID, st.Symbol, st.MarketType}] = st
+		}
+	}
+	return s, nil
+}
+
+// Close closes the ledger file.
+func (s *ShadowEngineStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ledger.Close()
+}
+
+// apply records t and moves its position the way the platform ledger does,
+// setting the trade's cost basis and realised P&L. A spot sell with no open
+// position has nothing to close and only records the trade. The caller holds
+// s.mu.
+func (s *ShadowEngineStore) apply(t *domain.Trade) {
+	defer func() {
+		s.trades[t.AccountID] = append(s.trades[t.AccountID], *t)
+		s.tradeIDs[t.TradeID] = struct{}{}
+	}()
+
+	key := shadowPosKey{t.AccountID, t.Symbol, string(t.MarketType)}
+	p, open := s.positions[key]
+	if !open {
+		side := domain.PositionSideLong
+		if t.Side == domain.SideSell {
+			if t.MarketType != domain.MarketTypeFutures {
+				t.CostBasis, t.RealizedPnL = 0, 0
+				return
+			}
+			side = domain.PositionSideShort
+		}
+		s.positions[key] = &domain.Position{
+			ID:            t.TradeID,
+			AccountID:     t.AccountID,
+			Symbol:        t.Symbol,
+			MarketType:    t.MarketType,
+			Side:          side,
+			Quantity:      t.Quantity,
+			AvgEntryPrice: t.Price,
+			CostBasis:     t.Quantity*t.Price + t.Fee,
+			Leverage:      t.Leverage,
+			Margin:        t.Margin,
+			Status:        domain.PositionStatusOpen,
+			OpenedAt:      t.Timestamp,
+		}
+		t.CostBasis, t.RealizedPnL = t.Quantity*t.Price+t.Fee, 0
+		return
+	}
+
+	adds := (p.Side == domain.PositionSideLong) == (t.Side == domain.SideBuy)
+	if adds {
+		qty := p.Quantity + t.Quantity
+		p.AvgEntryPrice = (p.AvgEntryPrice*p.Quantity + t.Price*t.Quantity) / qty
+		p.Quantity = qty
+		p.CostBasis += t.Quantity*t.Price + t.Fee
+		if t.Margin != nil {
+			m := *t.Margin
+			if p.Margin != nil {
+				m += *p.Margin
+			}
+			p.Margin = &m
+		}
+		t.CostBasis, t.RealizedPnL = t.Quantity*t.Price+t.Fee, 0
+		return
+	}
+
+	qty := min(t.Quantity, p.Quantity)
+	pnl := (t.Price - p.AvgEntryPrice) * qty
+	if p.Side == domain.PositionSideShort {
+		pnl = -pnl
+	}
+	pnl -= t.Fee
+	t.CostBasis, t.RealizedPnL = p.AvgEntryPrice*qty, pnl
+
+	keep := 1 - qty/p.Quantity
+	p.Quantity -= qty
+	p.CostBasis *= keep
+	p.RealizedPnL += pnl
+	if p.Margin != nil {
+		m := *p.Margin * keep
+		p.Margin = &m
+	}
+	if p.Quantity <= shadowDustQty {
+		delete(s.positions, key)
+	}
+}
+
+// InsertTradeAndUpdatePosition appends the trade to the shadow ledger.
+func (s *ShadowEngineStore) InsertTradeAndUpdatePosition(_ context.Context, _ uuid.UUID, trade *domain.Trade) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, dup := s.tradeIDs[trade.TradeID]; dup {
+		return false, nil
+	}
+
+	key := shadowPosKey{trade.AccountID, trade.Symbol, string(trade.MarketType)}
+	var prev *domain.Position
+	if p, ok := s.positions[key]; ok {
+		cp := *p
+		prev = &cp
+	}
+	t := *trade
+	s.apply(&t)
+
+	b, err := json.Marshal(t)
+	if err == nil {
+		_, err = s.ledger.Write(append(b, '\n'))
+	}
+	if err != nil {
+		// Undo so memory matches the ledger on disk.
+		trades := s.trades[t.AccountID]
+		s.trades[t.AccountID] = trades[:len(trades)-1]
+		delete(s.tradeIDs, t.TradeID)
+		if prev != nil {
+			s.positions[key] = prev
+		} else {
+			delete(s.positions, key)
+		}
+		return false, fmt.Errorf("write shadow ledger: %w", err)
+	}
+	return true, nil
+}
+
+// GetAccountBalance returns the real account's balance.
+func (s *ShadowEngineStore) GetAccountBalance(ctx context.Context, tenantID uuid.UUID, accountID, currency string) (*float64, error) {
+	return s.base.GetAccountBalance(ctx, tenantID, accountID, currency)
+}
+
+// AdjustBalance does nothing: shadow trades never move the real balance.
+func (s *ShadowEngineStore) AdjustBalance(_ context.Context, _ uuid.UUID, _, _ string, _ float64) error {
+	return nil
+}
+
+// GetAvgEntryPrice returns the average entry of the open shadow position, or 0.
+func (s *ShadowEngineStore) GetAvgEntryPrice(_ context.Context, _ uuid.UUID, accountID, symbol string, marketType domain.MarketType) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.positions[shadowPosKey{accountID, symbol, string(marketType)}]; ok {
+		return p.AvgEntryPrice, nil
+	}
+	return 0, nil
+}
+
+// CountOpenPositionStates returns the number of shadow position states for
+// the account.
+func (s *ShadowEngineStore) CountOpenPositionStates(_ context.Context, accountID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for key := range s.states {
+		if key.accountID == accountID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ListOpenPositionsForAccount returns the account's open shadow positions.
+func (s *ShadowEngineStore) ListOpenPositionsForAccount(_ context.Context, accountID string) ([]domain.Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.Position
+	for key, p := range s.positions {
+		if key.accountID == accountID {
+			out = append(out, *p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+// ListAccounts returns the tenant's real accounts.
+func (s *ShadowEngineStore) ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]domain.Account, error) {
+	return s.base.ListAccounts(ctx, tenantID)
+}
+
+// LoadPositionStates returns the account's shadow position states.
+func (s *ShadowEngineStore) LoadPositionStates(_ context.Context, accountID string) ([]EnginePositionState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []EnginePositionState
+	for key, st := range s.states {
+		if key.accountID == accountID {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+// InsertPositionState saves a new shadow position state.
+func (s *ShadowEngineStore) InsertPositionState(_ context.Context, _ uuid.UUID, st *EnginePositionState) error {
+	return s.putState(st)
+}
+
+// UpdatePositionState replaces a shadow position state.
+func (s *ShadowEngineStore) UpdatePositionState(_ context.Context, _ uuid.UUID, st *EnginePositionState) error {
+	return s.putState(st)
+}
+
+func (s *ShadowEngineStore) putState(st *EnginePositionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[shadowPosKey{st.AccountID, st.Symbol, st.MarketType}] = *st
+	return s.saveStates()
+}
+
+// DeletePositionState removes a closed shadow position's state.
+func (s *ShadowEngineStore) DeletePositionState(_ context.Context, _ uuid.UUID, symbol, marketType, accountID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, shadowPosKey{accountID, symbol, marketType})
+	return s.saveStates()
+}
+
+// saveStates rewrites the position state file via a temp file and rename.
+// The caller holds s.mu.
+func (s *ShadowEngineStore) saveStates() error {
+	states := make([]EnginePositionState, 0, len(s.states))
+	for _, st := range s.states {
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].AccountID != states[j].AccountID {
+			return states[i].AccountID < states[j].AccountID
+		}
+		return states[i].Symbol < states[j].Symbol
+	})
+	b, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("marshal shadow position state: %w", err)
+	}
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("write shadow position state: %w", err)
+	}
+	if err := os.Rename(tmp, s.statePath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write shadow position state: %w", err)
+	}
+	return nil
+}
+
+// DailyRealizedPnL sums the realised P&L of the account's shadow trades since
+// midnight UTC.
+func (s *ShadowEngineStore) DailyRealizedPnL(_ context.Context, accountID string) (float64, error) {
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var pnl float64
+	for _, t := range s.Trades(accountID, midnight, time.Time{}) {
+		pnl += t.RealizedPnL
+	}
+	return pnl, nil
+}
+
+// ListClosingTrades returns the account's shadow trades at or after since
+// that carry an exit reason or realised P&L.
+func (s *ShadowEngineStore) ListClosingTrades(_ context.Context, accountID string, since time.Time) ([]domain.Trade, error) {
+	var out []domain.Trade
+	for _, t := range s.Trades(accountID, since, time.Time{}) {
+		if t.ExitReason != nil || t.RealizedPnL != 0 {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// Trades returns the shadow trades of the account, or of every account when
+// accountID is empty, timestamped in [since, until). Zero bounds are open.
+func (s *ShadowEngineStore) Trades(accountID string, since, until time.Time) []domain.Trade {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.Trade
+	for acct, trades := range s.trades {
+		if accountID != "" && acct != accountID {
+			continue
+		}
+		for _, t := range trades {
+			if t.Timestamp.Before(since) || (!until.IsZero() && !t.Timestamp.Before(until)) {
+				continue
+			}
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+// LoadBreakerStates returns the account's in-memory shadow breaker states.
+func (s *ShadowEngineStore) LoadBreakerStates(_ context.Context, accountID string) ([]BreakerState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []BreakerState
+	for key, st := range s.breakers {
+		if key.accountID == accountID {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+// SaveBreakerState keeps a shadow breaker state in memory.
+func (s *ShadowEngineStore) SaveBreakerState(_ context.Context, st *BreakerState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakers[breakerKey{st.AccountID, st.Strategy}] = *st
+	return nil
+}
+
+// LoadPauses returns the shadow engine's operator pauses.
+func (s *ShadowEngineStore) LoadPauses(_ context.Context, _ uuid.UUID) ([]PauseState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]PauseState, 0, len(s.pauses))
+	for _, p := range s.pauses {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// SavePause keeps a shadow pause in memory.
+func (s *ShadowEngineStore) SavePause(_ context.Context, _ uuid.UUID, p *PauseState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pauses[pauseKey{p.AccountID, p.Strategy}] = *p
+	return nil
+}
+
+// DeletePause removes a shadow pause.
+func (s *ShadowEngineStore) DeletePause(_ context.Context, _ uuid.UUID, accountID, strategy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pauses, pauseKey{accountID, strategy})
+	return nil
+}
+
+// LoadPendingEntries returns nothing: shadow entries rest on the simulated
+// exchange, which does not survive a restart.
+func (s *ShadowEngineStore) LoadPendingEntries(_ context.Context, _ string) ([]PendingEntryState, error) {
+	return nil, nil
+}
+
+// SavePendingEntry is a no-op; see LoadPendingEntries.
+func (s *ShadowEngineStore) SavePendingEntry(_ context.Context, _ *PendingEntryState) error {
+	return nil
+}
+
+// DeletePendingEntry is a no-op; see LoadPendingEntries.
+func (s *ShadowEngineStore) DeletePendingEntry(_ context.Context, _, _ string) error {
+	return nil
+}
+
+// ClaimSignal deduplicates signals within this process only.
+func (s *ShadowEngineStore) ClaimSignal(_ context.Context, accountID, signalID string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := accountID + "/" + signalID
+	now := time.Now()
+	if exp, ok := s.claims[key]; ok && now.Before(exp) {
+		return false, nil
+	}
+	s.claims[key] = now.Add(ttl)
+	return true, nil
+}
+
+// ReleaseSignal forgets the in-process claim.
+func (s *ShadowEngineStore) ReleaseSignal(_ context.Context, accountID, signalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claims, accountID+"/"+signalID)
+	return nil
+}
+
+// ShadowTrades returns the shadow ledger's trades for the account, or for
+// every managed account when accountID is empty, in [since, until).
+func (e *Engine) ShadowTrades(accountID string, since, until time.Time) ([]domain.Trade, error) {
+	s, ok := e.repo.(*ShadowEngineStore)
+	if !ok {
+		return nil, ErrNotShadow
+	}
+	if err := e.checkAccount(accountID); err != nil {
+		return nil, err
+	}
+	return s.Trades(accountID, since, until), nil
+}
+
+// readShadowTradingConfigs reads SHADOW_TRADING_CONFIG_FILE in place of GET
+// /config/trading. The file's modification time and size act as the ETag.
+func (e *Engine) readShadowTradingConfigs(etag string) (configs []TradingConfig, newETag string, notModified bool, err error) {
+	path := e.cfg.ShadowConfigFile
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("read shadow trading configs: %w", err)
+	}
+	newETag = fmt.Sprintf(`"%x-%x"`, fi.ModTime().UnixNano(), fi.Size())
+	if newETag == etag {
+		return nil, etag, true, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("read shadow trading configs: %w", err)
+	}
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return nil, "", false, fmt.Errorf("decode shadow trading configs: %w", err)
+	}
+	return configs, newETag, false, nil
+}
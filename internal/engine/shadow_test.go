@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+func shadowTestTrade(id, symbol string, side domain.Side, mt domain.MarketType, qty, price float64, at time.Time) *domain.Trade {
+	return &domain.Trade{
+		TradeID: id, AccountID: "acc", Symbol: symbol, Side: side, MarketType: mt,
+		Quantity: qty, Price: price, Timestamp: at,
+	}
+}
+
+func TestShadowEngineStore_LedgerRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shadow", "ledger.jsonl")
+	s, err := NewShadowEngineStore(&stateStore{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	reason := "Take profit hit"
+
+	exit := shadowTestTrade("t2", "BTC-USD", domain.SideSell, domain.MarketTypeSpot, 1, 110, now)
+	exit.ExitReason = &reason
+	for _, tr := range []*domain.Trade{
+		shadowTestTrade("t1", "BTC-USD", domain.SideBuy, domain.MarketTypeSpot, 1, 100, now.Add(-time.Minute)),
+		exit,
+		shadowTestTrade("t3", "ETH-USD", domain.SideSell, domain.MarketTypeFutures, 2, 2000, now.Add(-time.Minute)),
+	} {
+		if ok, err := s.InsertTradeAndUpdatePosition(ctx, uuid.Nil, tr); err != nil || !ok {
+			t.Fatalf("insert %s: %v %v", tr.TradeID, ok, err)
+		}
+	}
+	if ok, _ := s.InsertTradeAndUpdatePosition(ctx, uuid.Nil, exit); ok {
+		t.Error("a duplicate trade ID should not be inserted")
+	}
+
+	avg, _ := s.GetAvgEntryPrice(ctx, uuid.Nil, "acc", "ETH-USD", domain.MarketTypeFutures)
+	assertFloat(t, "short avg entry", 2000, avg)
+	pnl, _ := s.DailyRealizedPnL(ctx, "acc")
+	assertFloat(t, "daily realised P&L", 10, pnl)
+
+	if err := s.InsertPositionState(ctx, uuid.Nil, &EnginePositionState{AccountID: "acc", Symbol: "ETH-USD", MarketType: "futures", Side: "short"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening replays the ledger and the saved position state.
+	s, err = NewShadowEngineStore(&stateStore{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if ok, _ := s.InsertTradeAndUpdatePosition(ctx, uuid.Nil, shadowTestTrade("t4", "ETH-USD", domain.SideBuy, domain.MarketTypeFutures, 2, 1990, now)); !ok {
+		t.Fatal("cover not inserted")
+	}
+	closes, _ := s.ListClosingTrades(ctx, "acc", now.Add(-time.Hour))
+	if len(closes) != 2 {
+		t.Fatalf("want two closing trades, got %+v", closes)
+	}
+	assertFloat(t, "short cover P&L", 20, closes[1].RealizedPnL)
+	if open, _ := s.ListOpenPositionsForAccount(ctx, "acc"); len(open) != 0 {
+		t.Errorf("all positions are closed, got %+v", open)
+	}
+	if n, _ := s.CountOpenPositionStates(ctx, "acc"); n != 1 {
+		t.Errorf("position state not restored: got %d", n)
+	}
+	if got := s.Trades("acc", now, time.Time{}); len(got) != 2 {
+		t.Errorf("want the two trades at or after now, got %+v", got)
+	}
+}
+
+func TestShadowTrades_RequiresShadowStore(t *testing.T) {
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = &stateStore{}
+	if _, err := e.ShadowTrades("", time.Time{}, time.Time{}); !errors.Is(err, ErrNotShadow) {
+		t.Errorf("want ErrNotShadow, got %v", err)
+	}
+}
+
+func TestReadShadowTradingConfigs_ETag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "configs.json")
+	if err := os.WriteFile(path, []byte(`[{"account_id":"acc","product_id":"BTC-USD","enabled":true}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e := makeEngine(&config.Config{TradingMode: "shadow", ShadowConfigFile: path})
+
+	configs, etag, notModified, err := e.fetchTradingConfigs(context.Background(), "")
+	if err != nil || notModified || len(configs) != 1 || configs[0].ProductID != "BTC-USD" {
+		t.Fatalf("first read: got %+v %q %v %v", configs, etag, notModified, err)
+	}
+	if _, _, notModified, err := e.fetchTradingConfigs(context.Background(), etag); err != nil || !notModified {
+		t.Errorf("unchanged file: want notModified, got %v %v", notModified, err)
+	}
+}
@@ -2,6 +2,8 @@ package engine
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -11,6 +13,7 @@ import (
 	"time"
 
 	nats "github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
 
 	"github.com/Signal-ngn/trader/internal/config"
 )
@@ -47,8 +50,14 @@ type SignalPayload struct {
 	RiskReasoning string             `json:"risk_reasoning"`
 	PositionPct   float64            `json:"position_pct"`
 	IsExit        bool               `json:"is_exit"`   // true when the strategy is closing an existing position
+	ScaleIn       bool               `json:"scale_in"`  // BUY/SHORT: add to an open same-direction position
+	ClosePct      float64            `json:"close_pct"` // SELL/COVER: fraction (0–1) of the position to close; 0 = all
 	Indicators    map[string]float64 `json:"indicators"`
 	Timestamp     int64              `json:"timestamp"` // Unix seconds
+
+	// ID is the deterministic signal ID set on receipt; "" when the signal
+	// has no timestamp to identify it by.
+	ID string `json:"-"`
 }
 
 // TradingConfig mirrors the SignalNGN server model for a trading config.
@@ -66,6 +75,39 @@ type TradingConfig struct {
 	Enabled         bool                          `json:"enabled"`
 	StrategyParams  map[string]map[string]float64 `json:"strategy_params"`
 	MinConfidence   float64                       `json:"min_confidence"`
+
+	// TakeProfitLadder stages exits at multiples of the entry risk; when set
+	// it replaces the single take-profit.
+	TakeProfitLadder []TakeProfitRung `json:"take_profit_ladder"`
+
+	// BreakEvenR moves the stop loss to entry plus fees once price has moved
+	// this many multiples of the entry risk in favour; 0 = disabled.
+	BreakEvenR float64 `json:"break_even_r"`
+
+	// Overrides of the engine-wide SIGNAL_MAX_AGE, CONFIDENCE_FLOOR and
+	// OPEN_COOLDOWN for this config; nil uses the default, 0 disables the check.
+	MaxSignalAgeSec *int     `json:"max_signal_age_sec,omitempty"`
+	ConfidenceFloor *float64 `json:"confidence_floor,omitempty"`
+	OpenCooldownSec *int     `json:"open_cooldown_sec,omitempty"`
+
+	// SizingMode selects position sizing: "percent" (default) sizes from
+	// POSITION_SIZE_PCT; "risk" risks RiskPct of equity down to the signal's
+	// stop, or ATRStopMultiple × its "atr" indicator when it has none;
+	// "kelly" bets KellyFraction of the Kelly fraction from the strategy's
+	// closed trades on the product, capped at KellyMaxPct of equity.
+	SizingMode      string  `json:"sizing_mode,omitempty"`
+	RiskPct         float64 `json:"risk_pct,omitempty"`
+	ATRStopMultiple float64 `json:"atr_stop_multiple,omitempty"`
+	KellyFraction   float64 `json:"kelly_fraction,omitempty"`
+	KellyMaxPct     float64 `json:"kelly_max_pct,omitempty"`
+}
+
+// TakeProfitRung is one stage of a take-profit ladder: close ClosePct (0–1)
+// of the position as entered once price moves R times the entry risk in its
+// favour.
+type TakeProfitRung struct {
+	R        float64 `json:"r"`
+	ClosePct float64 `json:"close_pct"`
 }
 
 // signalKey uniquely identifies a (exchange, product, granularity, strategy) tuple.
@@ -105,81 +147,6 @@ func (a signalAllowlist) allows(exchange, product, granularity, strategy string)
 	return false
 }
 
-// fetchAllowlist fetches enabled trading configs from the SN API and builds an allowlist.
-// Also returns a map of product → TradingConfig for position sizing lookups.
-func fetchAllowlist(ctx context.Context, cfg *config.Config) (signalAllowlist, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.TraderAPIURL+"/config/trading", nil)
-	if err != nil {
-		return nil, fmt.Errorf("build allowlist request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+cfg.SNAPIKey)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("fetch trading configs: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("trading config API returned %d", resp.StatusCode)
-	}
-
-	var configs []TradingConfig
-	if err := json.NewDecoder(resp.Body).Decode(&configs); err != nil {
-		return nil, fmt.Errorf("decode trading configs: %w", err)
-	}
-
-	allowlist := make(signalAllowlist)
-	for _, tc := range configs {
-		if !tc.Enabled {
-			continue
-		}
-		allStrategies := append(append(tc.StrategiesLong, tc.StrategiesShort...), tc.StrategiesSpot...)
-		for _, strat := range allStrategies {
-			allowlist[signalKey{
-				exchange:    tc.Exchange,
-				product:     tc.ProductID,
-				granularity: tc.Granularity,
-				strategy:    strat,
-			}] = struct{}{}
-		}
-	}
-	return allowlist, nil
-}
-
-// fetchTradingConfigs fetches all enabled trading configs indexed by product ID.
-func fetchTradingConfigs(ctx context.Context, cfg *config.Config) (tradingConfigByProduct, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.TraderAPIURL+"/config/trading", nil)
-	if err != nil {
-		return nil, fmt.Errorf("build config request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+cfg.SNAPIKey)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("fetch trading configs: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("trading config API returned %d", resp.StatusCode)
-	}
-
-	var configs []TradingConfig
-	if err := json.NewDecoder(resp.Body).Decode(&configs); err != nil {
-		return nil, fmt.Errorf("decode trading configs: %w", err)
-	}
-
-	m := make(tradingConfigByProduct)
-	for i := range configs {
-		tc := configs[i]
-		if tc.Enabled {
-			m[tradingConfigKey{accountID: tc.AccountID, productID: tc.ProductID}] = &tc
-		}
-	}
-	return m, nil
-}
-
 // resolveNATSCreds returns the path to a NATS credentials file.
 // If SN_NATS_CREDS_FILE is set, that path is used directly.
 // Otherwise the embedded credentials are written to a temp file.
@@ -266,28 +233,6 @@ func fetchCurrentPrice(ctx context.Context, cfg *config.Config, exchange, produc
 	return price.Close, nil
 }
 
-// startAllowlistRefresher runs a goroutine that re-fetches the allowlist every 5 minutes.
-func (e *Engine) startAllowlistRefresher(ctx context.Context) {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			al, err := fetchAllowlist(ctx, e.cfg)
-			if err != nil {
-				e.logger.Warn().Err(err).Msg("failed to refresh signal allowlist")
-				continue
-			}
-			e.allowlistMu.Lock()
-			e.allowlist = al
-			e.allowlistMu.Unlock()
-			e.logger.Debug().Int("slots", len(al)).Msg("allowlist refreshed")
-		}
-	}
-}
-
 // runSignalLoop connects to Synadia NGS and subscribes to signals.
 // Uses exponential backoff (10s → 5m) on connection failure.
 func (e *Engine) runSignalLoop(ctx context.Context) {
@@ -330,7 +275,7 @@ func (e *Engine) runSignalLoop(ctx context.Context) {
 			continue
 		}
 
-		e.ngsConn = nc
+		e.ngsConn.Store(nc)
 		e.logger.Info().Str("url", nc.ConnectedUrl()).Msg("connected to Synadia NGS")
 		backoff = 10 * time.Second // reset on success
 
@@ -385,6 +330,10 @@ func (e *Engine) handleSignal(ctx context.Context, msg *nats.Msg) {
 		return
 	}
 
+	if signal.Timestamp > 0 {
+		signal.ID = signalID(msg.Subject, signal.Timestamp, signal.Strategy)
+	}
+
 	logger := e.logger.With().
 		Str("exchange", exchange).
 		Str("product", product).
@@ -392,12 +341,13 @@ func (e *Engine) handleSignal(ctx context.Context, msg *nats.Msg) {
 		Str("action", signal.Action).
 		Float64("price", signal.Price).
 		Float64("confidence", signal.Confidence).
+		Str("signal_id", signal.ID).
 		Logger()
 
 	// 1. Allowlist check.
-	e.allowlistMu.RLock()
+	e.configMu.RLock()
 	al := e.allowlist
-	e.allowlistMu.RUnlock()
+	e.configMu.RUnlock()
 	if !al.allows(exchange, product, granularity, strategy) {
 		return // silent drop
 	}
@@ -407,38 +357,25 @@ func (e *Engine) handleSignal(ctx context.Context, msg *nats.Msg) {
 		return // silent drop
 	}
 
-	// 3. Stale signal check.
-	// Signals carry the candle-close time as their timestamp. The shortest
-	// configured granularity is FIVE_MINUTES, so a freshly-emitted signal is
-	// already up to ~90 s old by the time the engine receives it (compute +
-	// publish latency). Allow one full candle period (5 min) plus a 5-minute
-	// processing buffer = 10 minutes total. Anything older is a replay or a
-	// stale batch from a strategy that was offline.
-	if signal.Timestamp > 0 {
-		age := time.Since(time.Unix(signal.Timestamp, 0))
-		if age > 10*time.Minute {
-			logger.Warn().Dur("age", age).Msg("signal too old, dropping")
-			return
-		}
-	}
+	e.recordSignal(msg.Subject, strategy, signal)
 
-	// 4. Global confidence floor for entry signals.
-	// Per-config thresholds (min_confidence) are enforced later in handleOpenSignal
-	// once the trading config is loaded. This 0.5 floor is a cheap early exit for
-	// obviously low-quality signals before any DB or API work is done.
-	if (signal.Action == "BUY" || signal.Action == "SHORT") && signal.Confidence < 0.5 {
-		logger.Debug().Msg("signal confidence below global floor of 0.5, dropping")
-		return
-	}
+	// Staleness, the confidence floor and the open cooldown depend on the
+	// account's trading config and are applied per account in routeSignal.
 
 	// Cache the signal price — used by the risk loop as current market price.
 	// Also trigger immediate risk evaluation for all open positions on this symbol
 	// (tick-level latency instead of waiting for the 30-second periodic ticker).
-	if signal.Price > 0 {
+	// Simulated exchanges see the price too so resting paper orders can fill.
+	// Signals older than the engine-wide SIGNAL_MAX_AGE are not a current price.
+	if signal.Price > 0 && !signalStale(signal, e.cfg.SignalMaxAge, time.Now()) {
 		e.lastPriceMu.Lock()
 		e.lastPrice[product] = signal.Price
 		e.lastPriceMu.Unlock()
-		go e.evaluateOpenPositionsForSymbol(ctx, product)
+		e.observePrice(product, signal.Price)
+		if po, ok := e.exchange.(priceObserver); ok {
+			po.ObservePrice(product, signal.Price)
+		}
+		e.checkSymbolPrice(ctx, product)
 	}
 
 	// Build the set of accounts this signal targets.
@@ -451,20 +388,181 @@ func (e *Engine) handleSignal(ctx context.Context, msg *nats.Msg) {
 		return
 	}
 
-	// Route to position engine for each target account.
+	// Route to position engine for each target account. Each account+product
+	// has its own ordered queue, so an open, a close and a risk exit for the
+	// same position never run concurrently. A full queue drops the signal
+	// rather than stall the NATS callback.
+	q := e.queues.Load()
 	for _, accountID := range targetAccounts {
-		// Per-account cooldown check (only for opening actions).
-		if signal.Action == "BUY" || signal.Action == "SHORT" {
-			key := cooldownKey{accountID: accountID, symbol: product, action: signal.Action}
-			e.cooldownMu.Lock()
-			expiry, active := e.cooldown[key]
-			e.cooldownMu.Unlock()
-			if active && time.Now().Before(expiry) {
-				remaining := time.Until(expiry).Round(time.Second)
-				logger.Debug().Str("account", accountID).Dur("remaining", remaining).Msg("cooldown active, dropping signal")
-				continue
-			}
+		accountID := accountID
+		route := func() { e.routeSignal(ctx, signal, product, strategy, accountID, logger) }
+		if q == nil {
+			route()
+			continue
 		}
-		e.processSignal(ctx, signal, product, strategy, accountID)
+		if !q.submit(posKey(accountID, product), route) {
+			logger.Error().Str("account", accountID).Int("depth", e.cfg.SignalQueueDepth).
+				Msg("signal queue full — dropping signal")
+		}
+	}
+}
+
+// routeSignal applies the staleness, confidence-floor, cooldown and dedup
+// checks for one account and hands the signal to the position engine. Runs on
+// the account+product queue, so the cooldown set by an open is seen by the
+// next signal in line.
+func (e *Engine) routeSignal(ctx context.Context, signal SignalPayload, product, strategy, accountID string, logger zerolog.Logger) {
+	tc, _ := e.tradingConfig(accountID, product)
+	th := e.thresholdsFor(tc)
+	e.cooldownMu.Lock()
+	cooldownUntil := e.cooldown[cooldownKey{accountID: accountID, symbol: product, action: signal.Action}]
+	e.cooldownMu.Unlock()
+
+	now := time.Now()
+	switch gateSignal(signal, th, cooldownUntil, now) {
+	case gateStale:
+		logger.Warn().Str("account", accountID).Dur("age", now.Sub(time.Unix(signal.Timestamp, 0))).
+			Dur("max_age", th.maxAge).Msg("signal too old, dropping")
+		return
+	case gateConfidence:
+		logger.Debug().Str("account", accountID).Float64("floor", th.confidenceFloor).
+			Msg("signal confidence below floor, dropping")
+		return
+	case gateCooldown:
+		logger.Debug().Str("account", accountID).Dur("remaining", cooldownUntil.Sub(now).Round(time.Second)).
+			Msg("cooldown active, dropping signal")
+		return
+	}
+	// Most exit signals reach accounts with nothing to close; they are
+	// dropped before the claim to spare the claim write.
+	if signal.Action == "SELL" || signal.Action == "COVER" {
+		e.posStateMu.RLock()
+		_, open := e.posState[posKey(accountID, product)]
+		e.posStateMu.RUnlock()
+		if !open {
+			logger.Debug().Str("account", accountID).Msg("no open position state for account+product, ignoring close signal")
+			return
+		}
+	}
+	// Redeliveries and other replicas of this engine see the same signal.
+	if !e.claimSignal(ctx, signal, accountID) {
+		logger.Debug().Str("account", accountID).Msg("signal already processed, dropping duplicate")
+		return
+	}
+	if err := e.processSignal(ctx, signal, product, strategy, accountID); err != nil {
+		// The signal was not carried out: let a redelivery try again.
+		e.releaseSignal(ctx, signal, accountID)
+	}
+}
+
+// signalThresholds are the staleness, confidence-floor and open-cooldown
+// limits a signal must pass for one account.
+type signalThresholds struct {
+	maxAge          time.Duration // 0 = no staleness check
+	confidenceFloor float64       // BUY/SHORT only
+	openCooldown    time.Duration // set after each open
+}
+
+// thresholdsFor resolves the limits for a trading config: its own overrides
+// where set, otherwise the engine-wide SIGNAL_MAX_AGE, CONFIDENCE_FLOOR and
+// OPEN_COOLDOWN. tc may be nil.
+func (e *Engine) thresholdsFor(tc *TradingConfig) signalThresholds {
+	th := signalThresholds{
+		maxAge:          e.cfg.SignalMaxAge,
+		confidenceFloor: e.cfg.ConfidenceFloor,
+		openCooldown:    e.cfg.OpenCooldown,
+	}
+	if tc == nil {
+		return th
+	}
+	if tc.MaxSignalAgeSec != nil {
+		th.maxAge = time.Duration(*tc.MaxSignalAgeSec) * time.Second
+	}
+	if tc.ConfidenceFloor != nil {
+		th.confidenceFloor = *tc.ConfidenceFloor
+	}
+	if tc.OpenCooldownSec != nil {
+		th.openCooldown = time.Duration(*tc.OpenCooldownSec) * time.Second
+	}
+	return th
+}
+
+// Reasons gateSignal drops a signal.
+const (
+	gateStale      = "stale"
+	gateConfidence = "confidence"
+	gateCooldown   = "cooldown"
+)
+
+// gateSignal applies, in order, the staleness check, the confidence floor for
+// entries and the open cooldown. Returns the gate that dropped the signal, or
+// "" when it passes. cooldownUntil is the cooldown expiry for this account,
+// symbol and action (zero when none).
+//
+// Signals carry the candle-close time as their timestamp, so a fresh signal is
+// already up to a candle plus publish latency old; the max age has to allow
+// for the config's granularity.
+func gateSignal(signal SignalPayload, th signalThresholds, cooldownUntil, now time.Time) string {
+	if signalStale(signal, th.maxAge, now) {
+		return gateStale
+	}
+	entry := signal.Action == "BUY" || signal.Action == "SHORT"
+	if entry && signal.Confidence < th.confidenceFloor {
+		return gateConfidence
+	}
+	if entry && now.Before(cooldownUntil) {
+		return gateCooldown
+	}
+	return ""
+}
+
+// signalStale reports whether a timestamped signal is older than maxAge.
+// Signals without a timestamp and a zero maxAge are never stale.
+func signalStale(signal SignalPayload, maxAge time.Duration, now time.Time) bool {
+	if signal.Timestamp <= 0 || maxAge <= 0 {
+		return false
+	}
+	return now.Sub(time.Unix(signal.Timestamp, 0)) > maxAge
+}
+
+// signalID derives a stable ID for a signal from its subject, candle
+// timestamp and strategy, so every delivery of the same signal — to this
+// process or another replica — maps to the same ID.
+func signalID(subject string, timestamp int64, strategy string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s", subject, timestamp, strategy)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// claimSignal records the signal as processed for the account in the shared
+// dedup store and reports whether this delivery should be handled. If the
+// store is unavailable the signal is handled anyway: its trade IDs derive
+// from the signal ID, so a duplicate trade is still rejected by the ledger.
+func (e *Engine) claimSignal(ctx context.Context, signal SignalPayload, accountID string) bool {
+	if signal.ID == "" || e.cfg.SignalDedupTTL <= 0 {
+		return true
+	}
+	claimed, err := e.repo.ClaimSignal(ctx, accountID, signal.ID, e.cfg.SignalDedupTTL)
+	if err != nil {
+		e.logger.Warn().Err(err).
+			Str("account", accountID).
+			Str("signal_id", signal.ID).
+			Msg("signal dedup store unavailable — relying on ledger trade-ID dedup")
+		return true
+	}
+	return claimed
+}
+
+// releaseSignal drops the account's claim on a signal whose processing
+// failed. If the release fails the claim simply expires after
+// SIGNAL_DEDUP_TTL.
+func (e *Engine) releaseSignal(ctx context.Context, signal SignalPayload, accountID string) {
+	if signal.ID == "" || e.cfg.SignalDedupTTL <= 0 {
+		return
+	}
+	if err := e.repo.ReleaseSignal(ctx, accountID, signal.ID); err != nil {
+		e.logger.Warn().Err(err).
+			Str("account", accountID).
+			Str("signal_id", signal.ID).
+			Msg("failed to release signal claim")
 	}
 }
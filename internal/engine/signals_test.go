@@ -1,8 +1,14 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
 )
 
 // ── signalAllowlist.allows ────────────────────────────────────────────────────
@@ -264,6 +270,67 @@ func TestSignalStale_OldRejected(t *testing.T) {
 	}
 }
 
+func TestThresholdsFor_ConfigOverridesDefaults(t *testing.T) {
+	e := makeEngine(&config.Config{SignalMaxAge: 10 * time.Minute, ConfidenceFloor: 0.5, OpenCooldown: 5 * time.Minute})
+	age, cooldown, floor := 120, 0, 0.7
+
+	tests := []struct {
+		name string
+		tc   *TradingConfig
+		want signalThresholds
+	}{
+		{"no config", nil, signalThresholds{10 * time.Minute, 0.5, 5 * time.Minute}},
+		{"no overrides", &TradingConfig{}, signalThresholds{10 * time.Minute, 0.5, 5 * time.Minute}},
+		{"scalping", &TradingConfig{MaxSignalAgeSec: &age, ConfidenceFloor: &floor, OpenCooldownSec: &cooldown},
+			signalThresholds{2 * time.Minute, 0.7, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.thresholdsFor(tt.tc); got != tt.want {
+				t.Errorf("want %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGateSignal(t *testing.T) {
+	now := time.Unix(1_750_000_000, 0)
+	ago := func(d time.Duration) int64 { return now.Add(-d).Unix() }
+	swing := signalThresholds{maxAge: 26 * time.Hour, confidenceFloor: 0.6, openCooldown: 24 * time.Hour}
+	scalp := signalThresholds{maxAge: 90 * time.Second, confidenceFloor: 0.4, openCooldown: 0}
+	cooling := now.Add(time.Minute)
+
+	tests := []struct {
+		name          string
+		signal        SignalPayload
+		th            signalThresholds
+		cooldownUntil time.Time
+		want          string
+	}{
+		{"fresh entry passes", SignalPayload{Action: "BUY", Confidence: 0.7, Timestamp: ago(time.Hour)}, swing, time.Time{}, ""},
+		{"swing accepts a day-old candle", SignalPayload{Action: "SHORT", Confidence: 0.7, Timestamp: ago(25 * time.Hour)}, swing, time.Time{}, ""},
+		{"scalp drops a 2-minute-old signal", SignalPayload{Action: "BUY", Confidence: 0.9, Timestamp: ago(2 * time.Minute)}, scalp, time.Time{}, gateStale},
+		{"no timestamp is never stale", SignalPayload{Action: "BUY", Confidence: 0.9}, scalp, time.Time{}, ""},
+		{"zero max age disables staleness", SignalPayload{Action: "BUY", Confidence: 0.9, Timestamp: ago(48 * time.Hour)}, signalThresholds{}, time.Time{}, ""},
+		{"below floor", SignalPayload{Action: "BUY", Confidence: 0.5, Timestamp: ago(time.Hour)}, swing, time.Time{}, gateConfidence},
+		{"at floor passes", SignalPayload{Action: "BUY", Confidence: 0.6, Timestamp: ago(time.Hour)}, swing, time.Time{}, ""},
+		{"scalp floor is lower", SignalPayload{Action: "BUY", Confidence: 0.45, Timestamp: ago(time.Minute)}, scalp, time.Time{}, ""},
+		{"floor ignores exits", SignalPayload{Action: "SELL", Confidence: 0.1, Timestamp: ago(time.Hour)}, swing, time.Time{}, ""},
+		{"cooldown active", SignalPayload{Action: "BUY", Confidence: 0.9, Timestamp: ago(time.Hour)}, swing, cooling, gateCooldown},
+		{"cooldown expired", SignalPayload{Action: "BUY", Confidence: 0.9, Timestamp: ago(time.Hour)}, swing, now.Add(-time.Second), ""},
+		{"cooldown ignores exits", SignalPayload{Action: "COVER", Confidence: 0.9, Timestamp: ago(time.Hour)}, swing, cooling, ""},
+		{"stale is checked before confidence", SignalPayload{Action: "BUY", Confidence: 0.1, Timestamp: ago(5 * time.Minute)}, scalp, cooling, gateStale},
+		{"confidence is checked before cooldown", SignalPayload{Action: "BUY", Confidence: 0.1, Timestamp: ago(time.Minute)}, scalp, cooling, gateConfidence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gateSignal(tt.signal, tt.th, tt.cooldownUntil, now); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
 // ── exit_confidence gate (IsExit bypass) ─────────────────────────────────────
 //
 // ML strategies self-govern exit confidence server-side. When is_exit=true the
@@ -339,3 +406,108 @@ func TestExitConfidenceGate_ZeroConfidence_NotFiltered(t *testing.T) {
 		t.Fatal("zero confidence should not be filtered by exit_confidence gate")
 	}
 }
+
+// ── signal deduplication ──────────────────────────────────────────────────────
+
+// claimStore is an EngineStore with an in-memory signal claim set.
+type claimStore struct {
+	EngineStore
+	claimed map[string]bool
+	err     error
+}
+
+func (s *claimStore) ClaimSignal(_ context.Context, accountID, signalID string, _ time.Duration) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	key := accountID + "/" + signalID
+	if s.claimed[key] {
+		return false, nil
+	}
+	s.claimed[key] = true
+	return true, nil
+}
+
+func (s *claimStore) ReleaseSignal(_ context.Context, accountID, signalID string) error {
+	delete(s.claimed, accountID+"/"+signalID)
+	return nil
+}
+
+func TestSignalID_Deterministic(t *testing.T) {
+	subject := "signals.coinbase.BTC-USD.ONE_HOUR.ml_xgboost"
+	a := signalID(subject, 1740912000, "ml_xgboost")
+	if a != signalID(subject, 1740912000, "ml_xgboost") {
+		t.Fatal("same signal must map to the same ID")
+	}
+	if a == signalID(subject, 1740915600, "ml_xgboost") {
+		t.Error("different timestamp must change the ID")
+	}
+	if a == signalID(subject, 1740912000, "macd_rsi") {
+		t.Error("different strategy must change the ID")
+	}
+}
+
+func TestClaimSignal_DropsDuplicatePerAccount(t *testing.T) {
+	store := &claimStore{claimed: make(map[string]bool)}
+	e := makeEngine(&config.Config{SignalDedupTTL: 30 * time.Minute})
+	e.repo = store
+	ctx := context.Background()
+	signal := SignalPayload{ID: "abc"}
+
+	if !e.claimSignal(ctx, signal, "live") {
+		t.Fatal("first delivery should be handled")
+	}
+	if e.claimSignal(ctx, signal, "live") {
+		t.Error("redelivery should be dropped")
+	}
+	if !e.claimSignal(ctx, signal, "paper") {
+		t.Error("another account should still see the signal")
+	}
+}
+
+func TestClaimSignal_FailsOpen(t *testing.T) {
+	e := makeEngine(&config.Config{SignalDedupTTL: 30 * time.Minute})
+	e.repo = &claimStore{err: errors.New("firestore unavailable")}
+	if !e.claimSignal(context.Background(), SignalPayload{ID: "abc"}, "live") {
+		t.Error("store errors should not drop the signal")
+	}
+
+	// No ID (untimestamped signal) or dedup disabled: the store is not consulted.
+	e.repo = nil
+	if !e.claimSignal(context.Background(), SignalPayload{}, "live") {
+		t.Error("signal without an ID should be handled")
+	}
+	e.cfg.SignalDedupTTL = 0
+	if !e.claimSignal(context.Background(), SignalPayload{ID: "abc"}, "live") {
+		t.Error("dedup disabled should handle every signal")
+	}
+}
+
+func TestRouteSignal_FailedCloseReleasesClaim(t *testing.T) {
+	// No ledger quantity behind the position state, so the close fails.
+	store := &claimStore{EngineStore: &ladderStore{}, claimed: make(map[string]bool)}
+	e := makeEngine(&config.Config{TradingMode: "paper", SignalDedupTTL: 30 * time.Minute})
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	e.tradingConfigs = map[tradingConfigKey]*TradingConfig{{accountID: "acc", productID: "BTC-USD"}: {}}
+	ctx := context.Background()
+	signal := SignalPayload{ID: "abc", Action: "SELL", Price: 50000}
+
+	e.routeSignal(ctx, signal, "BTC-USD", "trend", "acc", e.logger)
+	if len(store.claimed) != 0 {
+		t.Fatal("an exit signal for a flat account should not be claimed")
+	}
+
+	e.posState[posKey("acc", "BTC-USD")] = &PositionState{AccountID: "acc", Symbol: "BTC-USD", MarketType: "spot", Side: "long", EntryPrice: 49000}
+	e.routeSignal(ctx, signal, "BTC-USD", "trend", "acc", e.logger)
+	if store.claimed["acc/abc"] {
+		t.Error("a signal whose close failed should be released for redelivery")
+	}
+}
+
+func TestEngineTradeID(t *testing.T) {
+	now := time.Unix(0, 1740912000000000000)
+	assertEq(t, "open from signal", "engine-live-BTC-USD-abc", engineTradeID("", "live", "BTC-USD", "abc", now))
+	assertEq(t, "close from signal", "engine-close-live-BTC-USD-abc", engineTradeID("close", "live", "BTC-USD", "abc", now))
+	assertEq(t, "risk close", "engine-close-live-BTC-USD-1740912000000000000", engineTradeID("close", "live", "BTC-USD", "", now))
+}
@@ -0,0 +1,286 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status summarises what the engine is doing right now.
+type Status struct {
+	Mode          string              `json:"mode"` // "paper" or "live"
+	Leader        bool                `json:"leader"`
+	Accounts      []string            `json:"accounts"`
+	NGS           NGSStatus           `json:"ngs"`
+	LastRiskLoop  time.Time           `json:"last_risk_loop,omitzero"` // last completed pass; zero = none yet
+	KillSwitch    bool                `json:"kill_switch"`             // KILL_SWITCH_FILE exists
+	TradingConfig TradingConfigStatus `json:"trading_config"`
+	Positions     int                 `json:"positions"`
+	Pending       int                 `json:"pending_entries"`
+	Pauses        int                 `json:"pauses"`
+	Breakers      int                 `json:"tripped_breakers"`
+	OutboxDepth   int                 `json:"outbox_depth"`
+	DeadLetters   int                 `json:"outbox_dead_letters"`
+	Queues        QueueStats          `json:"signal_queues"`
+}
+
+// NGSStatus is the state of the Synadia NGS signal connection.
+type NGSStatus struct {
+	State string `json:"state"` // nats connection status, or "NOT_STARTED"
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"` // last connection error
+}
+
+// PositionStatus is the in-memory risk state of one open position, marked at
+// the last price seen for its symbol.
+type PositionStatus struct {
+	AccountID     string    `json:"account_id"`
+	Symbol        string    `json:"symbol"`
+	MarketType    string    `json:"market_type"`
+	Exchange      string    `json:"exchange,omitempty"`
+	Side          string    `json:"side"`
+	Strategy      string    `json:"strategy,omitempty"`
+	Leverage      int       `json:"leverage,omitempty"`
+	EntryPrice    float64   `json:"entry_price"`
+	Quantity      float64   `json:"quantity,omitempty"`
+	StopLoss      float64   `json:"stop_loss,omitempty"`
+	TakeProfit    float64   `json:"take_profit,omitempty"`
+	HardStop      float64   `json:"hard_stop,omitempty"`
+	TrailingStop  float64   `json:"trailing_stop,omitempty"`
+	PeakPrice     float64   `json:"peak_price,omitempty"`
+	BreakEven     bool      `json:"break_even,omitempty"`
+	MarkPrice     float64   `json:"mark_price,omitempty"` // 0 = no price seen yet
+	UnrealizedPnL float64   `json:"unrealized_pnl,omitempty"`
+	OpenedAt      time.Time `json:"opened_at"`
+	Closing       bool      `json:"closing,omitempty"`
+}
+
+// CooldownStatus is an active open cooldown.
+type CooldownStatus struct {
+	AccountID string    `json:"account_id"`
+	Symbol    string    `json:"symbol"`
+	Action    string    `json:"action"`
+	Until     time.Time `json:"until"`
+}
+
+// ConflictStatus is the direction the conflict guard holds for a symbol.
+type ConflictStatus struct {
+	AccountID string `json:"account_id"`
+	Symbol    string `json:"symbol"`
+	Side      string `json:"side"`
+}
+
+// AllowlistEntry is one (exchange, product, granularity, strategy) slot the
+// engine accepts signals for.
+type AllowlistEntry struct {
+	Exchange    string `json:"exchange"`
+	Product     string `json:"product"`
+	Granularity string `json:"granularity"`
+	Strategy    string `json:"strategy"`
+}
+
+// SignalRecord is the last allowlisted signal received on an NGS subject.
+type SignalRecord struct {
+	Subject    string    `json:"subject"`
+	Strategy   string    `json:"strategy"`
+	Action     string    `json:"action"`
+	Price      float64   `json:"price"`
+	Confidence float64   `json:"confidence"`
+	SignalID   string    `json:"signal_id,omitempty"`
+	Timestamp  time.Time `json:"timestamp,omitzero"` // candle time carried by the signal
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// recordSignal remembers signal as the last one received on subject.
+func (e *Engine) recordSignal(subject, strategy string, signal SignalPayload) {
+	rec := SignalRecord{
+		Subject:    subject,
+		Strategy:   strategy,
+		Action:     signal.Action,
+		Price:      signal.Price,
+		Confidence: signal.Confidence,
+		SignalID:   signal.ID,
+		ReceivedAt: time.Now().UTC(),
+	}
+	if signal.Timestamp > 0 {
+		rec.Timestamp = time.Unix(signal.Timestamp, 0).UTC()
+	}
+	e.signalsMu.Lock()
+	e.signals[subject] = rec
+	e.signalsMu.Unlock()
+}
+
+// splitPosKey reverses posKey.
+func splitPosKey(key string) (accountID, symbol string) {
+	i := strings.LastIndex(key, ":")
+	if i < 0 {
+		return "", key
+	}
+	return key[:i], key[i+1:]
+}
+
+// Status returns a summary of the engine's state.
+func (e *Engine) Status() Status {
+	st := Status{
+		Mode:          e.cfg.TradingMode,
+		Leader:        e.IsLeader(),
+		Accounts:      e.accounts,
+		NGS:           NGSStatus{State: "NOT_STARTED"},
+		KillSwitch:    e.killSwitchActive(),
+		TradingConfig: e.TradingConfigStatus(),
+		Breakers:      len(e.Breakers()),
+		OutboxDepth:   e.OutboxDepth(),
+		DeadLetters:   e.OutboxDeadLetters(),
+		Queues:        e.QueueStats(),
+	}
+	if nc := e.ngsConn.Load(); nc != nil {
+		st.NGS.State = nc.Status().String()
+		st.NGS.URL = nc.ConnectedUrl()
+		if err := nc.LastError(); err != nil {
+			st.NGS.Error = err.Error()
+		}
+	}
+	if ns := e.lastRiskLoop.Load(); ns > 0 {
+		st.LastRiskLoop = time.Unix(0, ns).UTC()
+	}
+	e.posStateMu.RLock()
+	st.Positions = len(e.posState)
+	e.posStateMu.RUnlock()
+	e.pendingMu.Lock()
+	st.Pending = len(e.pending)
+	e.pendingMu.Unlock()
+	e.pauseMu.RLock()
+	st.Pauses = len(e.pauses)
+	e.pauseMu.RUnlock()
+	return st
+}
+
+// statusOf copies the risk state of ps; the caller holds posStateMu.
+func statusOf(ps *PositionState) PositionStatus {
+	return PositionStatus{
+		AccountID:    ps.AccountID,
+		Symbol:       ps.Symbol,
+		MarketType:   ps.MarketType,
+		Exchange:     ps.Exchange,
+		Side:         ps.Side,
+		Strategy:     ps.Strategy,
+		Leverage:     ps.Leverage,
+		EntryPrice:   ps.EntryPrice,
+		Quantity:     ps.Quantity,
+		StopLoss:     ps.StopLoss,
+		TakeProfit:   ps.TakeProfit,
+		HardStop:     ps.HardStop,
+		TrailingStop: ps.TrailingStop,
+		PeakPrice:    ps.PeakPrice,
+		BreakEven:    ps.BreakEven,
+		OpenedAt:     ps.OpenedAt,
+		Closing:      ps.Closing,
+	}
+}
+
+// unrealizedPnLAt returns the P&L of quantity held on side from entry, marked
+// at mark.
+func unrealizedPnLAt(side string, entry, quantity, mark float64) float64 {
+	pnl := (mark - entry) * quantity
+	if side == "short" {
+		return -pnl
+	}
+	return pnl
+}
+
+// PositionStatuses returns the in-memory state of every open position.
+func (e *Engine) PositionStatuses() []PositionStatus {
+	e.posStateMu.RLock()
+	out := make([]PositionStatus, 0, len(e.posState))
+	for _, ps := range e.posState {
+		out = append(out, statusOf(ps))
+	}
+	e.posStateMu.RUnlock()
+
+	e.lastPriceMu.RLock()
+	for i := range out {
+		p := &out[i]
+		p.MarkPrice = e.lastPrice[p.Symbol]
+		if p.MarkPrice > 0 {
+			p.UnrealizedPnL = unrealizedPnLAt(p.Side, p.EntryPrice, p.Quantity, p.MarkPrice)
+		}
+	}
+	e.lastPriceMu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AccountID != out[j].AccountID {
+			return out[i].AccountID < out[j].AccountID
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// Cooldowns returns the open cooldowns that have not yet expired.
+func (e *Engine) Cooldowns() []CooldownStatus {
+	now := time.Now()
+	e.cooldownMu.Lock()
+	out := make([]CooldownStatus, 0, len(e.cooldown))
+	for key, until := range e.cooldown {
+		if until.After(now) {
+			out = append(out, CooldownStatus{AccountID: key.accountID, Symbol: key.symbol, Action: key.action, Until: until})
+		}
+	}
+	e.cooldownMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Until.Before(out[j].Until) })
+	return out
+}
+
+// Conflicts returns the direction conflict guard.
+func (e *Engine) Conflicts() []ConflictStatus {
+	e.conflictMu.Lock()
+	out := make([]ConflictStatus, 0, len(e.conflict))
+	for key, side := range e.conflict {
+		accountID, symbol := splitPosKey(key)
+		out = append(out, ConflictStatus{AccountID: accountID, Symbol: symbol, Side: side})
+	}
+	e.conflictMu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AccountID != out[j].AccountID {
+			return out[i].AccountID < out[j].AccountID
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// Allowlist returns the signal slots built from the cached trading configs.
+func (e *Engine) Allowlist() []AllowlistEntry {
+	e.configMu.RLock()
+	out := make([]AllowlistEntry, 0, len(e.allowlist))
+	for key := range e.allowlist {
+		out = append(out, AllowlistEntry{Exchange: key.exchange, Product: key.product, Granularity: key.granularity, Strategy: key.strategy})
+	}
+	e.configMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		if a.Product != b.Product {
+			return a.Product < b.Product
+		}
+		if a.Granularity != b.Granularity {
+			return a.Granularity < b.Granularity
+		}
+		return a.Strategy < b.Strategy
+	})
+	return out
+}
+
+// LastSignals returns the last allowlisted signal per subject, newest first.
+func (e *Engine) LastSignals() []SignalRecord {
+	e.signalsMu.RLock()
+	out := make([]SignalRecord, 0, len(e.signals))
+	for _, rec := range e.signals {
+		out = append(out, rec)
+	}
+	e.signalsMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ReceivedAt.After(out[j].ReceivedAt) })
+	return out
+}
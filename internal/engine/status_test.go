@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/config"
+)
+
+func TestPositionStatuses_MarksAtLastPrice(t *testing.T) {
+	e := makeEngine(&config.Config{})
+	e.posState[posKey("acc", "ETH-USD")] = &PositionState{
+		AccountID: "acc", Symbol: "ETH-USD", Side: "short", EntryPrice: 2000, Quantity: 2, HardStop: 2400, TrailingStop: 1950,
+	}
+	e.posState[posKey("acc", "BTC-USD")] = &PositionState{AccountID: "acc", Symbol: "BTC-USD", Side: "long", EntryPrice: 50000, Quantity: 1}
+	e.lastPrice["ETH-USD"] = 1900
+
+	got := e.PositionStatuses()
+	if len(got) != 2 || got[0].Symbol != "BTC-USD" {
+		t.Fatalf("want both positions sorted by symbol, got %+v", got)
+	}
+	if got[0].MarkPrice != 0 || got[0].UnrealizedPnL != 0 {
+		t.Errorf("no price seen: want no mark, got %+v", got[0])
+	}
+	eth := got[1]
+	assertFloat(t, "mark", 1900, eth.MarkPrice)
+	assertFloat(t, "short unrealised P&L", 200, eth.UnrealizedPnL)
+	assertFloat(t, "hard stop", 2400, eth.HardStop)
+	assertFloat(t, "trailing stop", 1950, eth.TrailingStop)
+}
+
+func TestCooldownsAndConflicts(t *testing.T) {
+	e := makeEngine(&config.Config{})
+	now := time.Now()
+	e.cooldown[cooldownKey{accountID: "acc", symbol: "BTC-USD", action: "BUY"}] = now.Add(time.Minute)
+	e.cooldown[cooldownKey{accountID: "acc", symbol: "ETH-USD", action: "BUY"}] = now.Add(-time.Minute)
+	e.conflict[posKey("acc", "BTC-USD")] = "long"
+
+	cds := e.Cooldowns()
+	if len(cds) != 1 || cds[0].Symbol != "BTC-USD" || cds[0].Action != "BUY" {
+		t.Errorf("want only the unexpired BTC-USD cooldown, got %+v", cds)
+	}
+	cfs := e.Conflicts()
+	if len(cfs) != 1 || cfs[0] != (ConflictStatus{AccountID: "acc", Symbol: "BTC-USD", Side: "long"}) {
+		t.Errorf("conflicts: got %+v", cfs)
+	}
+}
+
+func TestStatus_SignalsAndRiskLoop(t *testing.T) {
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.allowlist = signalAllowlist{{exchange: "binance", product: "BTC-USD", granularity: "ONE_HOUR", strategy: "trend"}: {}}
+
+	st := e.Status()
+	if st.NGS.State != "NOT_STARTED" || !st.LastRiskLoop.IsZero() {
+		t.Errorf("fresh engine: got %+v", st)
+	}
+
+	subject := "signals.binance.BTC-USD.ONE_HOUR.trend"
+	e.recordSignal(subject, "trend", SignalPayload{Action: "BUY", Price: 100, Timestamp: 1700000000})
+	e.recordSignal(subject, "trend", SignalPayload{Action: "SELL", Price: 110, Timestamp: 1700003600})
+	e.lastRiskLoop.Store(time.Now().UnixNano())
+
+	signals := e.LastSignals()
+	if len(signals) != 1 || signals[0].Action != "SELL" || !signals[0].Timestamp.Equal(time.Unix(1700003600, 0)) {
+		t.Errorf("want the last signal on the subject, got %+v", signals)
+	}
+	if e.Status().LastRiskLoop.IsZero() {
+		t.Error("risk loop run time not reported")
+	}
+	if al := e.Allowlist(); len(al) != 1 || al[0].Strategy != "trend" {
+		t.Errorf("allowlist: got %+v", al)
+	}
+}
@@ -17,6 +17,7 @@ type EnginePositionState struct {
 	AccountID    string
 	Symbol       string
 	MarketType   string
+	Exchange     string  // venue holding the position ("binance", "coinbase"); "" = unknown
 	Side         string  // "long" or "short"
 	EntryPrice   float64
 	StopLoss     float64
@@ -28,6 +29,59 @@ type EnginePositionState struct {
 	OpenedAt     time.Time
 	PeakPrice    float64
 	TrailingStop float64
+
+	// Exchange-side protective order IDs (live mode); "" = none placed.
+	StopOrderID       string
+	TakeProfitOrderID string
+
+	// Open quantity and the fills layered into it; EntryPrice is their
+	// quantity-weighted average. Zero/empty for positions opened before
+	// layering was tracked.
+	Quantity float64
+	Entries  []PositionEntry
+
+	// Take-profit ladder rungs, fixed at entry; nil = single take-profit.
+	Ladder []LadderRung
+
+	// Break-even rule from the trading config. Once triggered, BreakEven is
+	// set, StopLoss holds the break-even price and EntryStopLoss the stop it
+	// replaced (0 = none).
+	BreakEvenR    float64
+	BreakEven     bool
+	EntryStopLoss float64
+}
+
+// PositionEntry is one fill layered into a position — the initial entry or a
+// scale-in.
+type PositionEntry struct {
+	Price    float64
+	Quantity float64
+	Fee      float64
+	At       time.Time
+}
+
+// LadderRung is a take-profit ladder stage resolved to a price at entry.
+type LadderRung struct {
+	R        float64 // multiple of entry risk
+	Price    float64 // trigger price
+	ClosePct float64 // fraction of the position as entered
+	Filled   bool
+}
+
+// PendingEntryState is a resting live entry order, persisted so a restarted
+// engine or a new leader keeps polling it and cancels it at
+// LIMIT_ORDER_TIMEOUT.
+type PendingEntryState struct {
+	AccountID    string
+	Symbol       string
+	Exchange     string // venue the order rests on
+	OrderID      string
+	Trade        domain.Trade // prepared open trade, recorded on fill
+	SignalPrice  float64
+	PositionSide string // "long" or "short"
+	Leverage     int
+	PlacedAt     time.Time
+	ExpiresAt    time.Time
 }
 
 // EngineStore is the narrow storage interface used by the trading engine.
@@ -71,7 +125,10 @@ type EngineStore interface {
 	InsertPositionState(ctx context.Context, tenantID uuid.UUID, s *EnginePositionState) error
 
 	// UpdatePositionState updates the mutable risk fields (trailing stop, peak
-	// price, stop loss, take profit) for an existing position state entry.
+	// price, stop loss, take profit, protective order IDs, and the entry price,
+	// hard stop, quantity, entries and ladder progress that change on scale-in
+	// and partial close, and the break-even stop state) for an existing
+	// position state entry.
 	UpdatePositionState(ctx context.Context, tenantID uuid.UUID, s *EnginePositionState) error
 
 	// DeletePositionState removes the position state entry for a closed position.
@@ -81,4 +138,47 @@ type EngineStore interface {
 	// midnight UTC today, for the given account. Returns 0 when no trades have
 	// been closed today.
 	DailyRealizedPnL(ctx context.Context, accountID string) (float64, error)
+
+	// ListClosingTrades returns the account's ledger trades at or after since
+	// that closed or reduced a position, i.e. that realised P&L.
+	ListClosingTrades(ctx context.Context, accountID string, since time.Time) ([]domain.Trade, error)
+
+	// LoadBreakerStates returns the account's persisted circuit-breaker
+	// states, one per scope (the account, or a strategy on it).
+	LoadBreakerStates(ctx context.Context, accountID string) ([]BreakerState, error)
+
+	// SaveBreakerState persists the circuit-breaker state of one scope,
+	// replacing any previous state.
+	SaveBreakerState(ctx context.Context, s *BreakerState) error
+
+	// LoadPauses returns the tenant's operator pauses on new positions.
+	LoadPauses(ctx context.Context, tenantID uuid.UUID) ([]PauseState, error)
+
+	// SavePause persists an operator pause, replacing any pause on the same
+	// scope.
+	SavePause(ctx context.Context, tenantID uuid.UUID, p *PauseState) error
+
+	// DeletePause removes the pause on the scope; deleting a missing pause is
+	// not an error.
+	DeletePause(ctx context.Context, tenantID uuid.UUID, accountID, strategy string) error
+
+	// LoadPendingEntries returns the account's resting entry orders.
+	LoadPendingEntries(ctx context.Context, accountID string) ([]PendingEntryState, error)
+
+	// SavePendingEntry persists a resting entry order, replacing any entry
+	// for the same symbol.
+	SavePendingEntry(ctx context.Context, p *PendingEntryState) error
+
+	// DeletePendingEntry removes the resting entry for account+symbol;
+	// deleting a missing entry is not an error.
+	DeletePendingEntry(ctx context.Context, accountID, symbol string) error
+
+	// ClaimSignal records that signalID is being processed for the account.
+	// Returns false when it was already claimed — by an earlier delivery or by
+	// another engine instance — within ttl.
+	ClaimSignal(ctx context.Context, accountID, signalID string, ttl time.Duration) (bool, error)
+
+	// ReleaseSignal drops the claim on signalID for the account, so a later
+	// delivery is processed again; releasing a missing claim is not an error.
+	ReleaseSignal(ctx context.Context, accountID, signalID string) error
 }
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TradingConfigStatus describes the engine's cached copy of the tenant's
+// trading configs.
+type TradingConfigStatus struct {
+	Configs   int       `json:"configs"` // enabled account+product configs
+	Slots     int       `json:"slots"`   // allowlisted (exchange, product, granularity, strategy) slots
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"` // last successful fetch or 304 revalidation
+	Changed   bool      `json:"changed"`    // whether the last refresh replaced the cache
+}
+
+// indexTradingConfigs builds the signal allowlist and the account+product
+// index from the enabled trading configs.
+func indexTradingConfigs(configs []TradingConfig) (signalAllowlist, tradingConfigByProduct) {
+	allowlist := make(signalAllowlist)
+	byProduct := make(tradingConfigByProduct)
+	for i := range configs {
+		tc := configs[i]
+		if !tc.Enabled {
+			continue
+		}
+		byProduct[tradingConfigKey{accountID: tc.AccountID, productID: tc.ProductID}] = &tc
+		var strategies []string
+		strategies = append(strategies, tc.StrategiesLong...)
+		strategies = append(strategies, tc.StrategiesShort...)
+		strategies = append(strategies, tc.StrategiesSpot...)
+		for _, strat := range strategies {
+			allowlist[signalKey{
+				exchange:    tc.Exchange,
+				product:     tc.ProductID,
+				granularity: tc.Granularity,
+				strategy:    strat,
+			}] = struct{}{}
+		}
+	}
+	return allowlist, byProduct
+}
+
+// fetchTradingConfigs fetches the tenant's trading configs from GET
+// /config/trading. With a non-empty etag the request is conditional; a 304
+// returns notModified and no configs. A shadow engine with
+// SHADOW_TRADING_CONFIG_FILE set reads that file instead.
+func (e *Engine) fetchTradingConfigs(ctx context.Context, etag string) (configs []TradingConfig, newETag string, notModified bool, err error) {
+	if e.cfg.TradingMode == "shadow" && e.cfg.ShadowConfigFile != "" {
+		return e.readShadowTradingConfigs(etag)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.cfg.TraderAPIURL+"/config/trading", nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("build config request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+e.cfg.SNAPIKey)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("fetch trading configs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, true, nil
+	case http.StatusOK:
+	default:
+		return nil, "", false, fmt.Errorf("trading config API returned %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&configs); err != nil {
+		return nil, "", false, fmt.Errorf("decode trading configs: %w", err)
+	}
+	return configs, resp.Header.Get("ETag"), false, nil
+}
+
+// refreshTradingConfigs revalidates the trading config cache against the
+// platform and replaces it when the configs changed. Concurrent refreshes are
+// collapsed into one request at a time.
+func (e *Engine) refreshTradingConfigs(ctx context.Context) (TradingConfigStatus, error) {
+	e.configRefreshMu.Lock()
+	defer e.configRefreshMu.Unlock()
+
+	e.configMu.RLock()
+	etag := e.configETag
+	e.configMu.RUnlock()
+
+	configs, newETag, notModified, err := e.fetchTradingConfigs(ctx, etag)
+	if err != nil {
+		return e.TradingConfigStatus(), err
+	}
+
+	now := time.Now().UTC()
+	e.configMu.Lock()
+	e.configFetchedAt = now
+	if !notModified {
+		e.allowlist, e.tradingConfigs = indexTradingConfigs(configs)
+		e.configETag = newETag
+	}
+	e.configMu.Unlock()
+
+	status := e.TradingConfigStatus()
+	status.Changed = !notModified
+	return status, nil
+}
+
+// tradingConfig returns the cached trading config for account+product.
+func (e *Engine) tradingConfig(accountID, product string) (*TradingConfig, bool) {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	tc, ok := e.tradingConfigs[tradingConfigKey{accountID: accountID, productID: product}]
+	return tc, ok
+}
+
+// TradingConfigStatus reports the state of the trading config cache.
+func (e *Engine) TradingConfigStatus() TradingConfigStatus {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	return TradingConfigStatus{
+		Configs:   len(e.tradingConfigs),
+		Slots:     len(e.allowlist),
+		ETag:      e.configETag,
+		FetchedAt: e.configFetchedAt,
+	}
+}
+
+// RefreshTradingConfigs refreshes the trading config cache immediately, for
+// config changes that should not wait for the next timed refresh.
+func (e *Engine) RefreshTradingConfigs(ctx context.Context) (TradingConfigStatus, error) {
+	status, err := e.refreshTradingConfigs(ctx)
+	if err != nil {
+		return status, err
+	}
+	e.logger.Info().
+		Int("configs", status.Configs).
+		Int("slots", status.Slots).
+		Bool("changed", status.Changed).
+		Msg("trading configs refreshed on request")
+	return status, nil
+}
+
+// startConfigRefresher revalidates the trading config cache every
+// CONFIG_REFRESH_INTERVAL. A zero interval leaves refreshes to the API.
+func (e *Engine) startConfigRefresher(ctx context.Context) {
+	if e.cfg.ConfigRefreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(e.cfg.ConfigRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			status, err := e.refreshTradingConfigs(ctx)
+			if err != nil {
+				e.logger.Warn().Err(err).Msg("failed to refresh trading configs")
+				continue
+			}
+			if status.Changed {
+				e.logger.Info().Int("configs", status.Configs).Int("slots", status.Slots).Msg("trading configs changed")
+			} else {
+				e.logger.Debug().Msg("trading configs unchanged")
+			}
+		}
+	}
+}
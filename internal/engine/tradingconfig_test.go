@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Signal-ngn/trader/internal/config"
+)
+
+// configServer serves /config/trading with an ETag and honours If-None-Match.
+type configServer struct {
+	etag     string
+	configs  []TradingConfig
+	requests atomic.Int32
+	notMod   atomic.Int32
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notMod.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	_ = json.NewEncoder(w).Encode(s.configs)
+}
+
+func TestIndexTradingConfigs_SkipsDisabled(t *testing.T) {
+	al, byProduct := indexTradingConfigs([]TradingConfig{
+		{AccountID: "acc", ProductID: "BTC-USD", Exchange: "binance", Granularity: "ONE_HOUR", StrategiesLong: []string{"trend"}, StrategiesShort: []string{"trend_short"}, Enabled: true},
+		{AccountID: "acc", ProductID: "ETH-USD", Exchange: "binance", Granularity: "ONE_HOUR", StrategiesSpot: []string{"trend"}, Enabled: false},
+	})
+	if len(byProduct) != 1 || len(al) != 2 {
+		t.Fatalf("want 1 config and 2 slots, got %d and %d", len(byProduct), len(al))
+	}
+	if !al.allows("binance", "BTC-USD", "ONE_HOUR", "trend_short") {
+		t.Error("short slot should be allowed")
+	}
+	if al.allows("binance", "ETH-USD", "ONE_HOUR", "trend") {
+		t.Error("disabled config should not be allowlisted")
+	}
+}
+
+func TestRefreshTradingConfigs_RevalidatesWithETag(t *testing.T) {
+	cs := &configServer{etag: `"v1"`, configs: []TradingConfig{
+		{AccountID: "acc", ProductID: "BTC-USD", Exchange: "binance", StrategiesLong: []string{"trend"}, LongLeverage: 3, Enabled: true},
+	}}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	e := makeEngine(&config.Config{TraderAPIURL: srv.URL})
+	ctx := context.Background()
+
+	status, err := e.refreshTradingConfigs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Changed || status.Configs != 1 || status.ETag != `"v1"` {
+		t.Fatalf("first load: %+v", status)
+	}
+
+	// Lookups are served from the cache.
+	for i := 0; i < 50; i++ {
+		if tc, ok := e.tradingConfig("acc", "BTC-USD"); !ok || tc.LongLeverage != 3 {
+			t.Fatalf("cached config: %+v %v", tc, ok)
+		}
+	}
+	if n := cs.requests.Load(); n != 1 {
+		t.Fatalf("lookups must not hit the API, got %d requests", n)
+	}
+
+	status, err = e.refreshTradingConfigs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Changed || cs.notMod.Load() != 1 || status.Configs != 1 {
+		t.Fatalf("unchanged refresh should be a 304 that keeps the cache: %+v", status)
+	}
+
+	cs.etag = `"v2"`
+	cs.configs[0].LongLeverage = 5
+	if status, _ = e.refreshTradingConfigs(ctx); !status.Changed {
+		t.Fatal("new ETag should replace the cache")
+	}
+	if tc, _ := e.tradingConfig("acc", "BTC-USD"); tc.LongLeverage != 5 {
+		t.Errorf("want updated leverage 5, got %d", tc.LongLeverage)
+	}
+}
+
+func TestRefreshTradingConfigs_KeepsCacheOnError(t *testing.T) {
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]TradingConfig{{AccountID: "acc", ProductID: "BTC-USD", Enabled: true}})
+	}))
+	defer srv.Close()
+	e := makeEngine(&config.Config{TraderAPIURL: srv.URL})
+
+	if _, err := e.refreshTradingConfigs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fail.Store(true)
+	if _, err := e.refreshTradingConfigs(context.Background()); err == nil {
+		t.Fatal("want error from a failing API")
+	}
+	if _, ok := e.tradingConfig("acc", "BTC-USD"); !ok {
+		t.Error("a failed refresh must keep the last good configs")
+	}
+}
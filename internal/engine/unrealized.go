@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// markPrice returns the price open positions in symbol are marked at: the
+// last signal price, else the SN price API, which also warms the cache.
+func (e *Engine) markPrice(ctx context.Context, symbol string) (float64, error) {
+	e.lastPriceMu.RLock()
+	price := e.lastPrice[symbol]
+	e.lastPriceMu.RUnlock()
+	if price > 0 {
+		return price, nil
+	}
+
+	exchange := e.exchangeForProduct(symbol)
+	if exchange == "" {
+		return 0, fmt.Errorf("no cached price for %s and exchange unknown", symbol)
+	}
+	price, err := fetchCurrentPrice(ctx, e.cfg, exchange, symbol)
+	if err != nil {
+		return 0, err
+	}
+	e.lastPriceMu.Lock()
+	e.lastPrice[symbol] = price
+	e.lastPriceMu.Unlock()
+	e.observePrice(symbol, price)
+	return price, nil
+}
+
+// unrealizedPnL returns the P&L of an open position marked at price.
+func unrealizedPnL(p domain.Position, price float64) float64 {
+	pnl := (price - p.AvgEntryPrice) * p.Quantity
+	if p.Side == domain.PositionSideShort {
+		return -pnl
+	}
+	return pnl
+}
+
+// accountUnrealizedPnL marks the account's open ledger positions to market.
+// Positions that cannot be priced count as flat and are logged.
+func (e *Engine) accountUnrealizedPnL(ctx context.Context, accountID string) (float64, error) {
+	positions, err := e.repo.ListOpenPositionsForAccount(ctx, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("list open positions: %w", err)
+	}
+	var pnl float64
+	for _, p := range positions {
+		price, err := e.markPrice(ctx, p.Symbol)
+		if err != nil {
+			e.logger.Warn().Err(err).Str("account", accountID).Str("symbol", p.Symbol).
+				Msg("unrealised P&L: cannot price open position — counting it as flat")
+			continue
+		}
+		pnl += unrealizedPnL(p, price)
+	}
+	return pnl, nil
+}
+
+// dailyLoss returns the account's loss today in USD, 0 when it is up:
+// realised P&L since midnight UTC, plus open positions marked to market when
+// withUnrealized is set. When the positions cannot be loaded the realised
+// loss alone is returned with the error.
+func (e *Engine) dailyLoss(ctx context.Context, accountID string, withUnrealized bool) (float64, error) {
+	pnl, err := e.repo.DailyRealizedPnL(ctx, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("daily realised P&L: %w", err)
+	}
+	if withUnrealized {
+		unrealized, err := e.accountUnrealizedPnL(ctx, accountID)
+		if err != nil {
+			return max(-pnl, 0), err
+		}
+		pnl += unrealized
+	}
+	return max(-pnl, 0), nil
+}
+
+// flattenedToday reports whether the account was flattened on
+// FLATTEN_LOSS_LIMIT earlier in the current UTC day.
+func (e *Engine) flattenedToday(accountID string) bool {
+	e.flattenMu.Lock()
+	defer e.flattenMu.Unlock()
+	return e.flattened[accountID] == time.Now().UTC().Format(time.DateOnly)
+}
+
+// checkFlattenLimits flattens every managed account whose loss today,
+// realised plus unrealised, has reached FLATTEN_LOSS_LIMIT.
+func (e *Engine) checkFlattenLimits(ctx context.Context) {
+	for _, accountID := range e.accounts {
+		loss, err := e.dailyLoss(ctx, accountID, true)
+		if err != nil {
+			e.logger.Warn().Err(err).Str("account", accountID).Msg("flatten check: cannot compute today's loss")
+			if loss == 0 {
+				continue
+			}
+		}
+		if loss < e.cfg.FlattenLossLimit {
+			continue
+		}
+		e.flattenMu.Lock()
+		e.flattened[accountID] = time.Now().UTC().Format(time.DateOnly)
+		e.flattenMu.Unlock()
+		e.flattenAccount(ctx, accountID, fmt.Sprintf("Flatten: loss today $%.2f reached limit $%.2f", loss, e.cfg.FlattenLossLimit))
+	}
+}
+
+// flattenAccount closes every engine-managed position on the account at its
+// mark price and returns how many closes were recorded. Positions already
+// closing are skipped; a position whose close fails stays managed.
+func (e *Engine) flattenAccount(ctx context.Context, accountID, exitReason string) int {
+	e.posStateMu.RLock()
+	var states []*PositionState
+	for _, ps := range e.posState {
+		if ps.AccountID == accountID {
+			states = append(states, ps)
+		}
+	}
+	e.posStateMu.RUnlock()
+	if len(states) == 0 {
+		return 0
+	}
+
+	e.logger.Error().Str("account", accountID).Int("positions", len(states)).Str("reason", exitReason).
+		Msg("flattening account")
+	closed := 0
+	for _, ps := range states {
+		ps := ps
+		e.serialize(ctx, posKey(ps.AccountID, ps.Symbol), func() {
+			price, err := e.markPrice(ctx, ps.Symbol)
+			if err != nil && e.cfg.TradingMode != "live" {
+				e.logger.Error().Err(err).Str("account", accountID).Str("symbol", ps.Symbol).
+					Msg("flatten: cannot price position — leaving it open")
+				return
+			}
+			e.posStateMu.Lock()
+			psInMap, exists := e.posState[posKey(ps.AccountID, ps.Symbol)]
+			if !exists || psInMap.Closing {
+				e.posStateMu.Unlock()
+				return
+			}
+			psInMap.Closing = true
+			e.posStateMu.Unlock()
+
+			if e.executeCloseTrade(ctx, ps, price, exitReason) {
+				closed++
+				return
+			}
+
+			// Hand the position back to the risk loop and later flattens.
+			e.posStateMu.Lock()
+			psInMap.Closing = false
+			e.posStateMu.Unlock()
+		})
+	}
+	return closed
+}
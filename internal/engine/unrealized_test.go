@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Signal-ngn/trader/internal/config"
+	"github.com/Signal-ngn/trader/internal/domain"
+)
+
+// dailyLossStore adds today's realised P&L to a single open ledger position.
+type dailyLossStore struct {
+	ladderStore
+	realized float64
+}
+
+func (s *dailyLossStore) DailyRealizedPnL(_ context.Context, _ string) (float64, error) {
+	return s.realized, nil
+}
+
+func TestUnrealizedPnL(t *testing.T) {
+	long := domain.Position{Side: domain.PositionSideLong, Quantity: 2, AvgEntryPrice: 100}
+	short := domain.Position{Side: domain.PositionSideShort, Quantity: 2, AvgEntryPrice: 100}
+	assertFloat(t, "long underwater", -20, unrealizedPnL(long, 90))
+	assertFloat(t, "short in profit", 20, unrealizedPnL(short, 90))
+}
+
+func TestIsDailyLossLimitReached_IncludesUnrealized(t *testing.T) {
+	store := &dailyLossStore{realized: -50, ladderStore: ladderStore{position: domain.Position{
+		AccountID: "acc", Symbol: "BTC-USD", Side: domain.PositionSideLong, Quantity: 1, AvgEntryPrice: 50000,
+	}}}
+	cfg := &config.Config{DailyLossLimit: 120}
+	e := makeEngine(cfg)
+	e.repo = store
+	e.lastPrice["BTC-USD"] = 49900
+	ctx := context.Background()
+
+	if e.isDailyLossLimitReached(ctx, "acc") {
+		t.Error("$50 realised loss is under the limit")
+	}
+	cfg.DailyLossIncludeUnrealized = true
+	if !e.isDailyLossLimitReached(ctx, "acc") {
+		t.Error("$50 realised plus $100 unrealised loss should reach the limit")
+	}
+}
+
+func TestCheckFlattenLimits_ClosesPositionsAndPausesOpens(t *testing.T) {
+	store := &dailyLossStore{realized: -200, ladderStore: ladderStore{position: domain.Position{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot,
+		Side: domain.PositionSideLong, Quantity: 1, AvgEntryPrice: 50000,
+	}}}
+	cfg := &config.Config{TradingMode: "paper", FlattenLossLimit: 1000}
+	e := makeEngine(cfg)
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	e.accounts = []string{"acc"}
+	e.posState[posKey("acc", "BTC-USD")] = &PositionState{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: "spot", Side: "long", EntryPrice: 50000, Quantity: 1,
+	}
+	ctx := context.Background()
+
+	e.lastPrice["BTC-USD"] = 49500 // $200 realised + $500 unrealised
+	e.checkFlattenLimits(ctx)
+	if len(store.trades) != 0 || e.flattenedToday("acc") {
+		t.Fatal("a $700 loss is under the flatten limit")
+	}
+
+	e.lastPrice["BTC-USD"] = 49000 // $200 realised + $1,000 unrealised
+	e.checkFlattenLimits(ctx)
+	if len(store.trades) != 1 {
+		t.Fatalf("want 1 close trade, got %d", len(store.trades))
+	}
+	assertFloat(t, "close price", 49000, store.trades[0].Price)
+	if !strings.HasPrefix(*store.trades[0].ExitReason, "Flatten: loss today $1200.00") {
+		t.Errorf("exit reason: got %q", *store.trades[0].ExitReason)
+	}
+	if !e.flattenedToday("acc") {
+		t.Error("account should stay flattened for the rest of the day")
+	}
+
+	// Opens are skipped before the balance or exchange is touched.
+	e.exchange = NewNoopExchange(cfg)
+	e.exchanges = NewPaperExchangeRegistry(e.exchange)
+	e.handleOpenSignal(ctx, SignalPayload{Action: "BUY", Price: 49000}, "ETH-USD", "trend", "acc", &TradingConfig{Exchange: "binance"})
+	if _, open := e.posState[posKey("acc", "ETH-USD")]; open {
+		t.Error("a flattened account must not open positions")
+	}
+}
+
+func TestFlattenAccount_FailedCloseStaysManaged(t *testing.T) {
+	store := &ladderStore{} // no ledger quantity: the close fails
+	e := makeEngine(&config.Config{TradingMode: "paper"})
+	e.repo = store
+	e.tenantUUID = uuid.New()
+	e.posState[posKey("acc", "BTC-USD")] = &PositionState{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: "spot", Side: "long", EntryPrice: 50000, Quantity: 1,
+	}
+	e.lastPrice["BTC-USD"] = 49000
+	ctx := context.Background()
+
+	if n := e.flattenAccount(ctx, "acc", "Flatten: operator request"); n != 0 {
+		t.Fatalf("want no closes, got %d", n)
+	}
+	ps := e.posState[posKey("acc", "BTC-USD")]
+	if ps == nil || ps.Closing {
+		t.Fatalf("a failed close must leave the position managed, got %+v", ps)
+	}
+
+	store.position = domain.Position{
+		AccountID: "acc", Symbol: "BTC-USD", MarketType: domain.MarketTypeSpot,
+		Side: domain.PositionSideLong, Quantity: 1, AvgEntryPrice: 50000,
+	}
+	if n := e.flattenAccount(ctx, "acc", "Flatten: operator request"); n != 1 || len(store.trades) != 1 {
+		t.Errorf("retry: want 1 close, got %d (%d trades)", n, len(store.trades))
+	}
+}
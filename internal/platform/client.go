@@ -311,6 +311,70 @@ func (c *PlatformClient) GetPortfolio(ctx context.Context, accountID string) (*P
 	return &portfolio, nil
 }
 
+// LedgerTrade is a trade as returned by the ledger trade list.
+type LedgerTrade struct {
+	TradeID     string    `json:"trade_id"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`
+	MarketType  string    `json:"market_type"`
+	Timestamp   time.Time `json:"timestamp"`
+	CostBasis   float64   `json:"cost_basis"`
+	RealizedPnL float64   `json:"realized_pnl"`
+	Strategy    *string   `json:"strategy,omitempty"`
+	ExitReason  *string   `json:"exit_reason,omitempty"`
+}
+
+// ListTrades calls GET /api/v1/accounts/{id}/trades and returns every trade
+// at or after since, following next_cursor across pages.
+func (c *PlatformClient) ListTrades(ctx context.Context, accountID string, since time.Time) ([]LedgerTrade, error) {
+	var trades []LedgerTrade
+	cursor := ""
+	for {
+		q := url.Values{}
+		q.Set("start", since.UTC().Format(time.RFC3339))
+		q.Set("limit", "500")
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL("/api/v1/accounts/"+accountID+"/trades", q), nil)
+		if err != nil {
+			return nil, fmt.Errorf("build request: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if c.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request: %w", err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, &APIError{StatusCode: resp.StatusCode, Body: string(b)}
+		}
+
+		var page struct {
+			Trades     []LedgerTrade `json:"trades"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(b, &page); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+		trades = append(trades, page.Trades...)
+		if page.NextCursor == "" {
+			return trades, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
 // tradePayload is the request body for POST /api/v1/trades.
 type tradePayload struct {
 	TenantID    string   `json:"tenant_id"`